@@ -0,0 +1,300 @@
+// Package client is a Go client for the project management API.
+//
+//	c, err := client.New("http://localhost:8080", client.WithToken("pm_..."))
+//	task, err := c.GetTask(ctx, 42)
+//	it := c.IterTasks(ctx)
+//	for it.Next() {
+//		fmt.Println(it.Value().Title)
+//	}
+//
+// Requests that fail with a 5xx or 429 status or a network error are retried
+// with exponential backoff when it is safe: GET, PUT and DELETE requests, and
+// POST requests that create something, which carry an Idempotency-Key. Those
+// are also retried on 409, which means an earlier attempt is still running.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultBackoff    = 200 * time.Millisecond
+	maxBackoff        = 10 * time.Second
+)
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	userID     int
+	token      string
+	userAgent  string
+	maxRetries int
+	backoff    time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests, http.DefaultClient
+// by default.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithUserID makes requests on behalf of a user, sent as the X-User-ID header.
+// Servers accept it only behind a gateway, with auth.trust_user_header set;
+// use WithToken otherwise.
+func WithUserID(id int) Option {
+	return func(c *Client) { c.userID = id }
+}
+
+// WithRetries sets how many times a failed request is retried and the delay
+// before the first retry, which doubles with every attempt. Zero retries
+// turns retrying off.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithToken sends token as a bearer token in the Authorization header.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// New returns a client for the API at baseURL, such as
+// "https://pm.example.com".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("client: base URL %q must be absolute", baseURL)
+	}
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		userAgent:  "pm-go-client",
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// request describes one API call.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	// body is sent as JSON unless raw is set.
+	body        any
+	raw         []byte
+	contentType string
+	header      http.Header
+	// idempotent makes a POST safe to retry by sending an Idempotency-Key.
+	idempotent bool
+}
+
+// do sends the request and decodes a JSON response into out, if not nil.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decoding response of %s %s: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// send sends the request, retrying it when that is safe, and returns the
+// response if its status is below 400. The caller closes the body.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	body := req.raw
+	contentType := req.contentType
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+		contentType = "application/json"
+	}
+
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	header := http.Header{}
+	for k, v := range req.header {
+		header[k] = v
+	}
+	header.Set("Accept", "application/json")
+	header.Set("User-Agent", c.userAgent)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if c.userID != 0 {
+		header.Set("X-User-ID", strconv.Itoa(c.userID))
+	}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	retryable := req.method != http.MethodPost
+	if req.idempotent && header.Get("Idempotency-Key") == "" {
+		header.Set("Idempotency-Key", newKey())
+		retryable = true
+	}
+	keyed := header.Get("Idempotency-Key") != ""
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header = header.Clone()
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err == nil && resp.StatusCode < 400 {
+			return resp, nil
+		}
+		var apiErr *Error
+		var retryAfter time.Duration
+		if err == nil {
+			apiErr = newError(req.method, req.path, resp)
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			err = apiErr
+		}
+		canRetry := retryable && (apiErr == nil || apiErr.temporary(keyed)) && ctx.Err() == nil
+		if !canRetry || attempt >= c.maxRetries {
+			return nil, err
+		}
+		if err := sleep(ctx, max(retryAfter, c.delay(attempt))); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// delay is the backoff before retry number attempt+1, with jitter.
+func (c *Client) delay(attempt int) time.Duration {
+	d := time.Duration(float64(c.backoff) * math.Pow(2, float64(attempt)))
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(mathrand.Int63n(int64(d/2)+1))
+}
+
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func newKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func idPath(format string, ids ...int) string {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Health reports whether the service is up.
+func (c *Client) Health(ctx context.Context) error {
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/health"})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// GraphQL runs a query against /graphql and decodes its data into out. Errors
+// reported by the server are returned as *GraphQLError.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/graphql",
+		body:   map[string]any{"query": query, "variables": variables},
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		gqlErr := &GraphQLError{}
+		for _, e := range resp.Errors {
+			gqlErr.Messages = append(gqlErr.Messages, e.Message)
+		}
+		return gqlErr
+	}
+	if out == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// GraphQLError lists the errors a GraphQL query failed with.
+type GraphQLError struct {
+	Messages []string
+}
+
+func (e *GraphQLError) Error() string {
+	return "graphql: " + strings.Join(e.Messages, "; ")
+}
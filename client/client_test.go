@@ -0,0 +1,145 @@
+package client_test
+
+import (
+	"HL_project_management/client"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stub serves the responses in order, repeating the last one, and records
+// the requests it got.
+type stub struct {
+	*httptest.Server
+	calls    atomic.Int32
+	keys     []string
+	statuses []int
+	header   http.Header
+}
+
+func newStub(t *testing.T, header http.Header, statuses ...int) *stub {
+	s := &stub{statuses: statuses, header: header}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(s.calls.Add(1))
+		s.keys = append(s.keys, r.Header.Get("Idempotency-Key"))
+		status := s.statuses[min(n, len(s.statuses))-1]
+		for k, v := range s.header {
+			w.Header()[k] = v
+		}
+		if status >= 400 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":1,"title":"Write tests"}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newClient(t *testing.T, url string, opts ...client.Option) *client.Client {
+	c, err := client.New(url, append([]client.Option{client.WithRetries(3, time.Millisecond)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		statuses []int
+		call     func(c *client.Client) error
+		calls    int
+		err      error
+	}{
+		{"get after 503", []int{503, 502, 200}, func(c *client.Client) error {
+			_, err := c.GetTask(ctx, 1)
+			return err
+		}, 3, nil},
+		{"get after 429", []int{429, 200}, func(c *client.Client) error {
+			_, err := c.GetTask(ctx, 1)
+			return err
+		}, 2, nil},
+		{"gives up after max retries", []int{500}, func(c *client.Client) error {
+			_, err := c.GetTask(ctx, 1)
+			return err
+		}, 4, client.ErrServer},
+		{"put after 503", []int{503, 200}, func(c *client.Client) error {
+			_, err := c.UpdateTask(ctx, 1, client.Task{})
+			return err
+		}, 2, nil},
+		{"create after 500", []int{500, 201}, func(c *client.Client) error {
+			_, err := c.CreateTask(ctx, client.Task{})
+			return err
+		}, 2, nil},
+		{"create while the first attempt is running", []int{409, 409, 201}, func(c *client.Client) error {
+			_, err := c.CreateTask(ctx, client.Task{})
+			return err
+		}, 3, nil},
+		{"put conflict is final", []int{409, 200}, func(c *client.Client) error {
+			_, err := c.UpdateTask(ctx, 1, client.Task{})
+			return err
+		}, 1, client.ErrConflict},
+		{"post without a key is not retried", []int{503, 200}, func(c *client.Client) error {
+			return c.GraphQL(ctx, "{ tasks { id } }", nil, nil)
+		}, 1, client.ErrServer},
+		{"client errors are not retried", []int{404, 200}, func(c *client.Client) error {
+			_, err := c.GetTask(ctx, 1)
+			return err
+		}, 1, client.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStub(t, nil, tt.statuses...)
+			err := tt.call(newClient(t, s.URL))
+			if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if got := int(s.calls.Load()); got != tt.calls {
+				t.Errorf("%d requests, want %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestRetryKeepsIdempotencyKey(t *testing.T) {
+	s := newStub(t, nil, 503, 409, 201)
+	if _, err := newClient(t, s.URL).CreateTask(context.Background(), client.Task{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.keys) != 3 || s.keys[0] == "" || s.keys[1] != s.keys[0] || s.keys[2] != s.keys[0] {
+		t.Errorf("Idempotency-Key of each attempt = %q, want the same non-empty key", s.keys)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusConflict} {
+		s := newStub(t, http.Header{"Retry-After": {"1"}}, status, 201)
+		start := time.Now()
+		if _, err := newClient(t, s.URL).CreateTask(context.Background(), client.Task{}); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("retry after %d came after %v, want Retry-After of 1s", status, elapsed)
+		}
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	s := newStub(t, nil, 503)
+	c := newClient(t, s.URL, client.WithRetries(10, time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetTask(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if got := s.calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
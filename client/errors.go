@@ -0,0 +1,102 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by errors.Is against an *Error with the
+// corresponding status code.
+var (
+	ErrBadRequest          = errors.New("bad request")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrTooLarge            = errors.New("request too large")
+	ErrUnprocessable       = errors.New("unprocessable entity")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrServer              = errors.New("server error")
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+)
+
+const maxErrorBody = 1 << 20
+
+// Error is returned for responses with a status of 400 or above.
+type Error struct {
+	StatusCode int
+	// Message is the text the server responded with.
+	Message string
+	Method  string
+	Path    string
+	// Body is the raw response body. Some endpoints, like batches and
+	// imports, explain a 422 with a JSON report.
+	Body []byte
+}
+
+func newError(method, path string, resp *http.Response) *Error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return &Error{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		Method:     method,
+		Path:       path,
+		Body:       body,
+	}
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrTooLarge:
+		return e.StatusCode == http.StatusRequestEntityTooLarge
+	case ErrUnprocessable:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	case ErrRangeNotSatisfiable:
+		return e.StatusCode == http.StatusRequestedRangeNotSatisfiable
+	}
+	return false
+}
+
+// temporary reports whether the request may succeed if sent again. A request
+// carrying an Idempotency-Key gets 409 while an earlier attempt with the same
+// key is still being handled; once that finishes, its response is replayed.
+func (e *Error) temporary(keyed bool) bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests ||
+		keyed && e.StatusCode == http.StatusConflict
+}
+
+// decodeReport decodes the JSON body of a 422 error into out.
+func decodeReport(err error, out any) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	return json.Unmarshal(apiErr.Body, out) == nil
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// DefaultPageSize is the number of items an iterator fetches per request.
+const DefaultPageSize = 100
+
+// Iterator walks a paginated list page by page:
+//
+//	it := c.IterUsers(ctx)
+//	for it.Next() {
+//		user := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	ctx      context.Context
+	fetch    func(ctx context.Context, query url.Values) ([]T, error)
+	query    url.Values
+	pageSize int
+	offset   int
+	page     []T
+	index    int
+	value    T
+	done     bool
+	err      error
+}
+
+func newIterator[T any](ctx context.Context, c *Client, path string, query url.Values) *Iterator[T] {
+	return &Iterator[T]{
+		ctx: ctx,
+		fetch: func(ctx context.Context, q url.Values) ([]T, error) {
+			var page []T
+			err := c.do(ctx, request{method: "GET", path: path, query: q}, &page)
+			return page, err
+		},
+		query:    query,
+		pageSize: DefaultPageSize,
+	}
+}
+
+// PageSize sets how many items are fetched per request, at most 100. It must
+// be called before the first Next.
+func (it *Iterator[T]) PageSize(n int) *Iterator[T] {
+	if n > 0 {
+		it.pageSize = min(n, 100)
+	}
+	return it
+}
+
+// Next advances to the next item, fetching a page when needed. It returns
+// false when there are no more items or a request failed.
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.index >= len(it.page) {
+		if it.done {
+			return false
+		}
+		if !it.fetchPage() {
+			return false
+		}
+	}
+	it.value = it.page[it.index]
+	it.index++
+	return true
+}
+
+func (it *Iterator[T]) fetchPage() bool {
+	q := url.Values{}
+	for k, v := range it.query {
+		q[k] = v
+	}
+	q.Set("limit", strconv.Itoa(it.pageSize))
+	q.Set("offset", strconv.Itoa(it.offset))
+
+	page, err := it.fetch(it.ctx, q)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page, it.index = page, 0
+	it.offset += len(page)
+	// A short page is the last one.
+	it.done = len(page) < it.pageSize
+	return len(page) > 0
+}
+
+// Value returns the current item.
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All collects the remaining items.
+func (it *Iterator[T]) All() ([]T, error) {
+	var all []T
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
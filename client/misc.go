@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Search types accepted by Search.
+const (
+	SearchTask    = "task"
+	SearchProject = "project"
+	SearchComment = "comment"
+	SearchUser    = "user"
+)
+
+// Search runs a full-text search, such as `deploy* "release notes" -draft`,
+// over the given types, or all of them if none are given.
+func (c *Client) Search(ctx context.Context, query string, types ...string) *Iterator[SearchResult] {
+	q := url.Values{"q": {query}}
+	if len(types) > 0 {
+		q.Set("types", strings.Join(types, ","))
+	}
+	return newIterator[SearchResult](ctx, c, "/search", q)
+}
+
+// Spreadsheet formats accepted by the export methods.
+const (
+	ExportCSV  = "csv"
+	ExportXLSX = "xlsx"
+)
+
+type ExportOptions struct {
+	// Format is ExportCSV, the default, or ExportXLSX.
+	Format string
+	// Columns selects and orders the exported columns, all by default.
+	Columns []string
+	// BOM starts CSV output with a UTF-8 byte order mark.
+	BOM bool
+}
+
+func (c *Client) export(ctx context.Context, path string, query url.Values, opts ExportOptions) (io.ReadCloser, error) {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("format", ExportCSV)
+	setNonEmpty(q, "format", opts.Format)
+	if len(opts.Columns) > 0 {
+		q.Set("columns", strings.Join(opts.Columns, ","))
+	}
+	if opts.BOM {
+		q.Set("bom", "true")
+	}
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: path, query: q})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ExportUsers downloads the users matching s as a spreadsheet. The caller
+// closes the returned reader.
+func (c *Client) ExportUsers(ctx context.Context, s UserSearch, opts ExportOptions) (io.ReadCloser, error) {
+	path := "/search/users"
+	if s == (UserSearch{}) {
+		path = "/users"
+	}
+	return c.export(ctx, path, s.query(), opts)
+}
+
+// MyViews returns the saved views of the client's user and those shared in
+// their projects.
+func (c *Client) MyViews(ctx context.Context) ([]SavedView, error) {
+	var views []SavedView
+	err := c.do(ctx, request{method: http.MethodGet, path: "/me/views"}, &views)
+	return views, err
+}
+
+func (c *Client) GetView(ctx context.Context, id int) (*SavedView, error) {
+	var view SavedView
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/me/views/%d", id)}, &view); err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (c *Client) CreateView(ctx context.Context, view SavedView) (*SavedView, error) {
+	var created SavedView
+	err := c.do(ctx, request{method: http.MethodPost, path: "/me/views", body: view, idempotent: true}, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateView(ctx context.Context, id int, view SavedView) (*SavedView, error) {
+	var updated SavedView
+	if err := c.do(ctx, request{method: http.MethodPut, path: idPath("/me/views/%d", id), body: view}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) DeleteView(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/me/views/%d", id)}, nil)
+}
+
+// IterViewTasks walks the tasks matching a saved view, in its sort order.
+func (c *Client) IterViewTasks(ctx context.Context, id int) *Iterator[Task] {
+	return newIterator[Task](ctx, c, idPath("/views/%d/tasks", id), nil)
+}
+
+// IterNotifications walks the notifications of the client's user, newest
+// first, optionally only the unread ones.
+func (c *Client) IterNotifications(ctx context.Context, unreadOnly bool) *Iterator[Notification] {
+	q := url.Values{}
+	if unreadOnly {
+		q.Set("unread", "true")
+	}
+	return newIterator[Notification](ctx, c, "/me/notifications", q)
+}
+
+func (c *Client) MarkNotificationRead(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/me/notifications/%d/read", id)}, nil)
+}
+
+func (c *Client) MarkAllNotificationsRead(ctx context.Context) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/me/notifications/read-all"}, nil)
+}
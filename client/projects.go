@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListProjects returns all projects.
+func (c *Client) ListProjects(ctx context.Context) ([]Project, error) {
+	var projects []Project
+	err := c.do(ctx, request{method: http.MethodGet, path: "/projects"}, &projects)
+	return projects, err
+}
+
+// IterProjects walks all projects page by page.
+func (c *Client) IterProjects(ctx context.Context) *Iterator[Project] {
+	return newIterator[Project](ctx, c, "/projects", nil)
+}
+
+func (c *Client) GetProject(ctx context.Context, id int) (*Project, error) {
+	var project Project
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/projects/%d", id)}, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (c *Client) CreateProject(ctx context.Context, project Project) (*Project, error) {
+	var created Project
+	err := c.do(ctx, request{method: http.MethodPost, path: "/projects", body: project, idempotent: true}, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateProject(ctx context.Context, id int, project Project) (*Project, error) {
+	var updated Project
+	if err := c.do(ctx, request{method: http.MethodPut, path: idPath("/projects/%d", id), body: project}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) DeleteProject(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/projects/%d", id)}, nil)
+}
+
+// IterProjectTasks walks the tasks of a project.
+func (c *Client) IterProjectTasks(ctx context.Context, id int) *Iterator[Task] {
+	return newIterator[Task](ctx, c, idPath("/projects/%d/tasks", id), nil)
+}
+
+// ProjectSearch filters projects by title and manager; empty fields are
+// ignored.
+type ProjectSearch struct {
+	Title     string
+	ManagerID int
+}
+
+func (s ProjectSearch) query() url.Values {
+	q := url.Values{}
+	setNonEmpty(q, "title", s.Title)
+	setNonZero(q, "manager", s.ManagerID)
+	return q
+}
+
+// SearchProjects walks the projects matching s.
+func (c *Client) SearchProjects(ctx context.Context, s ProjectSearch) *Iterator[Project] {
+	return newIterator[Project](ctx, c, "/search/projects", s.query())
+}
+
+// ExportProjects downloads the projects matching s as a spreadsheet. The
+// caller closes the returned reader.
+func (c *Client) ExportProjects(ctx context.Context, s ProjectSearch, opts ExportOptions) (io.ReadCloser, error) {
+	path := "/search/projects"
+	if s == (ProjectSearch{}) {
+		path = "/projects"
+	}
+	return c.export(ctx, path, s.query(), opts)
+}
+
+func (c *Client) BatchProjects(ctx context.Context, ops []BatchOperation, continueOnError bool) (*BatchResponse, error) {
+	return c.batch(ctx, "/projects:batch", ops, continueOnError)
+}
+
+func (c *Client) GetProjectWatchers(ctx context.Context, id int) ([]User, error) {
+	var users []User
+	err := c.do(ctx, request{method: http.MethodGet, path: idPath("/projects/%d/watchers", id)}, &users)
+	return users, err
+}
+
+func (c *Client) WatchProject(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/projects/%d/watchers", id)}, nil)
+}
+
+func (c *Client) UnwatchProject(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/projects/%d/watchers", id)}, nil)
+}
+
+// Import formats accepted by ImportTasks.
+const (
+	ImportCSV    = "csv"
+	ImportJira   = "jira"
+	ImportTrello = "trello"
+)
+
+// ImportOptions configures ImportTasks. Mapping maps task fields (title,
+// description, priority, status, assignee, due) to CSV column names.
+type ImportOptions struct {
+	Format          string
+	Mapping         map[string]string
+	DefaultAssignee int
+	DryRun          bool
+}
+
+// ImportTasks creates tasks in a project from an exported file. If any row
+// is invalid nothing is imported and the report listing the problems is
+// returned together with an *Error matching ErrUnprocessable.
+func (c *Client) ImportTasks(ctx context.Context, projectID int, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	setNonEmpty(q, "format", opts.Format)
+	if len(opts.Mapping) > 0 {
+		mapping, err := json.Marshal(opts.Mapping)
+		if err != nil {
+			return nil, err
+		}
+		q.Set("mapping", string(mapping))
+	}
+	setNonZero(q, "default_assignee", opts.DefaultAssignee)
+	if opts.DryRun {
+		q.Set("dry_run", strconv.FormatBool(true))
+	}
+
+	contentType := "text/csv"
+	if opts.Format == ImportTrello {
+		contentType = "application/json"
+	}
+	var report ImportReport
+	err = c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        idPath("/projects/%d/import", projectID),
+		query:       q,
+		raw:         data,
+		contentType: contentType,
+		idempotent:  true,
+	}, &report)
+	if err != nil && !decodeReport(err, &report) {
+		return nil, err
+	}
+	return &report, err
+}
@@ -0,0 +1,202 @@
+package client_test
+
+import (
+	"HL_project_management/client"
+	"HL_project_management/internal/config"
+	"HL_project_management/internal/ratelimit"
+	"HL_project_management/internal/repository"
+	"HL_project_management/internal/router"
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// newServer serves the API's router. Tests that need the database skip
+// unless PM_TEST_DATABASE_URL points at a PostgreSQL database, which is
+// migrated first.
+func newServer(t *testing.T, needDB bool, configure func(cfg *config.Config)) string {
+	t.Helper()
+	cfg := config.Default()
+	cfg.DB.DSN = os.Getenv("PM_TEST_DATABASE_URL")
+	if cfg.DB.DSN == "" {
+		if needDB {
+			t.Skip("PM_TEST_DATABASE_URL is not set")
+		}
+		// Nothing listens here, so any query fails.
+		cfg.DB.DSN = "postgres://pm:pm@127.0.0.1:1/pm?sslmode=disable"
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	if _, err := repository.OpenDB(cfg.DB); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(repository.CloseDB)
+	if needDB {
+		if err := repository.MigrateUp(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := httptest.NewServer(router.SetupRouter(cfg))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newServer(t, false, nil), client.WithRetries(0, 0))
+
+	_, err := c.MyViews(ctx)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("MyViews without a user: %v, want *client.Error matching ErrUnauthorized", err)
+	}
+	if apiErr.StatusCode != 401 || apiErr.Method != "GET" || apiErr.Path != "/me/views" || apiErr.Message != "Unauthorized" {
+		t.Errorf("error = %+v", apiErr)
+	}
+	if errors.Is(err, client.ErrNotFound) {
+		t.Error("401 matches ErrNotFound")
+	}
+
+	_, err = c.UpdateUser(ctx, 1, client.User{Name: "Ann"})
+	if !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("UpdateUser with no email: %v, want ErrBadRequest", err)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	url := newServer(t, false, func(cfg *config.Config) { cfg.RateLimit.Enabled = true })
+	ratelimit.Configure(ratelimit.Config{
+		Store:   ratelimit.NewMemoryStore(),
+		Default: ratelimit.Every(1, time.Hour, 1),
+		Search:  ratelimit.Every(1, time.Hour, 1),
+		Write:   ratelimit.Every(1, time.Hour, 1),
+	})
+	t.Cleanup(func() { ratelimit.Configure(ratelimit.Config{}) })
+
+	c := newClient(t, url, client.WithRetries(0, 0))
+	if _, err := c.MyViews(context.Background()); !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("first request: %v, want ErrUnauthorized", err)
+	}
+	_, err := c.MyViews(context.Background())
+	if !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("second request: %v, want ErrRateLimited", err)
+	}
+}
+
+func TestCRUD(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newServer(t, true, nil))
+	suffix := time.Now().UnixNano()
+
+	user, err := c.CreateUser(ctx, client.User{Name: "Ann", Email: fmt.Sprintf("ann%d@example.com", suffix), Role: "developer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID == 0 || user.Name != "Ann" {
+		t.Fatalf("created user %+v", user)
+	}
+	user.Name = "Anna"
+	if updated, err := c.UpdateUser(ctx, user.ID, *user); err != nil || updated.Name != "Anna" {
+		t.Fatalf("UpdateUser = %+v, %v", updated, err)
+	}
+	if got, err := c.GetUser(ctx, user.ID); err != nil || got.Name != "Anna" {
+		t.Fatalf("GetUser = %+v, %v", got, err)
+	}
+
+	project, err := c.CreateProject(ctx, client.Project{Title: "Client", ManagerID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := c.CreateTask(ctx, client.Task{Title: "Write tests", Priority: "high", AssigneeID: user.ID, ProjectID: project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !task.DueAt.IsZero() {
+		t.Errorf("task created without a due date is due %v", task.DueAt)
+	}
+	task.Status = client.TaskStatusDone
+	if updated, err := c.UpdateTask(ctx, task.ID, *task); err != nil || updated.Status != client.TaskStatusDone {
+		t.Fatalf("UpdateTask = %+v, %v", updated, err)
+	}
+	tasks, err := c.IterProjectTasks(ctx, project.ID).All()
+	if err != nil || len(tasks) != 1 || tasks[0].ID != task.ID {
+		t.Fatalf("IterProjectTasks = %+v, %v", tasks, err)
+	}
+
+	if err := c.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetTask(ctx, task.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetTask after delete: %v, want ErrNotFound", err)
+	}
+	if err := c.DeleteProject(ctx, project.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetUser(ctx, user.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetUser after delete: %v, want ErrNotFound", err)
+	}
+}
+
+func TestIterators(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newServer(t, true, nil))
+	suffix := time.Now().UnixNano()
+
+	var created []int
+	for i := 0; i < 5; i++ {
+		user, err := c.CreateUser(ctx, client.User{Name: "Iter", Email: fmt.Sprintf("iter%d-%d@example.com", suffix, i), Role: "developer"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, user.ID)
+		t.Cleanup(func() { c.DeleteUser(ctx, user.ID) })
+	}
+
+	all, err := c.ListUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Page sizes that divide the list evenly, leave a short last page and
+	// exceed it.
+	for _, size := range []int{1, 2, len(all), len(all) + 1} {
+		users, err := c.IterUsers(ctx).PageSize(size).All()
+		if err != nil {
+			t.Fatalf("page size %d: %v", size, err)
+		}
+		seen := map[int]bool{}
+		for _, u := range users {
+			if seen[u.ID] {
+				t.Errorf("page size %d: user %d seen twice", size, u.ID)
+			}
+			seen[u.ID] = true
+		}
+		for _, id := range created {
+			if !seen[id] {
+				t.Errorf("page size %d: user %d missing", size, id)
+			}
+		}
+		if len(users) != len(all) {
+			t.Errorf("page size %d: %d users, want %d", size, len(users), len(all))
+		}
+	}
+
+	found, err := c.SearchUsers(ctx, client.UserSearch{Email: fmt.Sprintf("iter%d", suffix)}).PageSize(2).All()
+	if err != nil || len(found) != len(created) {
+		t.Errorf("SearchUsers = %d users, %v, want %d", len(found), err, len(created))
+	}
+
+	it := c.IterUsers(ctx)
+	for it.Next() {
+	}
+	if it.Next() {
+		t.Error("Next after the end returned true")
+	}
+}
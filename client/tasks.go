@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// ListTasks returns all tasks.
+func (c *Client) ListTasks(ctx context.Context) ([]Task, error) {
+	var tasks []Task
+	err := c.do(ctx, request{method: http.MethodGet, path: "/tasks"}, &tasks)
+	return tasks, err
+}
+
+// IterTasks walks all tasks page by page.
+func (c *Client) IterTasks(ctx context.Context) *Iterator[Task] {
+	return newIterator[Task](ctx, c, "/tasks", nil)
+}
+
+func (c *Client) GetTask(ctx context.Context, id int) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/tasks/%d", id)}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) CreateTask(ctx context.Context, task Task) (*Task, error) {
+	var created Task
+	err := c.do(ctx, request{method: http.MethodPost, path: "/tasks", body: task, idempotent: true}, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateTask(ctx context.Context, id int, task Task) (*Task, error) {
+	var updated Task
+	if err := c.do(ctx, request{method: http.MethodPut, path: idPath("/tasks/%d", id), body: task}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/tasks/%d", id)}, nil)
+}
+
+// TaskSearch selects tasks either by a filter expression in Query, such as
+// "priority:high AND assignee:me", or by the other fields, of which empty
+// ones are ignored.
+type TaskSearch struct {
+	Query      string
+	Title      string
+	Priority   string
+	Status     string
+	AssigneeID int
+	ProjectID  int
+}
+
+func (s TaskSearch) query() url.Values {
+	q := url.Values{}
+	setNonEmpty(q, "q", s.Query)
+	setNonEmpty(q, "title", s.Title)
+	setNonEmpty(q, "priority", s.Priority)
+	setNonEmpty(q, "status", s.Status)
+	setNonZero(q, "assignee", s.AssigneeID)
+	setNonZero(q, "project", s.ProjectID)
+	return q
+}
+
+// SearchTasks walks the tasks matching s.
+func (c *Client) SearchTasks(ctx context.Context, s TaskSearch) *Iterator[Task] {
+	return newIterator[Task](ctx, c, "/search/tasks", s.query())
+}
+
+// ExportTasks downloads the tasks matching s as a spreadsheet. The caller
+// closes the returned reader.
+func (c *Client) ExportTasks(ctx context.Context, s TaskSearch, opts ExportOptions) (io.ReadCloser, error) {
+	path := "/search/tasks"
+	if s == (TaskSearch{}) {
+		path = "/tasks"
+	}
+	return c.export(ctx, path, s.query(), opts)
+}
+
+func (c *Client) BatchTasks(ctx context.Context, ops []BatchOperation, continueOnError bool) (*BatchResponse, error) {
+	return c.batch(ctx, "/tasks:batch", ops, continueOnError)
+}
+
+func (c *Client) GetTaskWatchers(ctx context.Context, id int) ([]User, error) {
+	var users []User
+	err := c.do(ctx, request{method: http.MethodGet, path: idPath("/tasks/%d/watchers", id)}, &users)
+	return users, err
+}
+
+// WatchTask subscribes the client's user to notifications about a task.
+func (c *Client) WatchTask(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/tasks/%d/watchers", id)}, nil)
+}
+
+func (c *Client) UnwatchTask(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/tasks/%d/watchers", id)}, nil)
+}
+
+func (c *Client) GetTaskComments(ctx context.Context, id int) ([]Comment, error) {
+	var comments []Comment
+	err := c.do(ctx, request{method: http.MethodGet, path: idPath("/tasks/%d/comments", id)}, &comments)
+	return comments, err
+}
+
+// CreateTaskComment comments on a task as the client's user.
+func (c *Client) CreateTaskComment(ctx context.Context, id int, body string) (*Comment, error) {
+	var comment Comment
+	err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       idPath("/tasks/%d/comments", id),
+		body:       Comment{Body: body},
+		idempotent: true,
+	}, &comment)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (c *Client) GetTaskAttachments(ctx context.Context, id int) ([]Attachment, error) {
+	var attachments []Attachment
+	err := c.do(ctx, request{method: http.MethodGet, path: idPath("/tasks/%d/attachments", id)}, &attachments)
+	return attachments, err
+}
+
+// UploadTaskAttachment attaches the content of r to a task. The content is
+// read into memory and sent with its checksum, so the server rejects a
+// corrupted upload.
+func (c *Client) UploadTaskAttachment(ctx context.Context, id int, fileName, contentType string, r io.Reader) (*Attachment, error) {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, escapeQuotes(fileName)))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.Set("Content-Type", contentType)
+	part, err := form.CreatePart(h)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(part, sum), r); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	var attachment Attachment
+	err = c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        idPath("/tasks/%d/attachments", id),
+		raw:         buf.Bytes(),
+		contentType: form.FormDataContentType(),
+		header:      http.Header{"X-Checksum-Sha256": {hex.EncodeToString(sum.Sum(nil))}},
+	}, &attachment)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// DownloadTaskAttachment returns the content of an attachment. The caller
+// closes the body.
+func (c *Client) DownloadTaskAttachment(ctx context.Context, taskID, attachmentID int) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: idPath("/tasks/%d/attachments/%d", taskID, attachmentID)})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *Client) DeleteTaskAttachment(ctx context.Context, taskID, attachmentID int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/tasks/%d/attachments/%d", taskID, attachmentID)}, nil)
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
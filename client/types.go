@@ -0,0 +1,23 @@
+package client
+
+import "HL_project_management/internal/model"
+
+// The API types are shared with the server.
+type (
+	User           = model.User
+	Task           = model.Task
+	Project        = model.Project
+	Comment        = model.Comment
+	Attachment     = model.Attachment
+	Notification   = model.Notification
+	SearchResult   = model.SearchResult
+	SavedView      = model.SavedView
+	ImportReport   = model.ImportReport
+	ImportRowError = model.ImportRowError
+	BatchOperation = model.BatchOperation
+	BatchResult    = model.BatchResult
+	BatchResponse  = model.BatchResponse
+)
+
+// TaskStatusDone marks a task as finished.
+const TaskStatusDone = model.TaskStatusDone
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListUsers returns all users.
+func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
+	var users []User
+	err := c.do(ctx, request{method: http.MethodGet, path: "/users"}, &users)
+	return users, err
+}
+
+// IterUsers walks all users page by page.
+func (c *Client) IterUsers(ctx context.Context) *Iterator[User] {
+	return newIterator[User](ctx, c, "/users", nil)
+}
+
+func (c *Client) GetUser(ctx context.Context, id int) (*User, error) {
+	var user User
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/users/%d", id)}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) CreateUser(ctx context.Context, user User) (*User, error) {
+	var created User
+	err := c.do(ctx, request{method: http.MethodPost, path: "/users", body: user, idempotent: true}, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) UpdateUser(ctx context.Context, id int, user User) (*User, error) {
+	var updated User
+	if err := c.do(ctx, request{method: http.MethodPut, path: idPath("/users/%d", id), body: user}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) DeleteUser(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/users/%d", id)}, nil)
+}
+
+// IterUserTasks walks the tasks assigned to a user.
+func (c *Client) IterUserTasks(ctx context.Context, id int) *Iterator[Task] {
+	return newIterator[Task](ctx, c, idPath("/users/%d/tasks", id), nil)
+}
+
+// UserSearch filters users by name and email; empty fields are ignored.
+type UserSearch struct {
+	Name  string
+	Email string
+}
+
+func (s UserSearch) query() url.Values {
+	q := url.Values{}
+	setNonEmpty(q, "name", s.Name)
+	setNonEmpty(q, "email", s.Email)
+	return q
+}
+
+// SearchUsers walks the users matching s.
+func (c *Client) SearchUsers(ctx context.Context, s UserSearch) *Iterator[User] {
+	return newIterator[User](ctx, c, "/search/users", s.query())
+}
+
+// BatchUsers applies the operations in one transaction. Unless
+// continueOnError is set, a failing operation rolls back the whole batch: the
+// response, which reports every operation, is then returned together with an
+// *Error matching ErrUnprocessable.
+func (c *Client) BatchUsers(ctx context.Context, ops []BatchOperation, continueOnError bool) (*BatchResponse, error) {
+	return c.batch(ctx, "/users:batch", ops, continueOnError)
+}
+
+func (c *Client) batch(ctx context.Context, path string, ops []BatchOperation, continueOnError bool) (*BatchResponse, error) {
+	q := url.Values{}
+	if continueOnError {
+		q.Set("continue_on_error", "true")
+	}
+	var resp BatchResponse
+	err := c.do(ctx, request{method: http.MethodPost, path: path, query: q, body: ops, idempotent: true}, &resp)
+	if err != nil && !decodeReport(err, &resp) {
+		return nil, err
+	}
+	return &resp, err
+}
+
+func setNonEmpty(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+func setNonZero(q url.Values, key string, value int) {
+	if value != 0 {
+		q.Set(key, strconv.Itoa(value))
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The scripts ask "pmctl __complete" for the candidates of the word being
+// completed, passing the words before it.
+const bashCompletion = `_pmctl() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	COMPREPLY=($(compgen -W "$(pmctl __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" 2>/dev/null)" -- "$cur"))
+}
+complete -o default -F _pmctl pmctl
+`
+
+const zshCompletion = `#compdef pmctl
+
+_pmctl() {
+	local -a candidates
+	candidates=(${(f)"$(pmctl __complete "${(@)words[2,CURRENT-1]}" 2>/dev/null)"})
+	compadd -a candidates
+}
+compdef _pmctl pmctl
+`
+
+const fishCompletion = `complete -c pmctl -f -a '(pmctl __complete (commandline -opc)[2..-1] 2>/dev/null)'
+`
+
+func completion(out io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		fmt.Fprint(out, bashCompletion)
+	case "zsh":
+		fmt.Fprint(out, zshCompletion)
+	case "fish":
+		fmt.Fprint(out, fishCompletion)
+	default:
+		return fmt.Errorf("unsupported shell %q, expected bash, zsh or fish", shell)
+	}
+	return nil
+}
+
+// complete prints the candidates for the word following args.
+func complete(out io.Writer, args []string) error {
+	// Skip global flags before the resource; all of them take a value.
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if !strings.Contains(args[0], "=") && len(args) > 1 {
+			args = args[1:]
+		}
+		args = args[1:]
+	}
+	var candidates []string
+	switch {
+	case len(args) == 0:
+		candidates = append(resourceNames(), "config", "completion", "help")
+	case args[0] == "config" && len(args) == 1:
+		candidates = []string{"show", "path", "set"}
+	case args[0] == "config" && len(args) == 2 && args[1] == "set":
+		candidates = []string{"server", "token", "user", "output"}
+	case args[0] == "completion" && len(args) == 1:
+		candidates = []string{"bash", "zsh", "fish"}
+	case resources[args[0]] != nil && len(args) == 1:
+		for verb := range resources[args[0]] {
+			candidates = append(candidates, verb)
+		}
+	case resources[args[0]] != nil && resources[args[0]][args[1]] != nil:
+		if last := args[len(args)-1]; last == "-o" || last == "-output" || last == "--output" {
+			candidates = []string{formatTable, formatJSON, formatYAML}
+			break
+		}
+		fs := flag.NewFlagSet("", flag.ContinueOnError)
+		new(globals).register(fs)
+		if cmd := resources[args[0]][args[1]]; cmd.flags != nil {
+			cmd.flags(fs)
+		}
+		fs.VisitAll(func(f *flag.Flag) {
+			if len(f.Name) == 1 {
+				candidates = append(candidates, "-"+f.Name)
+			} else {
+				candidates = append(candidates, "--"+f.Name)
+			}
+		})
+	}
+	sort.Strings(candidates)
+	for _, c := range candidates {
+		fmt.Fprintln(out, c)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"HL_project_management/client"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// config is read from a YAML file, then overridden by PMCTL_* environment
+// variables and finally by flags.
+type config struct {
+	Server string `yaml:"server,omitempty"`
+	Token  string `yaml:"token,omitempty"`
+	UserID int    `yaml:"user,omitempty"`
+	Output string `yaml:"output,omitempty"`
+}
+
+func configPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pmctl", "config.yaml"), nil
+}
+
+// loadConfig reads the config file, if it exists, and the environment.
+func loadConfig(path string) (*config, error) {
+	cfg, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if v := os.Getenv("PMCTL_SERVER"); v != "" {
+		cfg.Server = v
+	}
+	if v := os.Getenv("PMCTL_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	if v := os.Getenv("PMCTL_USER"); v != "" {
+		if cfg.UserID, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid PMCTL_USER %q", v)
+		}
+	}
+	return cfg, nil
+}
+
+func readConfig(path string) (*config, error) {
+	path, err := configPath(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (cfg *config) override(g *globals) {
+	if g.server != "" {
+		cfg.Server = g.server
+	}
+	if g.token != "" {
+		cfg.Token = g.token
+	}
+	if g.userID != 0 {
+		cfg.UserID = g.userID
+	}
+	if g.output != "" {
+		cfg.Output = g.output
+	}
+}
+
+func (cfg *config) client() (*client.Client, error) {
+	server := cfg.Server
+	if server == "" {
+		server = defaultServer
+	}
+	return client.New(server,
+		client.WithToken(cfg.Token),
+		client.WithUserID(cfg.UserID),
+		client.WithUserAgent("pmctl"),
+	)
+}
+
+// runConfig handles "pmctl config show|path|set <key> <value>".
+func runConfig(g *globals, args []string, out io.Writer) error {
+	path, err := configPath(g.config)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		args = []string{"show"}
+	}
+	switch {
+	case args[0] == "path" && len(args) == 1:
+		fmt.Fprintln(out, path)
+		return nil
+	case args[0] == "show" && len(args) == 1:
+		cfg, err := loadConfig(g.config)
+		if err != nil {
+			return err
+		}
+		cfg.override(g)
+		if cfg.Token != "" {
+			cfg.Token = "********"
+		}
+		return yaml.NewEncoder(out).Encode(cfg)
+	case args[0] == "set" && len(args) == 3:
+		cfg, err := readConfig(g.config)
+		if err != nil {
+			return err
+		}
+		switch key, value := args[1], args[2]; key {
+		case "server":
+			cfg.Server = value
+		case "token":
+			cfg.Token = value
+		case "user":
+			if cfg.UserID, err = strconv.Atoi(value); err != nil {
+				return fmt.Errorf("invalid user ID %q", value)
+			}
+		case "output":
+			cfg.Output = value
+		default:
+			return fmt.Errorf("unknown config key %q, expected server, token, user or output", key)
+		}
+		data, err := yaml.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+		// The file holds the token, so only the owner may read it.
+		return os.WriteFile(path, data, 0o600)
+	}
+	return errors.New("usage: pmctl config show | path | set server|token|user|output <value>")
+}
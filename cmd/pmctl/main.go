@@ -0,0 +1,231 @@
+// Command pmctl manages users, tasks and projects from the command line
+// through the REST API.
+//
+//	pmctl tasks list --project 3 --status open
+//	pmctl task create --title "Release notes" --project 3 --assignee 2
+//	pmctl task move 42 done
+//	pmctl project show 3 -o yaml
+package main
+
+import (
+	"HL_project_management/client"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+)
+
+// command is a verb of a resource, such as "list" of "tasks".
+type command struct {
+	usage string
+	help  string
+	// flags registers the command's flags; it may be nil.
+	flags func(fs *flag.FlagSet)
+	// args is the number of positional arguments, -1 for any.
+	args int
+	run  func(ctx context.Context, env *env, args []string) error
+}
+
+// resources maps resource names, singular and plural, to their commands.
+var resources = map[string]map[string]*command{}
+
+func register(names []string, commands map[string]*command) {
+	for _, name := range names {
+		resources[name] = commands
+	}
+}
+
+// env is what a command runs with.
+type env struct {
+	client *client.Client
+	out    io.Writer
+	format string
+}
+
+// globals are flags accepted by every command.
+type globals struct {
+	config string
+	server string
+	token  string
+	userID int
+	output string
+}
+
+func (g *globals) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.config, "config", g.config, "Config file")
+	fs.StringVar(&g.server, "server", g.server, "API server URL")
+	fs.StringVar(&g.token, "token", g.token, "API token")
+	fs.IntVar(&g.userID, "user", g.userID, "ID of the user to act as, for servers that trust the X-User-ID header")
+	fs.StringVar(&g.output, "o", g.output, "Output format: table, json or yaml")
+	fs.StringVar(&g.output, "output", g.output, "Output format: table, json or yaml")
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[1:], os.Stdout)
+	stop()
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pmctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	g := &globals{config: os.Getenv("PMCTL_CONFIG")}
+	fs := flag.NewFlagSet("pmctl", flag.ContinueOnError)
+	g.register(fs)
+	fs.Usage = func() { usage(fs.Output()) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return errors.New("missing command")
+	}
+
+	switch args[0] {
+	case "help":
+		usage(out)
+		return nil
+	case "completion":
+		if len(args) != 2 {
+			return errors.New("usage: pmctl completion bash|zsh|fish")
+		}
+		return completion(out, args[1])
+	case "config":
+		return runConfig(g, args[1:], out)
+	case "__complete":
+		// Used by the completion scripts.
+		return complete(out, args[1:])
+	}
+
+	commands, ok := resources[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, see pmctl help", args[0])
+	}
+	if len(args) < 2 {
+		resourceUsage(os.Stderr, args[0], commands)
+		return fmt.Errorf("missing %s command", args[0])
+	}
+	cmd, ok := commands[args[1]]
+	if !ok {
+		resourceUsage(os.Stderr, args[0], commands)
+		return fmt.Errorf("unknown %s command %q", args[0], args[1])
+	}
+
+	cfs := flag.NewFlagSet("pmctl "+args[0]+" "+args[1], flag.ContinueOnError)
+	g.register(cfs)
+	if cmd.flags != nil {
+		cmd.flags(cfs)
+	}
+	cfs.Usage = func() {
+		fmt.Fprintf(cfs.Output(), "Usage: pmctl %s %s\n\n%s\n\nFlags:\n", args[0], cmd.usage, cmd.help)
+		cfs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(cfs, args[2:])
+	if err != nil {
+		return err
+	}
+	if cmd.args >= 0 && len(positional) != cmd.args {
+		cfs.Usage()
+		return fmt.Errorf("expected %d argument(s), got %d", cmd.args, len(positional))
+	}
+
+	cfg, err := loadConfig(g.config)
+	if err != nil {
+		return err
+	}
+	cfg.override(g)
+	format := cfg.Output
+	if format == "" {
+		format = formatTable
+	}
+	if format != formatTable && format != formatJSON && format != formatYAML {
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	c, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	return cmd.run(ctx, &env{client: c, out: out, format: format}, positional)
+}
+
+// parseInterspersed parses flags that may come before, between or after the
+// positional arguments, which it returns.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage: pmctl [flags] <resource> <command> [flags] [args]
+
+Resources:
+`)
+	for _, name := range resourceNames() {
+		var verbs []string
+		for verb := range resources[name] {
+			verbs = append(verbs, verb)
+		}
+		sort.Strings(verbs)
+		fmt.Fprintf(w, "  %-10s %s\n", name, strings.Join(verbs, ", "))
+	}
+	fmt.Fprint(w, `
+Other commands:
+  config     show or set the server, token and user in the config file
+  completion print a shell completion script (bash, zsh or fish)
+
+Flags accepted everywhere:
+  --config   config file, by default $PMCTL_CONFIG or ~/.config/pmctl/config.yaml
+  --server   API server URL ($PMCTL_SERVER)
+  --token    API token ($PMCTL_TOKEN)
+  --user     ID of the user to act as ($PMCTL_USER)
+  -o         output format: table, json or yaml
+`)
+}
+
+func resourceUsage(w io.Writer, name string, commands map[string]*command) {
+	fmt.Fprintf(w, "Commands of %s:\n", name)
+	var verbs []string
+	for verb := range commands {
+		verbs = append(verbs, verb)
+	}
+	sort.Strings(verbs)
+	for _, verb := range verbs {
+		fmt.Fprintf(w, "  pmctl %s %s\n      %s\n", name, commands[verb].usage, commands[verb].help)
+	}
+}
+
+// resourceNames returns the plural resource names.
+func resourceNames() []string {
+	var names []string
+	for name := range resources {
+		if strings.HasSuffix(name, "s") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"HL_project_management/client"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// print writes v as JSON or YAML, or else as a table with the given
+// header and rows.
+func (e *env) print(v any, header []string, rows [][]string) error {
+	switch e.format {
+	case formatJSON:
+		enc := json.NewEncoder(e.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatYAML:
+		// Going through JSON keeps the field names of the API.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic any
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+		return yaml.NewEncoder(e.out).Encode(generic)
+	}
+	tw := tabwriter.NewWriter(e.out, 0, 4, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printFields writes a single item as a two-column table of field names and
+// values.
+func (e *env) printFields(v any, fields [][2]string) error {
+	rows := make([][]string, len(fields))
+	for i, f := range fields {
+		rows[i] = []string{f[0] + ":", f[1]}
+	}
+	return e.print(v, nil, rows)
+}
+
+func (e *env) printTasks(tasks []client.Task) error {
+	rows := make([][]string, len(tasks))
+	for i, t := range tasks {
+		rows[i] = []string{itoa(t.ID), t.Title, t.Status, t.Priority, itoa(t.AssigneeID), itoa(t.ProjectID), date(t.DueAt)}
+	}
+	if tasks == nil {
+		tasks = []client.Task{}
+	}
+	return e.print(tasks, []string{"ID", "TITLE", "STATUS", "PRIORITY", "ASSIGNEE", "PROJECT", "DUE"}, rows)
+}
+
+func (e *env) printTask(t *client.Task) error {
+	return e.printFields(t, [][2]string{
+		{"ID", itoa(t.ID)},
+		{"Title", t.Title},
+		{"Description", t.Description},
+		{"Status", t.Status},
+		{"Priority", t.Priority},
+		{"Assignee", itoa(t.AssigneeID)},
+		{"Project", itoa(t.ProjectID)},
+		{"Created", date(t.CreatedAt)},
+		{"Due", date(t.DueAt)},
+		{"Completed", date(t.CompletedAt)},
+	})
+}
+
+func itoa(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// parseDate accepts a date or an RFC 3339 timestamp.
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"HL_project_management/client"
+	"context"
+	"flag"
+)
+
+var projectCreate struct {
+	title       string
+	description string
+	manager     int
+	end         string
+}
+
+var projectShow struct {
+	tasks bool
+}
+
+func init() {
+	register([]string{"projects", "project"}, map[string]*command{
+		"list": {
+			usage: "list",
+			help:  "List projects.",
+			run: func(ctx context.Context, e *env, args []string) error {
+				projects, err := e.client.IterProjects(ctx).All()
+				if err != nil {
+					return err
+				}
+				return e.printProjects(projects)
+			},
+		},
+		"show": {
+			usage: "show ID [--tasks]",
+			help:  "Show a project, optionally with its tasks.",
+			args:  1,
+			flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&projectShow.tasks, "tasks", false, "Also list the tasks of the project")
+			},
+			run: showProject,
+		},
+		"create": {
+			usage: "create --title T --manager ID [--description D] [--end DATE]",
+			help:  "Create a project.",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&projectCreate.title, "title", "", "Title")
+				fs.StringVar(&projectCreate.description, "description", "", "Description")
+				fs.IntVar(&projectCreate.manager, "manager", 0, "Manager ID")
+				fs.StringVar(&projectCreate.end, "end", "", "End date, YYYY-MM-DD")
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				project := client.Project{
+					Title:       projectCreate.title,
+					Description: projectCreate.description,
+					ManagerID:   projectCreate.manager,
+				}
+				if projectCreate.end != "" {
+					var err error
+					if project.EndDate, err = parseDate(projectCreate.end); err != nil {
+						return err
+					}
+				}
+				created, err := e.client.CreateProject(ctx, project)
+				if err != nil {
+					return err
+				}
+				return e.printProject(created)
+			},
+		},
+		"delete": {
+			usage: "delete ID",
+			help:  "Delete a project.",
+			args:  1,
+			run: func(ctx context.Context, e *env, args []string) error {
+				id, err := parseID(args[0])
+				if err != nil {
+					return err
+				}
+				return e.client.DeleteProject(ctx, id)
+			},
+		},
+	})
+}
+
+func showProject(ctx context.Context, e *env, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	project, err := e.client.GetProject(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !projectShow.tasks {
+		return e.printProject(project)
+	}
+	tasks, err := e.client.IterProjectTasks(ctx, id).All()
+	if err != nil {
+		return err
+	}
+	if e.format != formatTable {
+		if tasks == nil {
+			tasks = []client.Task{}
+		}
+		return e.print(struct {
+			*client.Project
+			Tasks []client.Task `json:"tasks"`
+		}{project, tasks}, nil, nil)
+	}
+	if err := e.printProject(project); err != nil {
+		return err
+	}
+	e.out.Write([]byte("\n"))
+	return e.printTasks(tasks)
+}
+
+func (e *env) printProjects(projects []client.Project) error {
+	rows := make([][]string, len(projects))
+	for i, p := range projects {
+		rows[i] = []string{itoa(p.ID), p.Title, itoa(p.ManagerID), date(p.StartDate), date(p.EndDate)}
+	}
+	if projects == nil {
+		projects = []client.Project{}
+	}
+	return e.print(projects, []string{"ID", "TITLE", "MANAGER", "START", "END"}, rows)
+}
+
+func (e *env) printProject(p *client.Project) error {
+	return e.printFields(p, [][2]string{
+		{"ID", itoa(p.ID)},
+		{"Title", p.Title},
+		{"Description", p.Description},
+		{"Manager", itoa(p.ManagerID)},
+		{"Start", date(p.StartDate)},
+		{"End", date(p.EndDate)},
+	})
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"HL_project_management/client"
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// statusOpen is a pseudo-status matching every task that is not done.
+const statusOpen = "open"
+
+var taskList struct {
+	project  int
+	assignee string
+	status   string
+	priority string
+	filter   string
+	limit    int
+}
+
+// taskFields are the flags of task create and update. Only flags given on
+// the command line are applied on update.
+type taskFields struct {
+	fs          *flag.FlagSet
+	title       string
+	description string
+	priority    string
+	status      string
+	assignee    int
+	project     int
+	due         string
+}
+
+var taskCreate, taskUpdate taskFields
+
+func (f *taskFields) register(fs *flag.FlagSet) {
+	f.fs = fs
+	fs.StringVar(&f.title, "title", "", "Title")
+	fs.StringVar(&f.description, "description", "", "Description")
+	fs.StringVar(&f.priority, "priority", "", "Priority: low, medium or high")
+	fs.StringVar(&f.status, "status", "", "Status")
+	fs.IntVar(&f.assignee, "assignee", 0, "Assignee ID")
+	fs.IntVar(&f.project, "project", 0, "Project ID")
+	fs.StringVar(&f.due, "due", "", "Due date, YYYY-MM-DD")
+}
+
+func (f *taskFields) apply(task *client.Task) error {
+	var err error
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "title":
+			task.Title = f.title
+		case "description":
+			task.Description = f.description
+		case "priority":
+			task.Priority = f.priority
+		case "status":
+			task.Status = f.status
+		case "assignee":
+			task.AssigneeID = f.assignee
+		case "project":
+			task.ProjectID = f.project
+		case "due":
+			if task.DueAt, err = parseDate(f.due); err != nil {
+				return
+			}
+		}
+	})
+	return err
+}
+
+func init() {
+	register([]string{"tasks", "task"}, map[string]*command{
+		"list": {
+			usage: "list [--project ID] [--assignee ID|me] [--status S|open] [--filter EXPR]",
+			help:  "List tasks.",
+			flags: func(fs *flag.FlagSet) {
+				fs.IntVar(&taskList.project, "project", 0, "Only tasks of this project")
+				fs.StringVar(&taskList.assignee, "assignee", "", `Only tasks assigned to this user ID, or "me"`)
+				fs.StringVar(&taskList.status, "status", "", `Only tasks with this status; "open" means not done`)
+				fs.StringVar(&taskList.priority, "priority", "", "Only tasks with this priority")
+				fs.StringVar(&taskList.filter, "filter", "", `Filter expression, e.g. "priority:high AND due<2024-10-01"`)
+				fs.IntVar(&taskList.limit, "limit", 0, "Show at most this many tasks")
+			},
+			run: listTasks,
+		},
+		"show": {
+			usage: "show ID",
+			help:  "Show a task.",
+			args:  1,
+			run: func(ctx context.Context, e *env, args []string) error {
+				id, err := parseID(args[0])
+				if err != nil {
+					return err
+				}
+				task, err := e.client.GetTask(ctx, id)
+				if err != nil {
+					return err
+				}
+				return e.printTask(task)
+			},
+		},
+		"create": {
+			usage: "create --title T --project ID --assignee ID [--priority P] [--due DATE]",
+			help:  "Create a task.",
+			flags: taskCreate.register,
+			run: func(ctx context.Context, e *env, args []string) error {
+				task := client.Task{Priority: "medium", Status: "new"}
+				if err := taskCreate.apply(&task); err != nil {
+					return err
+				}
+				created, err := e.client.CreateTask(ctx, task)
+				if err != nil {
+					return err
+				}
+				return e.printTask(created)
+			},
+		},
+		"update": {
+			usage: "update ID [--title T] [--status S] [--priority P] [--assignee ID] [--due DATE]",
+			help:  "Change fields of a task.",
+			args:  1,
+			flags: taskUpdate.register,
+			run: func(ctx context.Context, e *env, args []string) error {
+				return updateTask(ctx, e, args[0], taskUpdate.apply)
+			},
+		},
+		"move": {
+			usage: "move ID STATUS",
+			help:  "Change the status of a task.",
+			args:  2,
+			run: func(ctx context.Context, e *env, args []string) error {
+				return updateTask(ctx, e, args[0], func(task *client.Task) error {
+					task.Status = args[1]
+					return nil
+				})
+			},
+		},
+		"assign": {
+			usage: "assign ID USER_ID",
+			help:  "Assign a task to a user.",
+			args:  2,
+			run: func(ctx context.Context, e *env, args []string) error {
+				userID, err := parseID(args[1])
+				if err != nil {
+					return err
+				}
+				return updateTask(ctx, e, args[0], func(task *client.Task) error {
+					task.AssigneeID = userID
+					return nil
+				})
+			},
+		},
+		"delete": {
+			usage: "delete ID",
+			help:  "Delete a task.",
+			args:  1,
+			run: func(ctx context.Context, e *env, args []string) error {
+				id, err := parseID(args[0])
+				if err != nil {
+					return err
+				}
+				return e.client.DeleteTask(ctx, id)
+			},
+		},
+		"comment": {
+			usage: "comment ID TEXT",
+			help:  "Comment on a task.",
+			args:  2,
+			run: func(ctx context.Context, e *env, args []string) error {
+				id, err := parseID(args[0])
+				if err != nil {
+					return err
+				}
+				comment, err := e.client.CreateTaskComment(ctx, id, args[1])
+				if err != nil {
+					return err
+				}
+				return e.printFields(comment, [][2]string{
+					{"ID", itoa(comment.ID)},
+					{"Task", itoa(comment.TaskID)},
+					{"Author", itoa(comment.AuthorID)},
+					{"Created", date(comment.CreatedAt)},
+					{"Body", comment.Body},
+				})
+			},
+		},
+	})
+}
+
+func listTasks(ctx context.Context, e *env, args []string) error {
+	var terms []string
+	if taskList.project != 0 {
+		terms = append(terms, "project="+strconv.Itoa(taskList.project))
+	}
+	switch taskList.assignee {
+	case "":
+	case "me":
+		terms = append(terms, "assignee:me")
+	default:
+		id, err := parseID(taskList.assignee)
+		if err != nil {
+			return err
+		}
+		terms = append(terms, "assignee="+strconv.Itoa(id))
+	}
+	switch taskList.status {
+	case "":
+	case statusOpen:
+		terms = append(terms, "status!="+client.TaskStatusDone)
+	default:
+		terms = append(terms, "status:"+quote(taskList.status))
+	}
+	if taskList.priority != "" {
+		terms = append(terms, "priority:"+quote(taskList.priority))
+	}
+	if taskList.filter != "" {
+		terms = append(terms, "("+taskList.filter+")")
+	}
+
+	it := e.client.IterTasks(ctx)
+	if len(terms) > 0 {
+		it = e.client.SearchTasks(ctx, client.TaskSearch{Query: strings.Join(terms, " AND ")})
+	}
+	if taskList.limit > 0 {
+		it.PageSize(taskList.limit)
+	}
+	var tasks []client.Task
+	for it.Next() {
+		tasks = append(tasks, it.Value())
+		if len(tasks) == taskList.limit {
+			break
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return e.printTasks(tasks)
+}
+
+// updateTask fetches a task, changes it with fn and saves it.
+func updateTask(ctx context.Context, e *env, arg string, fn func(*client.Task) error) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	task, err := e.client.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := fn(task); err != nil {
+		return err
+	}
+	updated, err := e.client.UpdateTask(ctx, id, *task)
+	if err != nil {
+		return err
+	}
+	return e.printTask(updated)
+}
+
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid ID %q", s)
+	}
+	return id, nil
+}
+
+// quote writes a filter value in double quotes.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"HL_project_management/client"
+	"context"
+)
+
+func init() {
+	register([]string{"users", "user"}, map[string]*command{
+		"list": {
+			usage: "list",
+			help:  "List users.",
+			run: func(ctx context.Context, e *env, args []string) error {
+				users, err := e.client.IterUsers(ctx).All()
+				if err != nil {
+					return err
+				}
+				rows := make([][]string, len(users))
+				for i, u := range users {
+					rows[i] = []string{itoa(u.ID), u.Name, u.Email, u.Role}
+				}
+				if users == nil {
+					users = []client.User{}
+				}
+				return e.print(users, []string{"ID", "NAME", "EMAIL", "ROLE"}, rows)
+			},
+		},
+		"show": {
+			usage: "show ID",
+			help:  "Show a user.",
+			args:  1,
+			run: func(ctx context.Context, e *env, args []string) error {
+				id, err := parseID(args[0])
+				if err != nil {
+					return err
+				}
+				u, err := e.client.GetUser(ctx, id)
+				if err != nil {
+					return err
+				}
+				return e.printFields(u, [][2]string{
+					{"ID", itoa(u.ID)},
+					{"Name", u.Name},
+					{"Email", u.Email},
+					{"Role", u.Role},
+					{"Registered", date(u.RegistrationAt)},
+				})
+			},
+		},
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: service migrate up | down [N|all] | to VERSION | status | force VERSION"
+
+// runMigrate handles "service migrate ...".
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	switch cmd := args[0]; {
+	case cmd == "up" && len(args) == 1:
+		if err := repository.MigrateUp(); err != nil {
+			return err
+		}
+	case cmd == "down" && len(args) <= 2:
+		// Rolling everything back drops all data, so it has to be asked for.
+		steps := 1
+		if len(args) == 2 {
+			if args[1] == "all" {
+				steps = 0
+			} else if n, err := strconv.Atoi(args[1]); err == nil && n > 0 {
+				steps = n
+			} else {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		if err := repository.MigrateDown(steps); err != nil {
+			return err
+		}
+	case cmd == "to" && len(args) == 2:
+		version, err := strconv.ParseUint(args[1], 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := repository.MigrateTo(uint(version)); err != nil {
+			return err
+		}
+	case cmd == "force" && len(args) == 2:
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < -1 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := repository.ForceMigrationVersion(version); err != nil {
+			return err
+		}
+	case cmd == "status" && len(args) == 1:
+	default:
+		return errors.New(migrateUsage)
+	}
+	return printMigrationStatus()
+}
+
+func printMigrationStatus() error {
+	status, err := repository.GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+	for _, m := range status.Migrations {
+		applied := "no"
+		if m.Applied {
+			applied = "yes"
+		}
+		if m.Version == status.Version && status.Dirty {
+			applied = "dirty"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", m.Version, m.Name, applied)
+	}
+	tw.Flush()
+	switch {
+	case status.Dirty:
+		fmt.Printf("\nVersion %d is dirty: fix the schema, then run migrate force VERSION.\n", status.Version)
+	case status.UpToDate():
+		fmt.Printf("\nUp to date at version %d.\n", status.Version)
+	default:
+		fmt.Printf("\nAt version %d, latest is %d.\n", status.Version, status.Latest)
+	}
+	return nil
+}
+
+// runCheckDB verifies that the database is reachable and fully migrated.
+func runCheckDB() error {
+	if err := repository.PingDB(context.Background()); err != nil {
+		return fmt.Errorf("database unreachable: %w", err)
+	}
+	status, err := repository.GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+	switch {
+	case status.Dirty:
+		return fmt.Errorf("migration %d is dirty", status.Version)
+	case !status.UpToDate():
+		return fmt.Errorf("schema is at version %d, latest is %d; run migrate up", status.Version, status.Latest)
+	}
+	fmt.Printf("Database OK, schema at version %d\n", status.Version)
+	return nil
+}
+
+// runSeed fills an empty database with sample users, projects and tasks
+// for development.
+func runSeed() error {
+	ctx := context.Background()
+	users, err := repository.GetAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return errors.New("the database already has users; seed only fills an empty database")
+	}
+
+	b, err := repository.BeginBatch(ctx)
+	if err != nil {
+		return err
+	}
+	defer b.Rollback()
+
+	now := time.Now()
+	users, err = b.CreateUsers(ctx, []model.User{
+		{Name: "Alice Admin", Email: "alice@example.com", Role: "admin", RegistrationAt: now},
+		{Name: "Bob Manager", Email: "bob@example.com", Role: "manager", RegistrationAt: now},
+		{Name: "Carol Developer", Email: "carol@example.com", Role: "developer", RegistrationAt: now},
+	})
+	if err != nil {
+		return err
+	}
+	projects, err := b.CreateProjects(ctx, []model.Project{
+		{Title: "Website", Description: "Company website relaunch", StartDate: now, EndDate: now.AddDate(0, 3, 0), ManagerID: users[1].ID},
+		{Title: "Mobile app", Description: "First release of the mobile app", StartDate: now, EndDate: now.AddDate(0, 6, 0), ManagerID: users[1].ID},
+	})
+	if err != nil {
+		return err
+	}
+	tasks, err := b.CreateTasks(ctx, []model.Task{
+		{Title: "Design landing page", Priority: "high", Status: "in_progress", AssigneeID: users[2].ID, ProjectID: projects[0].ID, CreatedAt: now, DueAt: now.AddDate(0, 0, 7)},
+		{Title: "Write release notes", Priority: "medium", Status: "new", AssigneeID: users[1].ID, ProjectID: projects[0].ID, CreatedAt: now, DueAt: now.AddDate(0, 0, 14)},
+		{Title: "Set up CI", Priority: "low", Status: model.TaskStatusDone, AssigneeID: users[0].ID, ProjectID: projects[1].ID, CreatedAt: now, CompletedAt: now, DueAt: now.AddDate(0, 0, 3)},
+		{Title: "Login screen", Priority: "high", Status: "new", AssigneeID: users[2].ID, ProjectID: projects[1].ID, CreatedAt: now, DueAt: now.AddDate(0, 1, 0)},
+	})
+	if err != nil {
+		return err
+	}
+	if err := b.Commit(ctx); err != nil {
+		return err
+	}
+	fmt.Printf("Created %d users, %d projects and %d tasks\n", len(users), len(projects), len(tasks))
+	return nil
+}
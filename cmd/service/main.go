@@ -2,49 +2,404 @@ package main
 
 import (
 	_ "HL_project_management/docs"
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/config"
+	"HL_project_management/internal/grpcapi"
+	"HL_project_management/internal/handler"
+	"HL_project_management/internal/health"
+	"HL_project_management/internal/idempotency"
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/metrics"
+	"HL_project_management/internal/notify"
+	"HL_project_management/internal/oidc"
+	"HL_project_management/internal/ratelimit"
 	"HL_project_management/internal/repository"
 	"HL_project_management/internal/router"
+	"HL_project_management/internal/scheduler"
+	"HL_project_management/internal/service"
+	"HL_project_management/internal/storage"
+	"HL_project_management/internal/tracing"
+	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/joho/godotenv"
+	"fmt"
 	"github.com/rs/cors"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 func main() {
-	var cfg repository.Config
-	err := godotenv.Load()
+	cfg, err := config.Load(config.FileFromArgs(os.Args[1:]))
 	if err != nil {
-		log.Fatal("Error loading .env file	")
-	}
-	url := os.Getenv("url")
-	flag.IntVar(&cfg.Port, "port", 8080, "API server port")
-	flag.StringVar(&cfg.Env, "env", "development", "Environment (development|staging|production)")
-	flag.StringVar(&cfg.Db.Dsn, "db-dsn", url, "PostgreSQL DSN")
-	flag.Parse()
-	_, err = repository.OpenDB(cfg)
+		log.Fatal(err)
+	}
+	cfg.RegisterFlags(flag.CommandLine)
+	flag.Usage = usage
+
+	command, args := parseCommand(os.Args[1:])
+	if err := logging.Setup(os.Stderr, cfg.Logging.Format, cfg.Logging.Level); err != nil {
+		log.Fatal(err)
+	}
+	repository.LogSlowQueries(cfg.Logging.SlowQuery)
+	if command == "config" {
+		if err := runConfig(cfg, args); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = repository.OpenDB(cfg.DB)
 	if err != nil {
 		log.Fatalf("could not connect to database: %v", err)
-	} else {
-		log.Println("Connected to the database")
 	}
 	defer repository.CloseDB()
-	r := router.SetupRouter()
+
+	switch {
+	case command == "serve" && len(args) == 0:
+		err = serve(cfg)
+	case command == "migrate":
+		err = runMigrate(args)
+	case command == "seed" && len(args) == 0:
+		err = runSeed()
+	case command == "check-db" && len(args) == 0:
+		err = runCheckDB()
+	default:
+		usage()
+		repository.CloseDB()
+		os.Exit(2)
+	}
+	if err != nil {
+		repository.CloseDB()
+		log.Fatal(err)
+	}
+}
+
+// runConfig handles "service config print|check".
+func runConfig(cfg *config.Config, args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "print":
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			return err
+		}
+		// Printing is often how a bad setting is tracked down, so problems
+		// are reported without failing.
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return nil
+	case len(args) == 1 && args[0] == "check":
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		fmt.Println("Configuration OK")
+		return nil
+	}
+	return errors.New("usage: service config print | check")
+}
+
+// parseCommand parses the flags, which may come before or after the
+// command, and returns the command, "serve" by default, and its arguments.
+func parseCommand(args []string) (string, []string) {
+	var positional []string
+	for {
+		flag.CommandLine.Parse(args)
+		args = flag.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) == 0 {
+		return "serve", nil
+	}
+	return positional[0], positional[1:]
+}
+
+func usage() {
+	fmt.Fprint(flag.CommandLine.Output(), `Usage: service [command] [flags]
+
+Commands:
+  serve                  run the API and gRPC servers (default)
+  migrate up             apply all pending migrations
+  migrate down [N|all]   roll back the last N migrations, 1 by default
+  migrate to VERSION     migrate up or down to VERSION
+  migrate status         list migrations and the current version
+  migrate force VERSION  mark VERSION as applied after fixing a failed migration
+  seed                   fill an empty database with sample data
+  check-db               check that the database is reachable and migrated
+  config print           print the effective configuration, secrets redacted
+  config check           validate the configuration
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+func serve(cfg *config.Config) error {
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("could not set up tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("could not flush traces", "error", err)
+		}
+	}()
+
+	if cfg.DB.Migrate {
+		if err := repository.MigrateUp(); err != nil {
+			return fmt.Errorf("could not apply migrations: %w", err)
+		}
+	}
+	if err := repository.PingDB(context.Background()); err != nil {
+		return fmt.Errorf("could not connect to database: %w", err)
+	}
+	slog.Info("connected to the database")
+	metrics.Registry.MustRegister(repository.Collectors()...)
+
+	blobs, err := newBlobStore(cfg)
+	if err != nil {
+		return fmt.Errorf("could not set up attachment storage: %w", err)
+	}
+	service.Configure(service.Config{Blobs: blobs})
+	handler.ConfigureAttachments(handler.AttachmentConfig{
+		Store:        blobs,
+		MaxSize:      cfg.Attachments.MaxSize,
+		AllowedTypes: cfg.Attachments.AllowedTypes,
+	})
+	handler.ConfigureStreaming(cfg.HTTP.StreamTimeout)
+
+	auth.Configure(auth.Config{
+		JWTSecret:       []byte(cfg.Auth.JWTSecret),
+		TrustUserHeader: cfg.Auth.TrustUserHeader,
+		TrustProxy:      cfg.RateLimit.TrustProxy,
+	})
+	if cfg.Auth.TrustUserHeader {
+		// Anyone can name a user in the header, so keys would protect nothing.
+		n, err := repository.CountAPIKeys(context.Background())
+		if err != nil {
+			return fmt.Errorf("could not count API keys: %w", err)
+		}
+		if n > 0 {
+			return fmt.Errorf("auth.trust_user_header cannot be used while %d API keys are active; delete them or turn the setting off", n)
+		}
+	}
+	if cfg.OIDC.Issuer != "" {
+		oidc.Configure(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+			GroupsClaim:  cfg.OIDC.GroupsClaim,
+			GroupRoles:   groupRoles(cfg.OIDC.GroupRoles),
+			Secret:       []byte(cfg.Auth.JWTSecret),
+		})
+		handler.ConfigureLogin(handler.LoginConfig{
+			DefaultRole:  cfg.OIDC.DefaultRole,
+			TokenTTL:     cfg.Auth.TokenTTL,
+			PostLoginURL: cfg.OIDC.PostLoginURL,
+		})
+	}
+
+	idempotency.Configure(idempotency.Config{
+		TTL:         cfg.Idempotency.TTL,
+		LockTimeout: cfg.Idempotency.LockTimeout,
+	})
+
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Store == "postgres" {
+			store = ratelimit.PostgresStore{}
+		}
+		ratelimit.Configure(ratelimit.Config{
+			Store:   store,
+			Default: newLimit(cfg.RateLimit.Default),
+			Search:  newLimit(cfg.RateLimit.Search),
+			Write:   newLimit(cfg.RateLimit.Write),
+			Auth:    newLimit(cfg.RateLimit.Auth),
+		})
+	}
+
+	r := router.SetupRouter(cfg)
 
 	// Add CORS support
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
 		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
+		AllowCredentials: cfg.CORS.AllowCredentials,
 	})
 
-	handler := c.Handler(r)
+	return run(cfg, c.Handler(r))
+}
+
+// run serves h, and the gRPC API if enabled, with the background workers
+// until SIGINT or SIGTERM. It then shuts down in order: readiness fails,
+// the servers drain, the workers stop and pending notifications are
+// written. The caller closes the database once run returns.
+func run(cfg *config.Config, h http.Handler) error {
+	unsubscribeNotify := notify.Register()
+
+	// Background workers stop only after the servers have drained, so that
+	// requests still in flight see a working system.
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	if cfg.Features.Reminders {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			scheduler.New(scheduler.Config{
+				Interval: cfg.Reminders.Interval,
+				Window:   cfg.Reminders.Window,
+			}).Run(workersCtx)
+		}()
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		idempotency.Sweep(workersCtx, time.Hour)
+	}()
+	if cfg.RateLimit.Enabled {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			ratelimit.Sweep(workersCtx, time.Minute)
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 2)
+	grpcServer, grpcHealth := grpcapi.NewServer()
+	if cfg.Features.GRPC {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			return fmt.Errorf("could not listen for gRPC: %w", err)
+		}
+		go func() {
+			slog.Info("starting gRPC server", "port", cfg.GRPCPort)
+			if err := grpcServer.Serve(lis); err != nil {
+				serveErr <- fmt.Errorf("gRPC server: %w", err)
+			}
+		}()
+	}
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           h,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+	useTLS := cfg.HTTP.TLSCert != "" || cfg.HTTP.TLSKey != ""
+	if useTLS {
+		certs, err := newCertReloader(cfg.HTTP.TLSCert, cfg.HTTP.TLSKey)
+		if err != nil {
+			return fmt.Errorf("could not load TLS certificate: %w", err)
+		}
+		go certs.Watch(ctx, cfg.HTTP.TLSReloadInterval)
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+	}
+	go func() {
+		var err error
+		if useTLS {
+			slog.Info("starting HTTPS server", "addr", srv.Addr)
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			slog.Info("starting HTTP server", "addr", srv.Addr)
+			err = srv.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- fmt.Errorf("HTTP server: %w", err)
+		}
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	case err = <-serveErr:
+		slog.Error("shutting down", "error", err)
+	}
+	// A second signal kills the process without waiting.
+	stop()
+	health.Drain()
+	if err == nil && cfg.HTTP.ShutdownDelay > 0 {
+		time.Sleep(cfg.HTTP.ShutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	var servers sync.WaitGroup
+	servers.Add(1)
+	go func() {
+		defer servers.Done()
+		grpcapi.Shutdown(shutdownCtx, grpcServer, grpcHealth)
+	}()
+	if shutdownErr := srv.Shutdown(shutdownCtx); shutdownErr != nil {
+		slog.Warn("HTTP server did not drain in time", "error", shutdownErr)
+		srv.Close()
+	}
+	servers.Wait()
+
+	stopWorkers()
+	workers.Wait()
+	unsubscribeNotify()
+	notify.Wait()
+	slog.Info("server stopped")
+	return err
+}
+
+// groupRoles parses group=role pairs, already validated by config.
+func groupRoles(pairs []string) []oidc.GroupRole {
+	var roles []oidc.GroupRole
+	for _, pair := range pairs {
+		group, role, _ := strings.Cut(pair, "=")
+		roles = append(roles, oidc.GroupRole{Group: group, Role: role})
+	}
+	return roles
+}
+
+func newLimit(l config.Limit) ratelimit.Limit {
+	return ratelimit.Every(l.Requests, l.Period, l.Burst)
+}
 
-	log.Println("Starting server on :8080")
-	if err := http.ListenAndServe(":8080", handler); err != nil {
-		log.Fatalf("could not start server: %v", err)
+func newBlobStore(cfg *config.Config) (storage.BlobStore, error) {
+	switch cfg.Attachments.Store {
+	case "local":
+		return storage.NewLocalStore(cfg.Attachments.Dir)
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.Attachments.S3.Endpoint,
+			Region:    cfg.Attachments.S3.Region,
+			Bucket:    cfg.Attachments.S3.Bucket,
+			AccessKey: cfg.Attachments.S3.AccessKey,
+			SecretKey: cfg.Attachments.S3.SecretKey,
+			PathStyle: cfg.Attachments.S3.PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown attachment store %q", cfg.Attachments.Store)
 	}
 }
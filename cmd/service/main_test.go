@@ -0,0 +1,151 @@
+package main
+
+import (
+	"HL_project_management/internal/config"
+	"HL_project_management/internal/health"
+	"HL_project_management/internal/repository"
+	"HL_project_management/internal/router"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+// serverStatus returns the server component of /readyz, which does not
+// depend on the database.
+func serverStatus(base string) (string, error) {
+	resp, err := http.Get(base + "/readyz")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var report health.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return "", err
+	}
+	return report.Components["server"].Status, nil
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	cfg := config.Default()
+	cfg.Port = freePort(t)
+	cfg.Features.GRPC = false
+	cfg.Reminders.Interval = 50 * time.Millisecond
+	cfg.HTTP.ShutdownDelay = 300 * time.Millisecond
+	cfg.DB.DSN = os.Getenv("PM_TEST_DATABASE_URL")
+	if cfg.DB.DSN == "" {
+		// Readiness only needs the server component here.
+		cfg.DB.DSN = "postgres://pm:pm@127.0.0.1:1/pm?sslmode=disable"
+	}
+	if _, err := repository.OpenDB(cfg.DB); err != nil {
+		t.Fatal(err)
+	}
+	defer repository.CloseDB()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	mux := http.NewServeMux()
+	mux.Handle("/", router.SetupRouter(cfg))
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "finished")
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- run(cfg, mux) }()
+
+	base := fmt.Sprintf("http://127.0.0.1:%d", cfg.Port)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := serverStatus(base)
+		if err == nil && status == health.StatusOK {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server did not become ready: %q, %v", status, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	type result struct {
+		body string
+		err  error
+	}
+	slow := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			slow <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		slow <- result{string(body), err}
+	}()
+	<-started
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	// During the shutdown delay the server still answers, but not ready.
+	for {
+		status, err := serverStatus(base)
+		if err != nil {
+			t.Fatalf("/readyz during the shutdown delay: %v", err)
+		}
+		if status == health.StatusFailing {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("/readyz did not start failing after SIGTERM")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-done:
+		t.Fatalf("run returned with a request in flight: %v", err)
+	case <-time.After(cfg.HTTP.ShutdownDelay + 100*time.Millisecond):
+	}
+	close(release)
+	if res := <-slow; res.err != nil || res.body != "finished" {
+		t.Errorf("in-flight request got %q, %v", res.body, res.err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not return after the request finished")
+	}
+	// run returns before the database is closed, with every worker gone.
+	workers := health.Live().Components
+	if len(workers) == 0 {
+		t.Error("no workers were started")
+	}
+	for name, c := range workers {
+		if c.Details["stopped"] != true {
+			t.Errorf("%s still running after shutdown", name)
+		}
+	}
+	if _, err := http.Get(base + "/readyz"); err == nil {
+		t.Error("server still accepts requests after shutdown")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// certReloader serves a TLS certificate and reloads it when the files change
+// or the process receives SIGHUP, so that renewed certificates are picked up
+// without a restart.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = r.lastModified()
+	r.mu.Unlock()
+	return nil
+}
+
+// lastModified returns the newer modification time of the two files.
+func (r *certReloader) lastModified() time.Time {
+	var latest time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		if info, err := os.Stat(name); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Watch reloads the certificate on SIGHUP and when the files have changed,
+// checked every interval, until ctx is done. A certificate that fails to
+// load is logged and the previous one kept.
+func (r *certReloader) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-ticker.C:
+			r.mu.RLock()
+			changed := r.lastModified().After(r.modTime)
+			r.mu.RUnlock()
+			if !changed {
+				continue
+			}
+		}
+		if err := r.reload(); err != nil {
+			slog.Error("could not reload TLS certificate", "error", err)
+			continue
+		}
+		slog.Info("reloaded TLS certificate")
+	}
+}
@@ -15,56 +15,33 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
-            "get": {
-                "description": "Health check",
-                "produces": [
-                    "text/plain"
-                ],
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health check",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            }
-        },
-        "/projects": {
+        "/admin/log-level": {
             "get": {
-                "description": "Get all projects",
+                "description": "Get the minimum level the service logs at. Needs the admin token as a bearer token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "admin"
                 ],
-                "summary": "Get all projects",
+                "summary": "Get log level",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Project"
-                            }
+                            "$ref": "#/definitions/handler.LogLevel"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Create a new project",
+            "put": {
+                "description": "Change the minimum level the service logs at until it restarts. Needs the admin token as a bearer token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -72,25 +49,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "admin"
                 ],
-                "summary": "Create a new project",
+                "summary": "Set log level",
                 "parameters": [
                     {
-                        "description": "Project data",
-                        "name": "project",
+                        "description": "Level: debug, info, warn or error",
+                        "name": "level",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Project"
+                            "$ref": "#/definitions/handler.LogLevel"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Project"
+                            "$ref": "#/definitions/handler.LogLevel"
                         }
                     },
                     "400": {
@@ -99,8 +76,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -108,22 +85,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/projects/{id}": {
+        "/auth/oidc/callback": {
             "get": {
-                "description": "Get project by ID",
+                "description": "Called by the identity provider after the user has logged in. Creates the user on their first login, or links the existing user with the same email address, and sets their role from their groups. Returns an access token to send as \"Authorization: Bearer ...\", or redirects to the configured post-login URL with the token in the URL fragment.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "auth"
                 ],
-                "summary": "Get project by ID",
+                "summary": "Finish a single sign-on login",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login state",
+                        "name": "state",
+                        "in": "query",
                         "required": true
                     }
                 ],
@@ -131,68 +115,133 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Project"
+                            "$ref": "#/definitions/handler.LoginResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid ID",
+                    "302": {
+                        "description": "Found"
+                    },
+                    "401": {
+                        "description": "Login failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "No role for this user",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Identity provider unavailable",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/login": {
+            "get": {
+                "description": "Redirect the browser to the OpenID Connect identity provider to log in. The provider sends it back to /auth/oidc/callback.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in with single sign-on",
+                "responses": {
+                    "302": {
+                        "description": "Found"
                     },
                     "404": {
-                        "description": "Project not found",
+                        "description": "Single sign-on is not configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Identity provider unavailable",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update project",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Always answers OK while the process is up. Kept for existing monitors; use /livez and /readyz instead.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
                 ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/livez": {
+            "get": {
+                "description": "Reports whether the process is working. Fails when a background worker has stalled.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "health"
                 ],
-                "summary": "Update project",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
                     },
-                    {
-                        "description": "Project data",
-                        "name": "project",
-                        "in": "body",
-                        "required": true,
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
-                            "$ref": "#/definitions/model.Project"
+                            "$ref": "#/definitions/health.Report"
                         }
                     }
+                }
+            }
+        },
+        "/me/api-keys": {
+            "get": {
+                "description": "List the API keys of the current user. The keys themselves are not included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
                 ],
+                "summary": "Get my API keys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Project"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.APIKey"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Invalid input",
+                    "401": {
+                        "description": "Sign in with an access token",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Project not found",
+                    "403": {
+                        "description": "Not available with an API key or scoped token",
                         "schema": {
                             "type": "string"
                         }
@@ -205,39 +254,56 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "Delete project",
+            "post": {
+                "description": "Create an API key acting as the current user, who has to sign in with an access token, limited to the given scopes: tasks:read, tasks:write, projects:read, projects:write, projects:admin, users:read and users:write. Write includes read, and admin includes both. Send it as \"Authorization: Bearer pm_...\". The key is only returned in this response.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "api-keys"
                 ],
-                "summary": "Delete project",
+                "summary": "Create an API key",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Key name, scopes and optional expiry",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.APIKey"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Deleted successfully",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/model.APIKey"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID",
+                        "description": "Invalid input",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Project not found",
+                    "401": {
+                        "description": "Sign in with an access token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Not available with an API key or scoped token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "string"
                         }
@@ -245,20 +311,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/projects/{id}/tasks": {
-            "get": {
-                "description": "Get tasks by project ID",
+        "/me/api-keys/{id}": {
+            "delete": {
+                "description": "Delete an API key of the current user. Requests made with it fail from then on.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "api-keys"
                 ],
-                "summary": "Get tasks by project ID",
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
+                        "description": "API key ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -266,12 +332,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Deleted successfully",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Task"
-                            }
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -280,8 +343,20 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Sign in with an access token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Not available with an API key or scoped token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "Tasks not found",
+                        "description": "API key not found",
                         "schema": {
                             "type": "string"
                         }
@@ -289,27 +364,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/search/projects": {
+        "/me/notifications": {
             "get": {
-                "description": "Search projects by title or manager",
+                "description": "Get notifications of the current user, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "notifications"
                 ],
-                "summary": "Search projects",
+                "summary": "Get my notifications",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Project title",
-                        "name": "title",
+                        "type": "boolean",
+                        "description": "Only unread notifications",
+                        "name": "unread",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Manager ID",
-                        "name": "manager",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of notifications to skip",
+                        "name": "offset",
                         "in": "query"
                     }
                 ],
@@ -319,7 +402,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Project"
+                                "$ref": "#/definitions/model.Notification"
                             }
                         }
                     },
@@ -328,64 +411,47 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/search/tasks": {
-            "get": {
-                "description": "Search tasks by title, priority, status, assignee, or project",
+        "/me/notifications/read-all": {
+            "post": {
+                "description": "Mark all notifications of the current user as read",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "notifications"
                 ],
-                "summary": "Search tasks",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Task title",
-                        "name": "title",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Task priority",
-                        "name": "priority",
-                        "in": "query"
+                "summary": "Mark all notifications as read",
+                "responses": {
+                    "200": {
+                        "description": "Marked as read",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Task status",
-                        "name": "status",
-                        "in": "query"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
-                    {
-                        "type": "integer",
-                        "description": "Assignee ID",
-                        "name": "assignee",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Project ID",
-                        "name": "project",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Task"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid input",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "string"
                         }
@@ -393,42 +459,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/search/users": {
-            "get": {
-                "description": "Search users by name or email",
+        "/me/notifications/{id}/read": {
+            "post": {
+                "description": "Mark a notification of the current user as read",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "notifications"
                 ],
-                "summary": "Search users by name or email",
+                "summary": "Mark notification as read",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User name",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "User email",
-                        "name": "email",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Notification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Marked as read",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.User"
-                            }
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
                         "schema": {
                             "type": "string"
                         }
@@ -436,26 +506,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/tasks": {
+        "/me/views": {
             "get": {
-                "description": "Get all tasks",
+                "description": "Get the current user's saved views followed by views shared with them",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "views"
                 ],
-                "summary": "Get all tasks",
+                "summary": "Get my views",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Task"
+                                "$ref": "#/definitions/model.SavedView"
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -465,7 +541,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new task",
+                "description": "Save a task filter as a view of the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -473,25 +549,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "views"
                 ],
-                "summary": "Create a new task",
+                "summary": "Create a view",
                 "parameters": [
                     {
-                        "description": "Task data",
-                        "name": "task",
+                        "description": "View data",
+                        "name": "view",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Task"
+                            "$ref": "#/definitions/model.SavedView"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retrying the request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Task"
+                            "$ref": "#/definitions/model.SavedView"
                         }
                     },
                     "400": {
@@ -500,6 +582,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -509,20 +597,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/tasks/{id}": {
+        "/me/views/{id}": {
             "get": {
-                "description": "Get task by ID",
+                "description": "Get a saved view of the current user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "views"
                 ],
-                "summary": "Get task by ID",
+                "summary": "Get my view",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
+                        "description": "View ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -532,7 +620,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Task"
+                            "$ref": "#/definitions/model.SavedView"
                         }
                     },
                     "400": {
@@ -541,8 +629,14 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "Task not found",
+                        "description": "View not found",
                         "schema": {
                             "type": "string"
                         }
@@ -550,7 +644,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Update task",
+                "description": "Update a saved view of the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -558,24 +652,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "views"
                 ],
-                "summary": "Update task",
+                "summary": "Update a view",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
+                        "description": "View ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Task data",
-                        "name": "task",
+                        "description": "View data",
+                        "name": "view",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Task"
+                            "$ref": "#/definitions/model.SavedView"
                         }
                     }
                 ],
@@ -583,7 +677,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Task"
+                            "$ref": "#/definitions/model.SavedView"
                         }
                     },
                     "400": {
@@ -592,8 +686,14 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "Task not found",
+                        "description": "View not found",
                         "schema": {
                             "type": "string"
                         }
@@ -607,18 +707,18 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete task",
+                "description": "Delete a saved view of the current user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "views"
                 ],
-                "summary": "Delete task",
+                "summary": "Delete a view",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
+                        "description": "View ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -637,8 +737,14 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "Task not found",
+                        "description": "View not found",
                         "schema": {
                             "type": "string"
                         }
@@ -646,23 +752,62 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/projects": {
             "get": {
-                "description": "Get all users",
+                "description": "Get all projects",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
-                    "users"
+                    "projects"
+                ],
+                "summary": "Get all projects",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format, also negotiated with the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Start CSV output with a UTF-8 byte order mark",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100); without limit and offset the whole list is returned",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.User"
+                                "$ref": "#/definitions/model.Project"
                             }
                         }
                     },
@@ -675,7 +820,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new user",
+                "description": "Create a new project",
                 "consumes": [
                     "application/json"
                 ],
@@ -683,25 +828,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "projects"
                 ],
-                "summary": "Create a new user",
+                "summary": "Create a new project",
                 "parameters": [
                     {
-                        "description": "User data",
-                        "name": "user",
+                        "description": "Project data",
+                        "name": "project",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/model.Project"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retrying the request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/model.Project"
                         }
                     },
                     "400": {
@@ -719,20 +870,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
+        "/projects/{id}": {
             "get": {
-                "description": "Get user by ID",
+                "description": "Get project by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "projects"
                 ],
-                "summary": "Get user by ID",
+                "summary": "Get project by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Project ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -742,7 +893,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/model.Project"
                         }
                     },
                     "400": {
@@ -752,7 +903,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "Project not found",
                         "schema": {
                             "type": "string"
                         }
@@ -760,7 +911,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Update user",
+                "description": "Update project",
                 "consumes": [
                     "application/json"
                 ],
@@ -768,24 +919,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "projects"
                 ],
-                "summary": "Update user",
+                "summary": "Update project",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Project ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "User data",
-                        "name": "user",
+                        "description": "Project data",
+                        "name": "project",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/model.Project"
                         }
                     }
                 ],
@@ -793,7 +944,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/model.Project"
                         }
                     },
                     "400": {
@@ -803,7 +954,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "Project not found",
                         "schema": {
                             "type": "string"
                         }
@@ -817,18 +968,18 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete user",
+                "description": "Delete project",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "projects"
                 ],
-                "summary": "Delete user",
+                "summary": "Delete project",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Project ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -848,7 +999,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "Project not found",
                         "schema": {
                             "type": "string"
                         }
@@ -856,52 +1007,2320 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}/tasks": {
-            "get": {
-                "description": "Get tasks by user ID",
+        "/projects/{id}/import": {
+            "post": {
+                "description": "Import tasks into a project from a CSV file, a Jira CSV export or a Trello JSON export, sent as the request body or as the \"file\" field of a multipart form.\nAssignees are matched to users by email, or for Trello by username. Every row is validated first: with dry_run the report is returned without importing,\notherwise the tasks are created in one transaction only when no row has errors.",
+                "consumes": [
+                    "text/csv",
+                    "application/json",
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "tasks"
                 ],
-                "summary": "Get tasks by user ID",
+                "summary": "Import tasks",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Project ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Format of the file: csv, jira or trello, by default trello for JSON and csv otherwise",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object mapping fields (title, description, priority, status, assignee, due) to CSV column names, e.g. {\\",
+                        "name": "mapping",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the user assigned to rows without an assignee",
+                        "name": "default_assignee",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the file",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retrying the request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Dry run report",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Task"
-                            }
+                            "$ref": "#/definitions/model.ImportReport"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportReport"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID",
+                        "description": "Invalid input",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "Tasks not found",
+                        "description": "Project not found",
                         "schema": {
                             "type": "string"
                         }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Some rows are invalid, nothing was imported",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/tasks": {
+            "get": {
+                "description": "Get tasks by project ID",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get tasks by project ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format, also negotiated with the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Start CSV output with a UTF-8 byte order mark",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100); without limit and offset the whole list is returned",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Task"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Tasks not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/watchers": {
+            "get": {
+                "description": "Get users watching all tasks of a project",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchers"
+                ],
+                "summary": "Get project watchers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribe the current user to changes of all tasks of a project",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchers"
+                ],
+                "summary": "Watch project",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Watching",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Unsubscribe the current user from changes of a project",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchers"
+                ],
+                "summary": "Unwatch project",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Not watching",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects:batch": {
+            "post": {
+                "description": "Create, update and delete up to 1000 projects in one transaction. Each operation has an op (create, update or delete), the id for update and delete, and the project as data for create and update.\nThe response has a result with an HTTP status for every operation. Without continue_on_error any failure rolls back the whole batch and the response is 422.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Batch write projects",
+                "parameters": [
+                    {
+                        "description": "Operations",
+                        "name": "operations",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BatchOperation"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Commit the operations that succeed even if others fail",
+                        "name": "continue_on_error",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retrying the request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Some operations failed, nothing was written",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Reports whether the server can take traffic: the database answers, its schema is at the version the binary expects and the server is not shutting down. Background workers are listed for information.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Search tasks, projects, comments and users at once, best matches first.\nWords are matched together, \"quoted phrases\" match adjacent words, word* matches prefixes, -word excludes and OR matches either side.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Full-text search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "deploy* \"release notes\" -draft",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "task,project,comment,user",
+                        "description": "Comma-separated types to search (task, project, comment, user)",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of results to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.SearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/projects": {
+            "get": {
+                "description": "Search projects by a case-insensitive substring of their title and by manager.\nDeprecated: use GET /search?types=project, which matches words and ranks the results.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Search projects",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Manager ID",
+                        "name": "manager",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format, also negotiated with the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Start CSV output with a UTF-8 byte order mark",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100); without limit and offset the whole list is returned",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Project"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/tasks": {
+            "get": {
+                "description": "Search tasks with q, a filter expression such as priority:high AND (status:new OR status:in_progress) AND created\u003e2024-01-01 AND assignee:me.\nFields: id, title, description, text, priority, status, assignee, project, created, due, completed. Operators: : = != \u003e \u003e= \u003c \u003c=. Terms combine with AND, OR, NOT (or -) and parentheses.\nDeprecated: without q, tasks are matched by case-insensitive substrings of title, priority and status and by assignee and project; use q, or GET /search?types=task for ranked full-text search.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Search tasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter expression; the other search parameters are ignored when it is set",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deprecated: substring of the task title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deprecated: substring of the task priority",
+                        "name": "priority",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deprecated: substring of the task status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Deprecated: assignee ID",
+                        "name": "assignee",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Deprecated: project ID",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format, also negotiated with the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Start CSV output with a UTF-8 byte order mark",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100); without limit and offset the whole list is returned",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Task"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/users": {
+            "get": {
+                "description": "Search users by a case-insensitive substring of their name or email.\nDeprecated: use GET /search?types=user, which matches words and ranks the results.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Search users by name or email",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User email",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format, also negotiated with the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Start CSV output with a UTF-8 byte order mark",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100); without limit and offset the whole list is returned",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "get": {
+                "description": "Get all tasks",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get all tasks",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format, also negotiated with the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Start CSV output with a UTF-8 byte order mark",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100); without limit and offset the whole list is returned",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Task"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Create a new task",
+                "parameters": [
+                    {
+                        "description": "Task data",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Task"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retrying the request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}": {
+            "get": {
+                "description": "Get task by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get task by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Update task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Task data",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Task"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Delete task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/attachments": {
+            "get": {
+                "description": "Get metadata of files attached to a task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Get task attachments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Attachment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Attach a file to a task. The file is sent in the \"file\" field of a multipart form. If X-Checksum-SHA256 is given, the upload is rejected unless the content matches it.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Upload task attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to attach",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hex-encoded SHA-256 of the file",
+                        "name": "X-Checksum-SHA256",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Attachment"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported file type",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/attachments/{attachmentId}": {
+            "get": {
+                "description": "Download an attached file. Range requests are supported.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Download task attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Attachment not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an attached file",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Delete task attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Attachment not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/comments": {
+            "get": {
+                "description": "Get comments of a task, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get task comments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Comment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a comment to a task as the current user. Users @mentioned in the comment start watching the task.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Comment on task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment data",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Comment"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retrying the request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/watchers": {
+            "get": {
+                "description": "Get users watching a task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchers"
+                ],
+                "summary": "Get task watchers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribe the current user to changes of a task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchers"
+                ],
+                "summary": "Watch task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Watching",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Unsubscribe the current user from changes of a task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchers"
+                ],
+                "summary": "Unwatch task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Not watching",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks:batch": {
+            "post": {
+                "description": "Create, update and delete up to 1000 tasks in one transaction. Each operation has an op (create, update or delete), the id for update and delete, and the task as data for create and update.\nThe response has a result with an HTTP status for every operation. Without continue_on_error any failure rolls back the whole batch and the response is 422.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Batch write tasks",
+                "parameters": [
+                    {
+                        "description": "Operations",
+                        "name": "operations",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BatchOperation"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Commit the operations that succeed even if others fail",
+                        "name": "continue_on_error",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retrying the request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Some operations failed, nothing was written",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "Get all users",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get all users",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format, also negotiated with the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Start CSV output with a UTF-8 byte order mark",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100); without limit and offset the whole list is returned",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retrying the request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "description": "Get user by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/tasks": {
+            "get": {
+                "description": "Get tasks by user ID",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get tasks by user ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format, also negotiated with the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Start CSV output with a UTF-8 byte order mark",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100); without limit and offset the whole list is returned",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Task"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Tasks not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users:batch": {
+            "post": {
+                "description": "Create, update and delete up to 1000 users in one transaction. Each operation has an op (create, update or delete), the id for update and delete, and the user as data for create and update.\nThe response has a result with an HTTP status for every operation. Without continue_on_error any failure rolls back the whole batch and the response is 422.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Batch write users",
+                "parameters": [
+                    {
+                        "description": "Operations",
+                        "name": "operations",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BatchOperation"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Commit the operations that succeed even if others fail",
+                        "name": "continue_on_error",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retrying the request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Some operations failed, nothing was written",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/views/{id}/tasks": {
+            "get": {
+                "description": "Run a saved view owned by or shared with the current user. In a shared view, assignee:me refers to the user running it.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "views"
+                ],
+                "summary": "Get tasks of a view",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "View ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format, also negotiated with the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Start CSV output with a UTF-8 byte order mark",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100); without limit and offset the whole list is returned",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Task"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "View not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.LogLevel": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "type": "string",
+                    "example": "info"
+                }
+            }
+        },
+        "handler.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "accessToken": {
+                    "type": "string"
+                },
+                "expiresIn": {
+                    "type": "integer",
+                    "example": 3600
+                },
+                "tokenType": {
+                    "type": "string",
+                    "example": "Bearer"
+                },
+                "user": {
+                    "$ref": "#/definitions/model.User"
+                }
+            }
+        },
+        "health.Component": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.Component"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.APIKey": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "expiresAt": {
+                    "type": "string",
+                    "example": "2025-09-20T15:04:05Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true
+                },
+                "key": {
+                    "description": "Key is the key itself, set only in the response to creating it.",
+                    "type": "string",
+                    "readOnly": true
+                },
+                "lastUsedAt": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "CI"
+                },
+                "prefix": {
+                    "description": "Prefix is the start of the key, enough to tell keys apart.",
+                    "type": "string",
+                    "readOnly": true,
+                    "example": "pm_1a2b3c4d"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "tasks:read",
+                        "tasks:write"
+                    ]
+                },
+                "userId": {
+                    "type": "integer",
+                    "readOnly": true
+                }
+            }
+        },
+        "model.Attachment": {
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "type": "string",
+                    "example": "hex-encoded SHA-256 of the content"
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "taskId": {
+                    "type": "integer"
+                },
+                "uploaderId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.BatchOperation": {
+            "type": "object",
+            "required": [
+                "op"
+            ],
+            "properties": {
+                "data": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "create"
+                }
+            }
+        },
+        "model.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BatchResult"
+                    }
+                }
+            }
+        },
+        "model.BatchResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 201
+                }
+            }
+        },
+        "model.Comment": {
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "authorId": {
+                    "type": "integer",
+                    "readOnly": true
+                },
+                "body": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true
+                },
+                "taskId": {
+                    "type": "integer",
+                    "readOnly": true
+                }
+            }
+        },
+        "model.ImportReport": {
+            "type": "object",
+            "properties": {
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImportRowError"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Task"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "line": {
+                    "type": "integer"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.Notification": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true
+                },
+                "message": {
+                    "type": "string"
+                },
+                "readAt": {
+                    "type": "string"
+                },
+                "taskId": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
         "model.Project": {
             "type": "object",
             "required": [
@@ -933,6 +3352,77 @@ const docTemplate = `{
                 }
             }
         },
+        "model.SavedView": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "readOnly": true
+                },
+                "filter": {
+                    "type": "string",
+                    "example": "priority:high AND assignee:me"
+                },
+                "id": {
+                    "type": "integer",
+                    "readOnly": true
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "ownerId": {
+                    "type": "integer",
+                    "readOnly": true
+                },
+                "projectId": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "shared": {
+                    "type": "boolean"
+                },
+                "sort": {
+                    "description": "Sort lists fields to order by, descending if prefixed with \"-\".",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "-priority,due"
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "readOnly": true
+                }
+            }
+        },
+        "model.SearchResult": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "snippet": {
+                    "description": "Snippet is an HTML excerpt of the matching text: the text is escaped\nand matches are wrapped in \u003cmark\u003e\u003c/mark\u003e.",
+                    "type": "string"
+                },
+                "taskId": {
+                    "description": "TaskID is the task a matching comment belongs to.",
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "task"
+                }
+            }
+        },
         "model.Task": {
             "type": "object",
             "required": [
@@ -957,6 +3447,10 @@ const docTemplate = `{
                 "description": {
                     "type": "string"
                 },
+                "dueAt": {
+                    "type": "string",
+                    "example": "2024-09-20T15:04:05Z"
+                },
                 "id": {
                     "type": "integer",
                     "readOnly": true
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, telling it apart from other bearer
+// tokens and making leaked keys easy to search for.
+const APIKeyPrefix = "pm_"
+
+// lastUsedPrecision is how stale the last use of a key may get before it
+// is recorded again, so that a busy key does not cost a write per request.
+const lastUsedPrecision = time.Minute
+
+// NewAPIKey generates a key. It returns the key, to be shown to its owner
+// once, the prefix that identifies it in listings and the hash to store.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + hex.EncodeToString(b)
+	return key, key[:len(APIKeyPrefix)+8], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the stored form of a key. Keys are random and long, so
+// a plain SHA-256 is as good as a password hash and far cheaper.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func checkAPIKey(ctx context.Context, key string) (identity, error) {
+	k, err := repository.GetAPIKeyByHash(ctx, HashAPIKey(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return identity{}, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+	if err != nil {
+		return identity{}, err
+	}
+	now := time.Now()
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return identity{}, fmt.Errorf("%w: API key %s has expired", ErrInvalidCredentials, k.Prefix)
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > lastUsedPrecision {
+		if err := repository.TouchAPIKey(ctx, k.ID, now); err != nil {
+			logging.FromContext(ctx).Error("auth: could not record API key use", "key", k.Prefix, "error", err)
+		}
+	}
+	scopes := k.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return identity{userID: k.UserID, scopes: scopes, apiKeyID: k.ID, verified: true}, nil
+}
@@ -0,0 +1,167 @@
+// Package auth identifies the user on whose behalf a request is made.
+package auth
+
+import (
+	"HL_project_management/internal/logging"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type Config struct {
+	// JWTSecret is the HS256 key of access tokens; without it bearer tokens
+	// other than API keys are not checked.
+	JWTSecret []byte
+	// TrustUserHeader accepts the X-User-ID header as the user, as set by
+	// an authenticating gateway. It is ignored when JWTSecret is set.
+	TrustUserHeader bool
+	// TrustProxy takes the client address from the last X-Forwarded-For
+	// entry, added by the proxy in front of the service.
+	TrustProxy bool
+}
+
+var config Config
+
+func Configure(cfg Config) {
+	config = cfg
+}
+
+// ErrInvalidCredentials is returned by Authenticate for credentials that
+// were presented but are not valid.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+type contextKey struct{}
+
+// identity is who a request is made by and what it may do.
+type identity struct {
+	userID int
+	// scopes limits the request to these scopes; nil leaves it unlimited.
+	scopes []string
+	// apiKeyID is the API key the request was made with, if any.
+	apiKeyID int
+	// verified is set when the user was proven by an API key or a token
+	// rather than taken from a header.
+	verified bool
+}
+
+// Middleware resolves the calling user with Authenticate and stores it in
+// the request context. Other bearer tokens, such as the admin token, are
+// left to the routes that expect them. Requests without credentials are
+// passed through anonymously.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := Authenticate(r.Context(), r.Header.Get("Authorization"), r.Header.Get("X-User-ID"))
+		if errors.Is(err, ErrInvalidCredentials) {
+			logging.FromContext(r.Context()).Info("auth: credentials rejected", "error", err)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Invalid user", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Authenticate identifies the caller from the values of the Authorization
+// and X-User-ID headers, or their gRPC metadata counterparts: an API key or
+// a JWT access token sent as a bearer token or, only if TrustUserHeader is
+// set, the user ID set by the gateway in front of the service; otherwise
+// the user ID is refused. The returned context carries the caller; without
+// credentials it is ctx itself. Rejected credentials give an error wrapping
+// ErrInvalidCredentials.
+func Authenticate(ctx context.Context, authorization, userID string) (context.Context, error) {
+	var id identity
+	var err error
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		token = ""
+	}
+	switch {
+	case strings.HasPrefix(token, APIKeyPrefix):
+		id, err = checkAPIKey(ctx, token)
+	case len(config.JWTSecret) > 0 && strings.Count(token, ".") == 2:
+		id, err = checkJWT(token)
+	case userID != "" && !trustUserHeader():
+		err = fmt.Errorf("%w: X-User-ID is not accepted, use an API key or an access token", ErrInvalidCredentials)
+	case userID != "":
+		id.userID, err = strconv.Atoi(userID)
+		if err != nil || id.userID <= 0 {
+			err = ErrInvalidCredentials
+		}
+	default:
+		return ctx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	logging.AddAttrs(ctx, "user_id", id.userID)
+	return context.WithValue(ctx, contextKey{}, id), nil
+}
+
+func trustUserHeader() bool {
+	return config.TrustUserHeader && len(config.JWTSecret) == 0
+}
+
+func WithUserID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity{userID: id})
+}
+
+// UserID returns the ID of the authenticated user, if any.
+func UserID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(contextKey{}).(identity)
+	return id.userID, ok
+}
+
+// VerifiedUserID returns the ID of the authenticated user if it was proven
+// by an API key or an access token.
+func VerifiedUserID(ctx context.Context) (int, bool) {
+	id, _ := ctx.Value(contextKey{}).(identity)
+	return id.userID, id.verified
+}
+
+// APIKeyID returns the ID of the API key the request was made with, if any.
+func APIKeyID(ctx context.Context) (int, bool) {
+	id, _ := ctx.Value(contextKey{}).(identity)
+	return id.apiKeyID, id.apiKeyID != 0
+}
+
+// ClientAddr returns the IP address of the client that made r.
+func ClientAddr(r *http.Request) string {
+	if config.TrustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			addrs := strings.Split(fwd, ",")
+			if addr := strings.TrimSpace(addrs[len(addrs)-1]); addr != "" {
+				return addr
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RequireToken rejects requests that do not carry token as a bearer token.
+// It guards the admin endpoints.
+func RequireToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
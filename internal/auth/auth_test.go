@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	Configure(Config{JWTSecret: secret})
+	token, err := NewToken(7, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := NewToken(7, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Configure(Config{}) })
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		header   http.Header
+		status   int
+		user     int
+		verified bool
+	}{
+		{"anonymous", Config{}, nil, 200, 0, false},
+		{"header refused by default", Config{}, http.Header{"X-User-Id": {"1"}}, 401, 0, false},
+		{"header trusted behind a gateway", Config{TrustUserHeader: true}, http.Header{"X-User-Id": {"1"}}, 200, 1, false},
+		{"invalid trusted header", Config{TrustUserHeader: true}, http.Header{"X-User-Id": {"x"}}, 401, 0, false},
+		{"header refused with a JWT secret", Config{JWTSecret: secret, TrustUserHeader: true}, http.Header{"X-User-Id": {"1"}}, 401, 0, false},
+		{"token", Config{JWTSecret: secret}, http.Header{"Authorization": {"Bearer " + token}}, 200, 7, true},
+		{"token wins over the header", Config{JWTSecret: secret}, http.Header{"Authorization": {"Bearer " + token}, "X-User-Id": {"1"}}, 200, 7, true},
+		{"expired token", Config{JWTSecret: secret}, http.Header{"Authorization": {"Bearer " + expired}}, 401, 0, false},
+		{"token with another secret", Config{JWTSecret: []byte("fedcba9876543210fedcba9876543210")}, http.Header{"Authorization": {"Bearer " + token}}, 401, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Configure(tt.cfg)
+			var user int
+			var verified bool
+			h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				user, _ = UserID(r.Context())
+				_, verified = VerifiedUserID(r.Context())
+			}))
+			r := httptest.NewRequest("GET", "/tasks", nil)
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status || user != tt.user || verified != tt.verified {
+				t.Errorf("got %d, user %d, verified %v; want %d, user %d, verified %v", w.Code, user, verified, tt.status, tt.user, tt.verified)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// claims are those of an access token: the user ID as the subject, an
+// expiry and, optionally, the space-separated scopes the token is limited to.
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+func checkJWT(token string) (identity, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return config.JWTSecret, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired(), jwt.WithLeeway(30*time.Second))
+	if err != nil {
+		return identity{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil || id <= 0 {
+		return identity{}, fmt.Errorf("%w: invalid subject %q", ErrInvalidCredentials, c.Subject)
+	}
+	ident := identity{userID: id, verified: true}
+	if c.Scope != "" {
+		ident.scopes = strings.Fields(c.Scope)
+	}
+	return ident, nil
+}
+
+// NewToken issues an unlimited access token for the user, valid for ttl.
+func NewToken(userID int, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   strconv.Itoa(userID),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}}).SignedString(config.JWTSecret)
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Scopes an API key or access token can be limited to. Each is a resource
+// and a level; a higher level includes the lower ones, so tasks:write also
+// grants tasks:read.
+const (
+	TasksRead     = "tasks:read"
+	TasksWrite    = "tasks:write"
+	ProjectsRead  = "projects:read"
+	ProjectsWrite = "projects:write"
+	// ProjectsAdmin allows deleting projects.
+	ProjectsAdmin = "projects:admin"
+	UsersRead     = "users:read"
+	UsersWrite    = "users:write"
+)
+
+// KnownScopes lists every scope.
+var KnownScopes = []string{TasksRead, TasksWrite, ProjectsRead, ProjectsWrite, ProjectsAdmin, UsersRead, UsersWrite}
+
+var levels = map[string]int{"read": 1, "write": 2, "admin": 3}
+
+// grants reports whether the scope granted includes the scope wanted.
+func grants(granted, wanted string) bool {
+	gRes, gLevel, _ := strings.Cut(granted, ":")
+	wRes, wLevel, _ := strings.Cut(wanted, ":")
+	return gRes == wRes && levels[gLevel] >= levels[wLevel]
+}
+
+// HasScope reports whether the request may act within scope. Only requests
+// limited to scopes, such as those made with an API key, can fail it.
+func HasScope(ctx context.Context, scope string) bool {
+	id, _ := ctx.Value(contextKey{}).(identity)
+	if id.scopes == nil {
+		return true
+	}
+	return slices.ContainsFunc(id.scopes, func(granted string) bool { return grants(granted, scope) })
+}
+
+// CheckScopes reports why the caller may not act with the scopes needed, or
+// nil if it may. listed is false for operations closed to limited callers.
+// Unlimited callers always may.
+func CheckScopes(ctx context.Context, needed []string, listed bool) error {
+	id, _ := ctx.Value(contextKey{}).(identity)
+	if id.scopes == nil {
+		return nil
+	}
+	if !listed {
+		return errors.New("Not available with an API key or scoped token")
+	}
+	for _, scope := range needed {
+		if !HasScope(ctx, scope) {
+			return errors.New("Missing scope " + scope)
+		}
+	}
+	return nil
+}
+
+// RequireScopes returns a middleware checking the scopes of limited
+// requests against routes, which maps a method and route template such as
+// "GET /tasks/{id}" to the scopes it needs. Routes that are not listed are
+// closed to limited requests. Install it with mux.Router.Use after
+// Middleware; unlimited requests pass untouched.
+func RequireScopes(routes map[string][]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var tmpl string
+			if route := mux.CurrentRoute(r); route != nil {
+				tmpl, _ = route.GetPathTemplate()
+			}
+			needed, ok := routes[r.Method+" "+tmpl]
+			if err := CheckScopes(r.Context(), needed, ok); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
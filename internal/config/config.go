@@ -0,0 +1,313 @@
+// Package config loads the service configuration. Settings come from, in
+// increasing order of precedence:
+//
+//  1. the defaults in Default,
+//  2. a YAML or TOML file given by -config or PM_CONFIG,
+//  3. environment variables named PM_ followed by the setting's path, such
+//     as PM_DB_DSN or PM_HTTP_READ_TIMEOUT, and a few older names like url
+//     and S3_BUCKET,
+//  4. command-line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Environments.
+const (
+	Development = "development"
+	Staging     = "staging"
+	Production  = "production"
+)
+
+// Config holds every setting. Fields tagged secret are redacted by Print; a
+// value of "dsn" only hides the password of a connection string.
+type Config struct {
+	Env      string `yaml:"env" toml:"env" validate:"oneof=development staging production"`
+	Port     int    `yaml:"port" toml:"port" validate:"min=1,max=65535"`
+	GRPCPort int    `yaml:"grpc_port" toml:"grpc_port" validate:"min=1,max=65535,nefield=Port"`
+
+	DB          DB          `yaml:"db" toml:"db"`
+	HTTP        HTTP        `yaml:"http" toml:"http"`
+	CORS        CORS        `yaml:"cors" toml:"cors"`
+	Auth        Auth        `yaml:"auth" toml:"auth"`
+	Reminders   Reminders   `yaml:"reminders" toml:"reminders"`
+	Idempotency Idempotency `yaml:"idempotency" toml:"idempotency"`
+	Attachments Attachments `yaml:"attachments" toml:"attachments"`
+	Features    Features    `yaml:"features" toml:"features"`
+	Tracing     Tracing     `yaml:"tracing" toml:"tracing"`
+	Logging     Logging     `yaml:"logging" toml:"logging"`
+	Admin       Admin       `yaml:"admin" toml:"admin"`
+	RateLimit   RateLimit   `yaml:"rate_limit" toml:"rate_limit"`
+	OIDC        OIDC        `yaml:"oidc" toml:"oidc"`
+}
+
+type DB struct {
+	DSN             string        `yaml:"dsn" toml:"dsn" env:"url" secret:"dsn" validate:"required"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns" validate:"min=0"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns" validate:"min=0"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" validate:"min=0"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" validate:"min=0"`
+	// Migrate applies pending migrations when the server starts.
+	Migrate bool `yaml:"migrate" toml:"migrate"`
+}
+
+type HTTP struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout" validate:"gt=0"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" validate:"gt=0"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout" validate:"gt=0"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout" validate:"gt=0"`
+	// StreamTimeout replaces the read and write timeouts for exports,
+	// imports and attachment uploads and downloads.
+	StreamTimeout   time.Duration `yaml:"stream_timeout" toml:"stream_timeout" validate:"gt=0"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" validate:"gt=0"`
+	// ShutdownDelay keeps the server answering after a signal, with readiness
+	// failing, so that load balancers take it out of rotation first.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" validate:"min=0"`
+	// TLSCert and TLSKey switch the server to HTTPS.
+	TLSCert           string        `yaml:"tls_cert" toml:"tls_cert" validate:"required_with=TLSKey,omitempty,file"`
+	TLSKey            string        `yaml:"tls_key" toml:"tls_key" validate:"required_with=TLSCert,omitempty,file"`
+	TLSReloadInterval time.Duration `yaml:"tls_reload_interval" toml:"tls_reload_interval" validate:"gt=0"`
+}
+
+type CORS struct {
+	AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins" validate:"dive,required"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials"`
+}
+
+type Auth struct {
+	// JWTSecret is the HS256 key of access tokens.
+	JWTSecret string `yaml:"jwt_secret" toml:"jwt_secret" secret:"true" validate:"omitempty,min=32"`
+	// TrustUserHeader takes the user from the X-User-ID header, for
+	// deployments behind a gateway that authenticates users and sets it.
+	// Anyone who can reach the service directly can then act as any user,
+	// so it cannot be combined with access tokens or API keys.
+	TrustUserHeader bool `yaml:"trust_user_header" toml:"trust_user_header"`
+	// TokenTTL is how long access tokens issued at login are valid.
+	TokenTTL time.Duration `yaml:"token_ttl" toml:"token_ttl" validate:"gt=0"`
+}
+
+type Reminders struct {
+	Interval time.Duration `yaml:"interval" toml:"interval" validate:"gt=0"`
+	// Window is how long before the due date assignees are reminded.
+	Window time.Duration `yaml:"window" toml:"window" validate:"gt=0"`
+}
+
+type Idempotency struct {
+	TTL         time.Duration `yaml:"ttl" toml:"ttl" validate:"gt=0"`
+	LockTimeout time.Duration `yaml:"lock_timeout" toml:"lock_timeout" validate:"gt=0"`
+}
+
+type Attachments struct {
+	Store        string   `yaml:"store" toml:"store" validate:"oneof=local s3"`
+	Dir          string   `yaml:"dir" toml:"dir" validate:"required_if=Store local"`
+	MaxSize      int64    `yaml:"max_size" toml:"max_size" validate:"gt=0"`
+	AllowedTypes []string `yaml:"allowed_types" toml:"allowed_types"`
+	S3           S3       `yaml:"s3" toml:"s3"`
+}
+
+type S3 struct {
+	Endpoint  string `yaml:"endpoint" toml:"endpoint" env:"S3_ENDPOINT" validate:"omitempty,url"`
+	Region    string `yaml:"region" toml:"region" env:"S3_REGION"`
+	Bucket    string `yaml:"bucket" toml:"bucket" env:"S3_BUCKET"`
+	AccessKey string `yaml:"access_key" toml:"access_key" env:"S3_ACCESS_KEY" secret:"true"`
+	SecretKey string `yaml:"secret_key" toml:"secret_key" env:"S3_SECRET_KEY" secret:"true"`
+	PathStyle bool   `yaml:"path_style" toml:"path_style"`
+}
+
+// Features turn optional parts of the service on and off.
+type Features struct {
+	GraphQL bool `yaml:"graphql" toml:"graphql"`
+	// GraphiQL serves the GraphQL IDE outside development too.
+	GraphiQL  bool `yaml:"graphiql" toml:"graphiql"`
+	GRPC      bool `yaml:"grpc" toml:"grpc"`
+	Swagger   bool `yaml:"swagger" toml:"swagger"`
+	Reminders bool `yaml:"reminders" toml:"reminders"`
+	Metrics   bool `yaml:"metrics" toml:"metrics"`
+}
+
+// Tracing configures OpenTelemetry. Spans are exported to stdout for local
+// testing or over OTLP/gRPC to a collector; the standard OTEL_* variables
+// are honoured for whatever is not set here.
+type Tracing struct {
+	Exporter    string `yaml:"exporter" toml:"exporter" validate:"oneof=none stdout otlp"`
+	Endpoint    string `yaml:"endpoint" toml:"endpoint"`
+	Insecure    bool   `yaml:"insecure" toml:"insecure"`
+	ServiceName string `yaml:"service_name" toml:"service_name" validate:"required"`
+	// SampleRatio is the share of traces started here that are recorded;
+	// requests that arrive with a sampled parent are always recorded.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" validate:"min=0,max=1"`
+}
+
+type Logging struct {
+	// Level can also be changed while running through /admin/log-level.
+	Level  string `yaml:"level" toml:"level" validate:"oneof=debug info warn error"`
+	Format string `yaml:"format" toml:"format" validate:"oneof=json text"`
+	// SlowQuery is how long a repository operation may take before it is
+	// logged as a warning; 0 turns this off.
+	SlowQuery time.Duration `yaml:"slow_query" toml:"slow_query" validate:"min=0"`
+}
+
+type Admin struct {
+	// Token guards the /admin endpoints, which are disabled without one.
+	Token string `yaml:"token" toml:"token" secret:"true" validate:"omitempty,min=16"`
+}
+
+// RateLimit throttles clients with token buckets, kept in memory or, to be
+// shared by all replicas, in PostgreSQL.
+type RateLimit struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled"`
+	Store   string `yaml:"store" toml:"store" validate:"oneof=memory postgres"`
+	// TrustProxy takes the client address from X-Forwarded-For. It also
+	// scopes the idempotency keys of anonymous requests.
+	TrustProxy bool  `yaml:"trust_proxy" toml:"trust_proxy"`
+	Default    Limit `yaml:"default" toml:"default"`
+	Search     Limit `yaml:"search" toml:"search"`
+	Write      Limit `yaml:"write" toml:"write"`
+	// Auth limits requests with credentials per address before they are
+	// checked, so that guessing keys costs no more than this.
+	Auth Limit `yaml:"auth" toml:"auth"`
+}
+
+// Limit allows Requests per Period on average, in bursts of up to Burst, or
+// Requests if Burst is 0.
+type Limit struct {
+	Requests int           `yaml:"requests" toml:"requests" validate:"gt=0"`
+	Period   time.Duration `yaml:"period" toml:"period" validate:"gt=0"`
+	Burst    int           `yaml:"burst" toml:"burst" validate:"min=0"`
+}
+
+// OIDC enables single sign-on with an OpenID Connect identity provider,
+// turned on by setting Issuer.
+type OIDC struct {
+	Issuer       string `yaml:"issuer" toml:"issuer" validate:"omitempty,url"`
+	ClientID     string `yaml:"client_id" toml:"client_id" validate:"required_with=Issuer"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret" secret:"true"`
+	// RedirectURL is the address of /auth/oidc/callback as registered with
+	// the identity provider.
+	RedirectURL string   `yaml:"redirect_url" toml:"redirect_url" validate:"required_with=Issuer,omitempty,url"`
+	Scopes      []string `yaml:"scopes" toml:"scopes"`
+	// GroupsClaim is the ID token claim listing the user's groups.
+	GroupsClaim string `yaml:"groups_claim" toml:"groups_claim"`
+	// GroupRoles maps groups to roles as group=role pairs; the first pair
+	// whose group the user is in gives their role at every login.
+	GroupRoles []string `yaml:"group_roles" toml:"group_roles" validate:"dive,contains=="`
+	// DefaultRole is given to new users in none of the groups. Without it
+	// they cannot log in.
+	DefaultRole string `yaml:"default_role" toml:"default_role"`
+	// PostLoginURL, if set, is where the browser is sent after logging in,
+	// with the access token in the URL fragment. Otherwise the token is
+	// returned as JSON.
+	PostLoginURL string `yaml:"post_login_url" toml:"post_login_url" validate:"omitempty,url"`
+}
+
+func Default() *Config {
+	cfg := &Config{Env: Development, Port: 8080, GRPCPort: 9090}
+	cfg.DB.MaxOpenConns = 25
+	cfg.DB.MaxIdleConns = 25
+	cfg.DB.ConnMaxLifetime = 30 * time.Minute
+	cfg.DB.ConnMaxIdleTime = 5 * time.Minute
+	cfg.HTTP.ReadTimeout = 30 * time.Second
+	cfg.HTTP.ReadHeaderTimeout = 5 * time.Second
+	cfg.HTTP.WriteTimeout = 60 * time.Second
+	cfg.HTTP.IdleTimeout = 2 * time.Minute
+	cfg.HTTP.StreamTimeout = time.Hour
+	cfg.HTTP.ShutdownTimeout = 30 * time.Second
+	cfg.HTTP.TLSReloadInterval = time.Minute
+	cfg.CORS.AllowedOrigins = []string{"*"}
+	cfg.CORS.AllowCredentials = true
+	cfg.Reminders.Interval = time.Minute
+	cfg.Reminders.Window = 24 * time.Hour
+	cfg.Idempotency.TTL = 24 * time.Hour
+	cfg.Idempotency.LockTimeout = time.Minute
+	cfg.Attachments.Store = "local"
+	cfg.Attachments.Dir = "data/attachments"
+	cfg.Attachments.MaxSize = 10 << 20
+	cfg.Attachments.AllowedTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain", "application/zip"}
+	cfg.Attachments.S3.PathStyle = true
+	cfg.Features.GraphQL = true
+	cfg.Features.GRPC = true
+	cfg.Features.Swagger = true
+	cfg.Features.Reminders = true
+	cfg.Features.Metrics = true
+	cfg.Tracing.Exporter = "none"
+	cfg.Tracing.ServiceName = "hl-project-management"
+	cfg.Tracing.SampleRatio = 1
+	cfg.Logging.Level = "info"
+	cfg.Logging.Format = "json"
+	cfg.Logging.SlowQuery = 500 * time.Millisecond
+	cfg.Auth.TokenTTL = time.Hour
+	cfg.OIDC.Scopes = []string{"openid", "email", "profile"}
+	cfg.OIDC.GroupsClaim = "groups"
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Store = "memory"
+	cfg.RateLimit.Default = Limit{Requests: 300, Period: time.Minute}
+	cfg.RateLimit.Search = Limit{Requests: 30, Period: time.Minute}
+	cfg.RateLimit.Write = Limit{Requests: 60, Period: time.Minute}
+	cfg.RateLimit.Auth = Limit{Requests: 600, Period: time.Minute}
+	return cfg
+}
+
+// Validate reports every invalid setting at once.
+func (cfg *Config) Validate() error {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		return strings.Split(f.Tag.Get("yaml"), ",")[0]
+	})
+	var problems []string
+	if err := v.Struct(cfg); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return err
+		}
+		for _, fe := range fieldErrs {
+			problems = append(problems, describe(fe))
+		}
+	}
+	if cfg.Attachments.Store == "s3" && cfg.Attachments.S3.Bucket == "" {
+		problems = append(problems, "attachments.s3.bucket is required when attachments.store is s3")
+	}
+	if cfg.DB.MaxOpenConns > 0 && cfg.DB.MaxIdleConns > cfg.DB.MaxOpenConns {
+		problems = append(problems, "db.max_idle_conns must not exceed db.max_open_conns")
+	}
+	if cfg.OIDC.Issuer != "" && cfg.Auth.JWTSecret == "" {
+		problems = append(problems, "auth.jwt_secret is required when oidc.issuer is set")
+	}
+	if cfg.Auth.TrustUserHeader && cfg.Auth.JWTSecret != "" {
+		problems = append(problems, "auth.trust_user_header cannot be used with auth.jwt_secret")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func describe(fe validator.FieldError) string {
+	name := strings.TrimPrefix(fe.Namespace(), "Config.")
+	switch fe.Tag() {
+	case "required":
+		return name + " is required"
+	case "required_with":
+		return fmt.Sprintf("%s is required with %s", name, fe.Param())
+	case "required_if":
+		return fmt.Sprintf("%s is required when %s", name, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s, got %q", name, fe.Param(), fmt.Sprint(fe.Value()))
+	case "file":
+		return fmt.Sprintf("%s: file %q does not exist", name, fmt.Sprint(fe.Value()))
+	case "contains":
+		return fmt.Sprintf("%s must contain %q, got %q", name, fe.Param(), fmt.Sprint(fe.Value()))
+	case "nefield":
+		return fmt.Sprintf("%s must differ from %s", name, fe.Param())
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("%s must satisfy %s=%s, got %v", name, fe.Tag(), fe.Param(), fe.Value())
+	}
+	return fmt.Sprintf("%s must satisfy %s, got %v", name, fe.Tag(), fe.Value())
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const envPrefix = "PM_"
+
+// Load returns the defaults overridden by the file at path, if not empty,
+// and then by the environment. A .env file in the working directory, if
+// present, is added to the environment first. Flags are applied by parsing
+// a flag set prepared with RegisterFlags.
+func Load(path string) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(".env: %w", err)
+	}
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// FileFromArgs finds the config file given by a -config flag in args, or
+// else by PM_CONFIG. Flags are parsed later, so it has to be found first.
+func FileFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return os.Getenv(envPrefix + "CONFIG")
+}
+
+func (cfg *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.Decode(string(data), cfg)
+		if err == nil && len(md.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown setting %s", md.Undecoded()[0])
+		}
+	default:
+		return fmt.Errorf("%s: unsupported config format, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv sets every field for which an environment variable is set. The
+// variable is named after the field's path, and fields tagged env also
+// accept the older name given there, with the PM_ name taking precedence.
+func (cfg *Config) loadEnv() error {
+	return walk(reflect.ValueOf(cfg).Elem(), "", func(path string, field reflect.StructField, v reflect.Value) error {
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if alias := field.Tag.Get("env"); alias != "" {
+				name = alias
+				value, ok = os.LookupEnv(alias)
+			}
+		}
+		if !ok {
+			return nil
+		}
+		if err := setValue(v, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// walk calls fn for every non-struct field below v, passing its dotted path
+// of YAML names.
+func walk(v reflect.Value, prefix string, fn func(path string, field reflect.StructField, v reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		path := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			if err := walk(v.Field(i), path+".", fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(path, field, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, s string) error {
+	switch v.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case []string:
+		v.Set(reflect.ValueOf(splitList(s)))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// listFlag is a flag holding a comma-separated list.
+type listFlag struct{ list *[]string }
+
+func (f listFlag) String() string {
+	if f.list == nil {
+		return ""
+	}
+	return strings.Join(*f.list, ",")
+}
+
+func (f listFlag) Set(s string) error {
+	*f.list = splitList(s)
+	return nil
+}
+
+// RegisterFlags defines the command-line flags on fs, bound to cfg, so that
+// the values already in cfg become the defaults and parsing overrides them.
+func (cfg *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.String("config", "", "YAML or TOML config file (also PM_CONFIG)")
+	fs.StringVar(&cfg.Env, "env", cfg.Env, "Environment (development|staging|production)")
+	fs.IntVar(&cfg.Port, "port", cfg.Port, "API server port")
+	fs.IntVar(&cfg.GRPCPort, "grpc-port", cfg.GRPCPort, "gRPC server port")
+
+	fs.StringVar(&cfg.DB.DSN, "db-dsn", cfg.DB.DSN, "PostgreSQL DSN")
+	fs.IntVar(&cfg.DB.MaxOpenConns, "db-max-open-conns", cfg.DB.MaxOpenConns, "Maximum open database connections, 0 for no limit")
+	fs.IntVar(&cfg.DB.MaxIdleConns, "db-max-idle-conns", cfg.DB.MaxIdleConns, "Maximum idle database connections")
+	fs.DurationVar(&cfg.DB.ConnMaxLifetime, "db-conn-max-lifetime", cfg.DB.ConnMaxLifetime, "How long a database connection may be reused, 0 for ever")
+	fs.DurationVar(&cfg.DB.ConnMaxIdleTime, "db-conn-max-idle-time", cfg.DB.ConnMaxIdleTime, "How long a database connection may stay idle, 0 for ever")
+	fs.BoolVar(&cfg.DB.Migrate, "migrate", cfg.DB.Migrate, "Apply pending migrations before serving")
+
+	fs.DurationVar(&cfg.HTTP.ReadTimeout, "http-read-timeout", cfg.HTTP.ReadTimeout, "Maximum time to read a request, including the body")
+	fs.DurationVar(&cfg.HTTP.ReadHeaderTimeout, "http-read-header-timeout", cfg.HTTP.ReadHeaderTimeout, "Maximum time to read request headers")
+	fs.DurationVar(&cfg.HTTP.WriteTimeout, "http-write-timeout", cfg.HTTP.WriteTimeout, "Maximum time to write a response")
+	fs.DurationVar(&cfg.HTTP.IdleTimeout, "http-idle-timeout", cfg.HTTP.IdleTimeout, "How long idle keep-alive connections are kept open")
+	fs.DurationVar(&cfg.HTTP.StreamTimeout, "http-stream-timeout", cfg.HTTP.StreamTimeout, "Maximum time to read or write exports, imports and attachments")
+	fs.DurationVar(&cfg.HTTP.ShutdownTimeout, "shutdown-timeout", cfg.HTTP.ShutdownTimeout, "How long to wait for in-flight requests on shutdown")
+	fs.DurationVar(&cfg.HTTP.ShutdownDelay, "shutdown-delay", cfg.HTTP.ShutdownDelay, "How long to keep serving with /readyz failing before shutting down")
+	fs.StringVar(&cfg.HTTP.TLSCert, "tls-cert", cfg.HTTP.TLSCert, "TLS certificate file; serves HTTPS when set")
+	fs.StringVar(&cfg.HTTP.TLSKey, "tls-key", cfg.HTTP.TLSKey, "TLS private key file")
+	fs.DurationVar(&cfg.HTTP.TLSReloadInterval, "tls-reload-interval", cfg.HTTP.TLSReloadInterval, "How often to check the TLS files for changes")
+
+	fs.Var(listFlag{&cfg.CORS.AllowedOrigins}, "cors-origins", "Comma-separated origins allowed to make cross-origin requests")
+	fs.BoolVar(&cfg.CORS.AllowCredentials, "cors-credentials", cfg.CORS.AllowCredentials, "Allow cross-origin requests with credentials")
+
+	fs.DurationVar(&cfg.Reminders.Interval, "reminder-interval", cfg.Reminders.Interval, "How often to check for due and overdue tasks")
+	fs.DurationVar(&cfg.Reminders.Window, "reminder-window", cfg.Reminders.Window, "How long before the due date to remind assignees")
+	fs.DurationVar(&cfg.Idempotency.TTL, "idempotency-ttl", cfg.Idempotency.TTL, "How long responses to requests with an Idempotency-Key are kept")
+	fs.DurationVar(&cfg.Idempotency.LockTimeout, "idempotency-lock-timeout", cfg.Idempotency.LockTimeout, "How long an unfinished request keeps its Idempotency-Key reserved")
+
+	fs.StringVar(&cfg.Attachments.Store, "attachment-store", cfg.Attachments.Store, "Where attachments are kept (local|s3)")
+	fs.StringVar(&cfg.Attachments.Dir, "attachment-dir", cfg.Attachments.Dir, "Directory for attachments when using the local store")
+	fs.Int64Var(&cfg.Attachments.MaxSize, "attachment-max-size", cfg.Attachments.MaxSize, "Maximum attachment size in bytes")
+	fs.Var(listFlag{&cfg.Attachments.AllowedTypes}, "attachment-types", "Comma-separated media types accepted as attachments")
+	fs.StringVar(&cfg.Attachments.S3.Endpoint, "s3-endpoint", cfg.Attachments.S3.Endpoint, "S3-compatible endpoint URL")
+	fs.StringVar(&cfg.Attachments.S3.Region, "s3-region", cfg.Attachments.S3.Region, "S3 region")
+	fs.StringVar(&cfg.Attachments.S3.Bucket, "s3-bucket", cfg.Attachments.S3.Bucket, "S3 bucket for attachments")
+	fs.BoolVar(&cfg.Attachments.S3.PathStyle, "s3-path-style", cfg.Attachments.S3.PathStyle, "Use path-style S3 addressing")
+
+	fs.BoolVar(&cfg.Features.GraphQL, "graphql", cfg.Features.GraphQL, "Serve the GraphQL API")
+	fs.BoolVar(&cfg.Features.GraphiQL, "graphiql", cfg.Features.GraphiQL, "Serve the GraphQL IDE outside development")
+	fs.BoolVar(&cfg.Features.GRPC, "grpc", cfg.Features.GRPC, "Serve the gRPC API")
+	fs.BoolVar(&cfg.Features.Swagger, "swagger", cfg.Features.Swagger, "Serve the Swagger UI")
+	fs.BoolVar(&cfg.Features.Reminders, "reminders", cfg.Features.Reminders, "Send due date reminders")
+	fs.BoolVar(&cfg.Features.Metrics, "metrics", cfg.Features.Metrics, "Serve Prometheus metrics on /metrics")
+
+	fs.StringVar(&cfg.Logging.Level, "log-level", cfg.Logging.Level, "Minimum log level: debug, info, warn or error")
+	fs.StringVar(&cfg.Logging.Format, "log-format", cfg.Logging.Format, "Log format: json or text")
+	fs.DurationVar(&cfg.Logging.SlowQuery, "log-slow-query", cfg.Logging.SlowQuery, "Log repository operations slower than this, 0 to disable")
+
+	fs.BoolVar(&cfg.Auth.TrustUserHeader, "auth-trust-user-header", cfg.Auth.TrustUserHeader, "Take the user from the X-User-ID header set by an authenticating gateway")
+
+	fs.StringVar(&cfg.OIDC.Issuer, "oidc-issuer", cfg.OIDC.Issuer, "OpenID Connect issuer URL; enables single sign-on")
+	fs.StringVar(&cfg.OIDC.ClientID, "oidc-client-id", cfg.OIDC.ClientID, "OpenID Connect client ID")
+	fs.StringVar(&cfg.OIDC.RedirectURL, "oidc-redirect-url", cfg.OIDC.RedirectURL, "URL of /auth/oidc/callback registered with the identity provider")
+
+	fs.BoolVar(&cfg.RateLimit.Enabled, "rate-limit", cfg.RateLimit.Enabled, "Limit the request rate of each client")
+	fs.StringVar(&cfg.RateLimit.Store, "rate-limit-store", cfg.RateLimit.Store, "Where rate limit buckets are kept: memory, or postgres to share them between replicas")
+	fs.BoolVar(&cfg.RateLimit.TrustProxy, "rate-limit-trust-proxy", cfg.RateLimit.TrustProxy, "Identify anonymous clients by X-Forwarded-For instead of the connection address")
+
+	fs.StringVar(&cfg.Tracing.Exporter, "tracing", cfg.Tracing.Exporter, "Trace exporter: none, stdout or otlp")
+	fs.StringVar(&cfg.Tracing.Endpoint, "tracing-endpoint", cfg.Tracing.Endpoint, "OTLP gRPC endpoint, host:port; $OTEL_EXPORTER_OTLP_ENDPOINT by default")
+	fs.BoolVar(&cfg.Tracing.Insecure, "tracing-insecure", cfg.Tracing.Insecure, "Send traces to the OTLP endpoint without TLS")
+	fs.Float64Var(&cfg.Tracing.SampleRatio, "tracing-sample-ratio", cfg.Tracing.SampleRatio, "Fraction of new traces to sample, from 0 to 1")
+}
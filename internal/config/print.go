@@ -0,0 +1,50 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// Print writes cfg as YAML with secrets redacted.
+func Print(w io.Writer, cfg *Config) error {
+	c := *cfg
+	walk(reflect.ValueOf(&c).Elem(), "", func(_ string, field reflect.StructField, v reflect.Value) error {
+		if v.Kind() != reflect.String || v.String() == "" {
+			return nil
+		}
+		switch field.Tag.Get("secret") {
+		case "true":
+			v.SetString(redacted)
+		case "dsn":
+			v.SetString(redactDSN(v.String()))
+		}
+		return nil
+	})
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	return enc.Encode(&c)
+}
+
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('[^']*'|\S+)`)
+
+// redactDSN hides the password of a URL or key=value connection string.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		q := u.Query()
+		if q.Has("password") {
+			q.Set("password", redacted)
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
@@ -0,0 +1,71 @@
+// Package events is a small in-process publish/subscribe bus used to react to
+// changes in tasks without coupling the producers to every consumer.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	TaskDueSoon   Type = "task.due_soon"
+	TaskOverdue   Type = "task.overdue"
+	TaskCreated   Type = "task.created"
+	TaskUpdated   Type = "task.updated"
+	TaskDeleted   Type = "task.deleted"
+	TaskCommented Type = "task.commented"
+)
+
+type Event struct {
+	Type      Type
+	TaskID    int
+	ProjectID int
+	// UserID is the user the event is about, e.g. the new assignee of a
+	// created or reassigned task, or the assignee of a deleted one.
+	UserID int
+	// ActorID is the user who caused the event, or 0 for the system.
+	ActorID int
+	// Mentioned lists users newly @mentioned by the change.
+	Mentioned []int
+	CommentID int
+	At        time.Time
+}
+
+var (
+	mu       sync.RWMutex
+	nextID   int
+	handlers = map[int]func(Event){}
+)
+
+// Subscribe registers fn to be called for every published event and returns a
+// function that removes the subscription. Handlers run synchronously on the
+// publisher's goroutine and must not block.
+func Subscribe(fn func(Event)) (unsubscribe func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	id := nextID
+	nextID++
+	handlers[id] = fn
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(handlers, id)
+	}
+}
+
+func Publish(e Event) {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	mu.RLock()
+	subscribers := make([]func(Event), 0, len(handlers))
+	for _, fn := range handlers {
+		subscribers = append(subscribers, fn)
+	}
+	mu.RUnlock()
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
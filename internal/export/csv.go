@@ -0,0 +1,49 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter writes RFC 4180 CSV. With bom set the output starts with a
+// UTF-8 byte order mark, which Excel needs to detect the encoding.
+func NewCSVWriter(w io.Writer, bom bool) (Writer, error) {
+	if bom {
+		if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+			return nil, err
+		}
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+func (c *csvWriter) Write(row []string) error {
+	for i, v := range row {
+		row[i] = defuse(v)
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// defuse stops spreadsheets from evaluating user-provided text as a formula
+// by prefixing it with an apostrophe. Numbers are left alone.
+func defuse(v string) string {
+	if v == "" {
+		return v
+	}
+	switch v[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "'" + v
+		}
+	}
+	return v
+}
@@ -0,0 +1,70 @@
+// Package export writes tabular data as CSV or Excel spreadsheets, one row at
+// a time, so that large result sets can be streamed to the client.
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	ContentTypeCSV  = "text/csv"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// Writer writes rows of a table. Close must be called to complete the output.
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// Column is a named column of a table of T.
+type Column[T any] struct {
+	Name  string
+	Value func(T) string
+}
+
+// Select picks columns by a comma-separated list of names, in the order
+// given. An empty list selects all columns.
+func Select[T any](all []Column[T], names string) ([]Column[T], error) {
+	if strings.TrimSpace(names) == "" {
+		return all, nil
+	}
+	var selected []Column[T]
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, c := range all {
+			if strings.EqualFold(c.Name, name) {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// Header returns the names of the columns.
+func Header[T any](columns []Column[T]) []string {
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		row[i] = c.Name
+	}
+	return row
+}
+
+// Row returns the values of the columns for v.
+func Row[T any](columns []Column[T], v T) []string {
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		row[i] = c.Value(v)
+	}
+	return row
+}
@@ -0,0 +1,225 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefuse(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"=SUM(A1:A2)", "'=SUM(A1:A2)"},
+		{"+1+2", "'+1+2"},
+		{"-2+3", "'-2+3"},
+		{"@cmd", "'@cmd"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"a=b", "a=b"},
+		{" =1", " =1"},
+		{"'=1", "'=1"},
+		// Numbers are left alone.
+		{"-12", "-12"},
+		{"+3.5", "+3.5"},
+		{"-1e3", "-1e3"},
+		{"-", "'-"},
+		{"-Inf", "-Inf"},
+	}
+	for _, tt := range tests {
+		if got := defuse(tt.in); got != tt.want {
+			t.Errorf("defuse(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	tests := []struct {
+		name string
+		bom  bool
+		rows [][]string
+		want string
+	}{
+		{"plain", false, [][]string{{"id", "title"}, {"1", "Fix bug"}}, "id,title\n1,Fix bug\n"},
+		{"bom", true, [][]string{{"id"}}, "\uFEFFid\n"},
+		{"quoting", false, [][]string{{`say "hi"`, "a,b", "two\nlines"}}, "\"say \"\"hi\"\"\",\"a,b\",\"two\nlines\"\n"},
+		{"formulas", false, [][]string{{"=1+1", "-5", "@x"}}, "'=1+1,-5,'@x\n"},
+		{"empty", false, nil, ""},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := NewCSVWriter(&buf, tt.bom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range tt.rows {
+			if err := w.Write(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: output %q, want %q", tt.name, buf.String(), tt.want)
+		}
+	}
+}
+
+// cell is a worksheet cell as read back from the XML.
+type cell struct {
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string `xml:"t"`
+	} `xml:"is"`
+}
+
+// readXLSX unpacks a workbook written by NewXLSXWriter and returns its sheet
+// name and the type and text of every cell.
+func readXLSX(t *testing.T, data []byte) (string, [][]cell) {
+	t.Helper()
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string][]byte{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name], err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/workbook.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatalf("workbook lacks %s", name)
+		}
+		var v any
+		if err := xml.Unmarshal(parts[name], &v); err != nil {
+			t.Fatalf("%s is not well-formed: %v", name, err)
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(parts["xl/workbook.xml"], &workbook); err != nil {
+		t.Fatal(err)
+	}
+	var sheet struct {
+		Rows []struct {
+			Cells []cell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]cell
+	for _, r := range sheet.Rows {
+		rows = append(rows, r.Cells)
+	}
+	return workbook.Sheets[0].Name, rows
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewXLSXWriter(&buf, `Tasks <"&">`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]string{
+		{"id", "title"},
+		{"42", `<b>"Fish" & chips</b>`},
+		{"007", "  padded  "},
+		{"-5", "=SUM(A1:A2)"},
+		{"1e5", "bell\x07 and \uFFFE"},
+		{"1234567890123456", "Привет\nмир"},
+		{"", "-0"},
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	name, got := readXLSX(t, buf.Bytes())
+	if name != `Tasks <"&">` {
+		t.Errorf("sheet name = %q", name)
+	}
+	number := func(v string) cell { return cell{Type: "n", Value: v} }
+	text := func(v string) cell {
+		c := cell{Type: "inlineStr"}
+		c.Inline.Text = v
+		return c
+	}
+	want := [][]cell{
+		{text("id"), text("title")},
+		{number("42"), text(`<b>"Fish" & chips</b>`)},
+		// Leading zeros and spaces are kept as text.
+		{text("007"), text("  padded  ")},
+		// Inline strings are never evaluated, so formulas stay as typed.
+		{number("-5"), text("=SUM(A1:A2)")},
+		// Characters XML cannot carry become U+FFFD.
+		{text("1e5"), text("bell\uFFFD and \uFFFD")},
+		// Integers beyond 15 digits would lose precision as numbers.
+		{text("1234567890123456"), text("Привет\nмир")},
+		{text(""), text("-0")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cells = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	type item struct{ id, name string }
+	all := []Column[item]{
+		{"id", func(i item) string { return i.id }},
+		{"name", func(i item) string { return i.name }},
+	}
+	tests := []struct {
+		names string
+		want  []string
+		err   bool
+	}{
+		{"", []string{"id", "name"}, false},
+		{" ", []string{"id", "name"}, false},
+		{"name", []string{"name"}, false},
+		{"NAME, id", []string{"name", "id"}, false},
+		{"id,colour", nil, true},
+		{"id,", nil, true},
+	}
+	for _, tt := range tests {
+		selected, err := Select(all, tt.names)
+		if (err != nil) != tt.err {
+			t.Errorf("Select(%q) error = %v", tt.names, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := Header(selected); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Select(%q) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+
+	row := Row(all, item{id: "1", name: "Ann"})
+	if strings.Join(row, ",") != "1,Ann" {
+		t.Errorf("Row = %q", row)
+	}
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"regexp"
+)
+
+// Static parts of a workbook with a single worksheet.
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+const (
+	sheetStart = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetEnd   = `</sheetData></worksheet>`
+)
+
+// Integers that fit a spreadsheet number exactly; anything else is text.
+var integer = regexp.MustCompile(`^(0|-?[1-9][0-9]{0,14})$`)
+
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+}
+
+// NewXLSXWriter writes an Office Open XML workbook with one worksheet. Cells
+// hold inline strings, so rows can be written as they come without keeping a
+// shared string table in memory.
+func NewXLSXWriter(w io.Writer, sheetName string) (Writer, error) {
+	z := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := z.Create("xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	var name xmlText
+	name.write(sheetName)
+	if _, err := io.WriteString(f, workbookXML(string(name))); err != nil {
+		return nil, err
+	}
+
+	sheet, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxWriter{zip: z, sheet: bufio.NewWriter(sheet)}
+	if _, err := x.sheet.WriteString(sheetStart); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func workbookXML(escapedName string) string {
+	return xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escapedName + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+}
+
+func (x *xlsxWriter) Write(row []string) error {
+	x.sheet.WriteString("<row>")
+	for _, v := range row {
+		if integer.MatchString(v) {
+			x.sheet.WriteString(`<c t="n"><v>` + v + `</v></c>`)
+			continue
+		}
+		var text xmlText
+		text.write(v)
+		x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">` + string(text) + `</t></is></c>`)
+	}
+	_, err := x.sheet.WriteString("</row>")
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(sheetEnd); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// xmlText accumulates XML-escaped text. Characters XML cannot represent are
+// replaced with U+FFFD.
+type xmlText []byte
+
+func (t *xmlText) Write(p []byte) (int, error) {
+	*t = append(*t, p...)
+	return len(p), nil
+}
+
+func (t *xmlText) write(s string) {
+	xml.EscapeText(t, []byte(s))
+}
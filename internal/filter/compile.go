@@ -0,0 +1,277 @@
+package filter
+
+import (
+	"HL_project_management/internal/search"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Context supplies the values that depend on who is asking and when.
+type Context struct {
+	// UserID resolves assignee:me; 0 if the caller is anonymous.
+	UserID int
+	Now    time.Time
+}
+
+type compileFunc func(c *compiler, t Term) (string, error)
+
+var fields = map[string]compileFunc{
+	"id":          intField("id"),
+	"title":       textField("title"),
+	"description": textField("description"),
+	"text":        fullTextField,
+	"priority":    priorityField,
+	"status":      statusField,
+	"assignee":    assigneeField,
+	"project":     intField("project_id"),
+	"created":     dateField("created_at", false),
+	"due":         dateField("due_at", true),
+	"completed":   dateField("completed_at", true),
+}
+
+// Fields returns the names of the fields that can be filtered on.
+func Fields() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compile turns an expression into a condition over the columns of the tasks
+// table. Its placeholders are numbered from argOffset+1 and their values are
+// returned alongside.
+func Compile(node Node, ctx Context, argOffset int) (string, []any, error) {
+	if ctx.Now.IsZero() {
+		ctx.Now = time.Now()
+	}
+	c := &compiler{ctx: ctx, offset: argOffset}
+	where, err := c.compile(node)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, c.args, nil
+}
+
+// ParseAndCompile is Parse followed by Compile.
+func ParseAndCompile(expr string, ctx Context, argOffset int) (string, []any, error) {
+	node, err := Parse(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return Compile(node, ctx, argOffset)
+}
+
+type compiler struct {
+	ctx    Context
+	offset int
+	args   []any
+}
+
+// arg adds a parameter and returns its placeholder.
+func (c *compiler) arg(v any) string {
+	c.args = append(c.args, v)
+	return "$" + strconv.Itoa(c.offset+len(c.args))
+}
+
+func (c *compiler) compile(node Node) (string, error) {
+	switch n := node.(type) {
+	case And:
+		return c.binary(n.Left, "AND", n.Right)
+	case Or:
+		return c.binary(n.Left, "OR", n.Right)
+	case Not:
+		expr, err := c.compile(n.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + expr + ")", nil
+	case Term:
+		compile, ok := fields[n.Field]
+		if !ok {
+			return "", errorf(n.Pos, "unknown field %q, expected one of %s", n.Field, strings.Join(Fields(), ", "))
+		}
+		return compile(c, n)
+	default:
+		return "", fmt.Errorf("filter: unexpected node %T", node)
+	}
+}
+
+func (c *compiler) binary(left Node, op string, right Node) (string, error) {
+	l, err := c.compile(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := c.compile(right)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+func unsupported(t Term, ops string) error {
+	return errorf(t.Pos, "operator %q is not supported for %s, use %s", t.Op, t.Field, ops)
+}
+
+// equality maps the equality operators onto SQL, rejecting the others.
+func equality(t Term) (string, error) {
+	switch t.Op {
+	case ":", "=":
+		return "=", nil
+	case "!=":
+		return "<>", nil
+	}
+	return "", unsupported(t, ": or !=")
+}
+
+// comparison maps any operator onto SQL.
+func comparison(t Term) string {
+	switch t.Op {
+	case ":":
+		return "="
+	case "!=":
+		return "<>"
+	}
+	return t.Op
+}
+
+func intField(column string) compileFunc {
+	return func(c *compiler, t Term) (string, error) {
+		v, err := strconv.Atoi(t.Value)
+		if err != nil {
+			return "", errorf(t.Pos, "%s should be a number, got %q", t.Field, t.Value)
+		}
+		return column + " " + comparison(t) + " " + c.arg(v), nil
+	}
+}
+
+// textField matches substrings with ":" and whole values with "=", ignoring case.
+func textField(column string) compileFunc {
+	return func(c *compiler, t Term) (string, error) {
+		switch t.Op {
+		case ":":
+			escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(t.Value)
+			return column + " ILIKE " + c.arg("%"+escaped+"%"), nil
+		case "=":
+			return "LOWER(" + column + ") = LOWER(" + c.arg(t.Value) + ")", nil
+		case "!=":
+			return "LOWER(" + column + ") <> LOWER(" + c.arg(t.Value) + ")", nil
+		}
+		return "", unsupported(t, ": = or !=")
+	}
+}
+
+func fullTextField(c *compiler, t Term) (string, error) {
+	if t.Op != ":" && t.Op != "=" {
+		return "", unsupported(t, ":")
+	}
+	tsquery, err := search.ToTSQuery(t.Value)
+	if err != nil {
+		return "", errorf(t.Pos, "%v", err)
+	}
+	return "search_vector @@ to_tsquery('simple', " + c.arg(tsquery) + ")", nil
+}
+
+var priorities = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// priorityField compares priorities by rank, so priority>low matches medium
+// and high.
+func priorityField(c *compiler, t Term) (string, error) {
+	rank, ok := priorities[strings.ToLower(t.Value)]
+	if !ok {
+		return "", errorf(t.Pos, "priority should be low, medium or high, got %q", t.Value)
+	}
+	return "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) " + comparison(t) + " " + c.arg(rank), nil
+}
+
+func statusField(c *compiler, t Term) (string, error) {
+	op, err := equality(t)
+	if err != nil {
+		return "", err
+	}
+	return "LOWER(status) " + op + " LOWER(" + c.arg(t.Value) + ")", nil
+}
+
+func assigneeField(c *compiler, t Term) (string, error) {
+	op, err := equality(t)
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(t.Value, "me") {
+		if c.ctx.UserID == 0 {
+			return "", errorf(t.Pos, "assignee:me needs an authenticated user")
+		}
+		return "assignee_id " + op + " " + c.arg(c.ctx.UserID), nil
+	}
+	id, err := strconv.Atoi(t.Value)
+	if err != nil {
+		return "", errorf(t.Pos, "assignee should be a user ID or me, got %q", t.Value)
+	}
+	return "assignee_id " + op + " " + c.arg(id), nil
+}
+
+// dateField compares timestamps. A value without a time of day stands for
+// the whole day, so created:2024-01-01 matches anything created that day and
+// created>2024-01-01 anything created after it. Nullable fields accept none.
+func dateField(column string, nullable bool) compileFunc {
+	return func(c *compiler, t Term) (string, error) {
+		if nullable && strings.EqualFold(t.Value, "none") {
+			switch t.Op {
+			case ":", "=":
+				return column + " IS NULL", nil
+			case "!=":
+				return column + " IS NOT NULL", nil
+			}
+			return "", unsupported(t, ": or != with none")
+		}
+
+		at, wholeDay, err := parseTime(t.Value, c.ctx.Now)
+		if err != nil {
+			return "", errorf(t.Pos, "%s should be a date (2006-01-02), a time (RFC 3339), today, yesterday, tomorrow or now, got %q", t.Field, t.Value)
+		}
+		if !wholeDay {
+			return column + " " + comparison(t) + " " + c.arg(at), nil
+		}
+
+		start, end := at, at.AddDate(0, 0, 1)
+		switch t.Op {
+		case ":", "=":
+			return "(" + column + " >= " + c.arg(start) + " AND " + column + " < " + c.arg(end) + ")", nil
+		case "!=":
+			return "(" + column + " < " + c.arg(start) + " OR " + column + " >= " + c.arg(end) + ")", nil
+		case ">":
+			return column + " >= " + c.arg(end), nil
+		case ">=":
+			return column + " >= " + c.arg(start), nil
+		case "<":
+			return column + " < " + c.arg(start), nil
+		default: // "<="
+			return column + " < " + c.arg(end), nil
+		}
+	}
+}
+
+// parseTime parses a date or time in the server's time zone, which is how
+// timestamps are stored. wholeDay reports whether the value is a date.
+func parseTime(value string, now time.Time) (at time.Time, wholeDay bool, err error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch strings.ToLower(value) {
+	case "now":
+		return now, false, nil
+	case "today":
+		return today, true, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true, nil
+	}
+	if at, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return at, true, nil
+	}
+	at, err = time.Parse(time.RFC3339, value)
+	return at.In(time.Local), false, err
+}
@@ -0,0 +1,186 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompile(t *testing.T) {
+	now := time.Date(2024, 3, 15, 14, 30, 0, 0, time.Local)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.Local) }
+	mar14, mar15, mar16, mar17 := day(2024, 3, 14), day(2024, 3, 15), day(2024, 3, 16), day(2024, 3, 17)
+	jan1, jan2 := day(2024, 1, 1), day(2024, 1, 2)
+	instant := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr  string
+		where string
+		args  []any
+	}{
+		{"id:5", "id = $1", []any{5}},
+		{"id=5", "id = $1", []any{5}},
+		{"id!=5", "id <> $1", []any{5}},
+		{"id>5", "id > $1", []any{5}},
+		{"id>=5", "id >= $1", []any{5}},
+		{"id<5", "id < $1", []any{5}},
+		{"id<=5", "id <= $1", []any{5}},
+		{"project:3", "project_id = $1", []any{3}},
+		{"project!=3", "project_id <> $1", []any{3}},
+		{"project>=3", "project_id >= $1", []any{3}},
+
+		{"title:login", "title ILIKE $1", []any{"%login%"}},
+		{`title:"50% off_now\\"`, "title ILIKE $1", []any{`%50\% off\_now\\%`}},
+		{"title=Login", "LOWER(title) = LOWER($1)", []any{"Login"}},
+		{"title!=Login", "LOWER(title) <> LOWER($1)", []any{"Login"}},
+		{"description:bug", "description ILIKE $1", []any{"%bug%"}},
+		{"description=bug", "LOWER(description) = LOWER($1)", []any{"bug"}},
+		{"description!=bug", "LOWER(description) <> LOWER($1)", []any{"bug"}},
+
+		{"text:deploy", "search_vector @@ to_tsquery('simple', $1)", []any{"deploy"}},
+		{"text=deploy", "search_vector @@ to_tsquery('simple', $1)", []any{"deploy"}},
+
+		{"priority:high", "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) = $1", []any{3}},
+		{"priority=Low", "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) = $1", []any{1}},
+		{"priority!=low", "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) <> $1", []any{1}},
+		{"priority>low", "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) > $1", []any{1}},
+		{"priority>=medium", "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) >= $1", []any{2}},
+		{"priority<high", "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) < $1", []any{3}},
+		{"priority<=medium", "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) <= $1", []any{2}},
+
+		{"status:done", "LOWER(status) = LOWER($1)", []any{"done"}},
+		{"status=done", "LOWER(status) = LOWER($1)", []any{"done"}},
+		{"status!=done", "LOWER(status) <> LOWER($1)", []any{"done"}},
+
+		{"assignee:me", "assignee_id = $1", []any{7}},
+		{"assignee=ME", "assignee_id = $1", []any{7}},
+		{"assignee!=me", "assignee_id <> $1", []any{7}},
+		{"assignee:12", "assignee_id = $1", []any{12}},
+		{"assignee!=12", "assignee_id <> $1", []any{12}},
+
+		{"created:2024-01-01", "(created_at >= $1 AND created_at < $2)", []any{jan1, jan2}},
+		{"created=2024-01-01", "(created_at >= $1 AND created_at < $2)", []any{jan1, jan2}},
+		{"created!=2024-01-01", "(created_at < $1 OR created_at >= $2)", []any{jan1, jan2}},
+		{"created>2024-01-01", "created_at >= $1", []any{jan2}},
+		{"created>=2024-01-01", "created_at >= $1", []any{jan1}},
+		{"created<2024-01-01", "created_at < $1", []any{jan1}},
+		{"created<=2024-01-01", "created_at < $1", []any{jan2}},
+		{"created>2024-01-01T09:00:00Z", "created_at > $1", []any{instant}},
+		{"created<=2024-01-01T09:00:00Z", "created_at <= $1", []any{instant}},
+		{"created:today", "(created_at >= $1 AND created_at < $2)", []any{mar15, mar16}},
+		{"created:yesterday", "(created_at >= $1 AND created_at < $2)", []any{mar14, mar15}},
+		{"due<tomorrow", "due_at < $1", []any{mar16}},
+		{"due<=tomorrow", "due_at < $1", []any{mar17}},
+		{"due<now", "due_at < $1", []any{now}},
+		{"due:none", "due_at IS NULL", nil},
+		{"due!=NONE", "due_at IS NOT NULL", nil},
+		{"completed:none", "completed_at IS NULL", nil},
+		{"completed>=today", "completed_at >= $1", []any{mar15}},
+
+		{"status:new priority:high", "(LOWER(status) = LOWER($1) AND (CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) = $2)", []any{"new", 3}},
+		{"status:new OR -assignee:me due:none", "(LOWER(status) = LOWER($1) OR (NOT (assignee_id = $2) AND due_at IS NULL))", []any{"new", 7}},
+		{"NOT (id:1 OR id:2)", "NOT ((id = $1 OR id = $2))", []any{1, 2}},
+	}
+	for _, tt := range tests {
+		where, args, err := ParseAndCompile(tt.expr, Context{UserID: 7, Now: now}, 0)
+		if err != nil {
+			t.Errorf("ParseAndCompile(%q): %v", tt.expr, err)
+			continue
+		}
+		if where != tt.where || !equalArgs(args, tt.args) {
+			t.Errorf("ParseAndCompile(%q) = %q %v, want %q %v", tt.expr, where, args, tt.where, tt.args)
+		}
+	}
+}
+
+func equalArgs(got, want []any) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if w, ok := want[i].(time.Time); ok {
+			g, ok := got[i].(time.Time)
+			if !ok || !g.Equal(w) {
+				return false
+			}
+			continue
+		}
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCompileArgOffset(t *testing.T) {
+	where, args, err := ParseAndCompile("project:2 status:new", Context{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "(project_id = $4 AND LOWER(status) = LOWER($5))"; where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	if len(args) != 2 {
+		t.Errorf("args = %v, want 2 values", args)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{"assignee:me", 0, "assignee:me needs an authenticated user"},
+		{"status:new assignee:me", 11, "assignee:me needs an authenticated user"},
+		{"owner:me", 0, `unknown field "owner", expected one of assignee, completed, created, description, due, id, priority, project, status, text, title`},
+		{"status:new -owner:me", 12, `unknown field "owner"`},
+
+		{"id:abc", 0, `id should be a number, got "abc"`},
+		{"project>x", 0, "project should be a number"},
+		{"title>a", 0, `operator ">" is not supported for title, use : = or !=`},
+		{"description<=a", 0, `operator "<=" is not supported for description`},
+		{"text!=a", 0, `operator "!=" is not supported for text, use :`},
+		{"text>a", 0, `operator ">" is not supported for text`},
+		{"priority:urgent", 0, `priority should be low, medium or high, got "urgent"`},
+		{"status>new", 0, `operator ">" is not supported for status, use : or !=`},
+		{"assignee<3", 0, `operator "<" is not supported for assignee`},
+		{"assignee:bob", 0, `assignee should be a user ID or me, got "bob"`},
+		{"created:none", 0, "created should be a date"},
+		{"due>none", 0, `operator ">" is not supported for due, use : or != with none`},
+		{"due:soon", 0, `due should be a date (2006-01-02), a time (RFC 3339), today, yesterday, tomorrow or now, got "soon"`},
+		{"completed:2024-13-01", 0, "completed should be a date"},
+	}
+	for _, tt := range tests {
+		_, _, err := ParseAndCompile(tt.expr, Context{}, 0)
+		var ferr *Error
+		if !errors.As(err, &ferr) {
+			t.Errorf("ParseAndCompile(%q) error = %v, want a *filter.Error", tt.expr, err)
+			continue
+		}
+		if ferr.Pos != tt.pos || !strings.Contains(ferr.Msg, tt.msg) {
+			t.Errorf("ParseAndCompile(%q) error at %d: %q, want at %d: %q", tt.expr, ferr.Pos, ferr.Msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestCompileSort(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "id ASC"},
+		{"due", "due_at ASC NULLS LAST, id ASC"},
+		{"-Priority, due", "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END) DESC NULLS LAST, due_at ASC NULLS LAST, id ASC"},
+	}
+	for _, tt := range tests {
+		got, err := CompileSort(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("CompileSort(%q) = %q, %v, want %q", tt.spec, got, err, tt.want)
+		}
+	}
+	if _, err := CompileSort("description"); err == nil {
+		t.Error("CompileSort(description) succeeded, want an error")
+	}
+}
@@ -0,0 +1,292 @@
+// Package filter implements the task filter language used by the q parameter,
+// for example
+//
+//	priority:high AND (status:new OR status:in_progress) AND created>2024-01-01 AND assignee:me
+//
+// An expression is parsed into an AST and compiled into a parameterized SQL
+// condition; values never become part of the SQL text.
+//
+// Terms have the form field op value, where op is one of : = != > >= < <=.
+// Terms are combined with AND, OR, NOT and parentheses; AND is implied
+// between adjacent terms and binds tighter than OR. Values containing spaces
+// or parentheses are written in double quotes.
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxLength = 1000
+	maxDepth  = 32
+)
+
+// Node is an expression in the AST.
+type Node interface {
+	node()
+}
+
+type And struct{ Left, Right Node }
+
+type Or struct{ Left, Right Node }
+
+type Not struct{ Expr Node }
+
+// Term compares a field with a value, e.g. priority:high.
+type Term struct {
+	Field string
+	Op    string
+	Value string
+	// Pos is the byte offset of the term in the expression, for errors.
+	Pos int
+}
+
+func (And) node()  {}
+func (Or) node()   {}
+func (Not) node()  {}
+func (Term) node() {}
+
+// Error describes a problem at a position in the expression.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("filter: position %d: %s", e.Pos+1, e.Msg)
+}
+
+func errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Parse parses a filter expression.
+func Parse(expr string) (Node, error) {
+	if len(expr) > maxLength {
+		return nil, errorf(maxLength, "expression is longer than %d characters", maxLength)
+	}
+	p := &parser{input: expr}
+	p.next()
+	if p.tok.kind == tokEOF {
+		return nil, errorf(0, "empty expression")
+	}
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, errorf(p.tok.pos, "unexpected %s", p.tok)
+	}
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type parser struct {
+	input string
+	pos   int
+	tok   token
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// next reads the next token outside of a value.
+func (p *parser) next() {
+	p.skipSpace()
+	start := p.pos
+	if p.pos >= len(p.input) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return
+	}
+	switch c := p.input[p.pos]; {
+	case c == '(':
+		p.pos++
+		p.tok = token{tokLParen, "(", start}
+	case c == ')':
+		p.pos++
+		p.tok = token{tokRParen, ")", start}
+	case strings.ContainsRune(":=!<>", rune(c)):
+		p.pos++
+		if p.pos < len(p.input) && p.input[p.pos] == '=' && c != ':' && c != '=' {
+			p.pos++
+		}
+		p.tok = token{tokOp, p.input[start:p.pos], start}
+	case c == '-':
+		p.pos++
+		p.tok = token{tokNot, "-", start}
+	default:
+		for p.pos < len(p.input) && isIdentChar(p.input[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start {
+			p.pos++
+		}
+		text := p.input[start:p.pos]
+		switch text {
+		case "AND":
+			p.tok = token{tokAnd, text, start}
+		case "OR":
+			p.tok = token{tokOr, text, start}
+		case "NOT":
+			p.tok = token{tokNot, text, start}
+		default:
+			p.tok = token{tokIdent, text, start}
+		}
+	}
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// value reads the value after an operator: a quoted string or everything up
+// to the next space or parenthesis.
+func (p *parser) value() (string, error) {
+	start := p.pos
+	if p.pos < len(p.input) && p.input[p.pos] == '"' {
+		var b strings.Builder
+		for p.pos++; p.pos < len(p.input); p.pos++ {
+			switch c := p.input[p.pos]; c {
+			case '"':
+				p.pos++
+				return b.String(), nil
+			case '\\':
+				if p.pos+1 < len(p.input) {
+					p.pos++
+					b.WriteByte(p.input[p.pos])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errorf(start, "unterminated quoted value")
+	}
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c == '(' || c == ')' || unicode.IsSpace(rune(c)) {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", errorf(start, "expected a value")
+	}
+	return p.input[start:p.pos], nil
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOr {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.tok.kind {
+		case tokAnd:
+			p.next()
+		case tokIdent, tokNot, tokLParen:
+			// adjacent terms are implicitly ANDed
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+}
+
+func (p *parser) parseUnary(depth int) (Node, error) {
+	if depth > maxDepth {
+		return nil, errorf(p.tok.pos, "expression is nested too deeply")
+	}
+	switch p.tok.kind {
+	case tokNot:
+		p.next()
+		expr, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Not{expr}, nil
+	case tokLParen:
+		open := p.tok.pos
+		p.next()
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, errorf(p.tok.pos, "expected \")\" to close \"(\" at position %d, found %s", open+1, p.tok)
+		}
+		p.next()
+		return expr, nil
+	case tokIdent:
+		return p.parseTerm()
+	default:
+		return nil, errorf(p.tok.pos, "expected a term like field:value, found %s", p.tok)
+	}
+}
+
+func (p *parser) parseTerm() (Node, error) {
+	field := p.tok
+	p.next()
+	if p.tok.kind != tokOp {
+		return nil, errorf(p.tok.pos, "expected an operator (: = != > >= < <=) after %q, found %s", field.text, p.tok)
+	}
+	op := p.tok.text
+	if op == "!" {
+		return nil, errorf(p.tok.pos, "unknown operator \"!\", did you mean \"!=\"?")
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.next()
+	return Term{Field: strings.ToLower(field.text), Op: op, Value: value, Pos: field.pos}, nil
+}
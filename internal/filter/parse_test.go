@@ -0,0 +1,131 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func term(field, op, value string, pos int) Term {
+	return Term{Field: field, Op: op, Value: value, Pos: pos}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Node
+	}{
+		{"status:new", term("status", ":", "new", 0)},
+		{"  status:new  ", term("status", ":", "new", 2)},
+		{"Status:New", term("status", ":", "New", 0)},
+
+		// operators
+		{"id=1", term("id", "=", "1", 0)},
+		{"id!=1", term("id", "!=", "1", 0)},
+		{"id>1", term("id", ">", "1", 0)},
+		{"id>=1", term("id", ">=", "1", 0)},
+		{"id<1", term("id", "<", "1", 0)},
+		{"id<=1", term("id", "<=", "1", 0)},
+
+		// quoting
+		{`title:"fix login (again)"`, term("title", ":", "fix login (again)", 0)},
+		{`title:"say \"hi\" \\ bye"`, term("title", ":", `say "hi" \ bye`, 0)},
+		{`title:""`, term("title", ":", "", 0)},
+		{"title:a-b_c", term("title", ":", "a-b_c", 0)},
+		{"title:AND", term("title", ":", "AND", 0)},
+
+		// implicit and explicit AND, left-associative
+		{"a:1 b:2", And{term("a", ":", "1", 0), term("b", ":", "2", 4)}},
+		{"a:1 AND b:2", And{term("a", ":", "1", 0), term("b", ":", "2", 8)}},
+		{"a:1 b:2 c:3", And{And{term("a", ":", "1", 0), term("b", ":", "2", 4)}, term("c", ":", "3", 8)}},
+
+		// AND binds tighter than OR
+		{"a:1 OR b:2 c:3", Or{term("a", ":", "1", 0), And{term("b", ":", "2", 7), term("c", ":", "3", 11)}}},
+		{"a:1 b:2 OR c:3", Or{And{term("a", ":", "1", 0), term("b", ":", "2", 4)}, term("c", ":", "3", 11)}},
+		{"a:1 OR b:2 OR c:3", Or{Or{term("a", ":", "1", 0), term("b", ":", "2", 7)}, term("c", ":", "3", 14)}},
+
+		// parentheses override precedence
+		{"(a:1 OR b:2) c:3", And{Or{term("a", ":", "1", 1), term("b", ":", "2", 8)}, term("c", ":", "3", 13)}},
+		{"a:1 (b:2 OR c:3)", And{term("a", ":", "1", 0), Or{term("b", ":", "2", 5), term("c", ":", "3", 12)}}},
+		{"((a:1))", term("a", ":", "1", 2)},
+
+		// NOT and - bind tightest
+		{"NOT a:1", Not{term("a", ":", "1", 4)}},
+		{"-a:1", Not{term("a", ":", "1", 1)}},
+		{"-a:1 b:2", And{Not{term("a", ":", "1", 1)}, term("b", ":", "2", 5)}},
+		{"NOT a:1 OR b:2", Or{Not{term("a", ":", "1", 4)}, term("b", ":", "2", 11)}},
+		{"NOT (a:1 OR b:2)", Not{Or{term("a", ":", "1", 5), term("b", ":", "2", 12)}}},
+		{"NOT NOT a:1", Not{Not{term("a", ":", "1", 8)}}},
+		{"a:1 -(b:2)", And{term("a", ":", "1", 0), Not{term("b", ":", "2", 6)}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{"", 0, "empty expression"},
+		{"   ", 0, "empty expression"},
+		{`title:"abc`, 6, "unterminated quoted value"},
+		{"priority high", 9, `expected an operator (: = != > >= < <=) after "priority", found "high"`},
+		{"priority", 8, "expected an operator"},
+		{"status!new", 6, `unknown operator "!", did you mean "!="?`},
+		{"status:", 7, "expected a value"},
+		{"status: new", 7, "expected a value"},
+		{"(status:new", 11, `expected ")" to close "(" at position 1, found end of expression`},
+		{"a:1 (b:2 c:3", 12, `to close "(" at position 5`},
+		{"status:new)", 10, `unexpected ")"`},
+		{"AND status:new", 0, `expected a term like field:value, found "AND"`},
+		{"status:new OR", 13, "expected a term like field:value, found end of expression"},
+		{"status:new AND AND id:1", 15, `found "AND"`},
+		{"()", 1, `found ")"`},
+		{"NOT", 3, "found end of expression"},
+		{":new", 0, `found ":"`},
+		{"id:1 #", 6, `after "#", found end of expression`},
+		{strings.Repeat("a", maxLength+1), maxLength, "longer than 1000 characters"},
+		{strings.Repeat("(", maxDepth+1) + "id:1" + strings.Repeat(")", maxDepth+1), maxDepth + 1, "nested too deeply"},
+		{strings.Repeat("-", maxDepth+1) + "id:1", maxDepth + 1, "nested too deeply"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		var ferr *Error
+		if !errors.As(err, &ferr) {
+			t.Errorf("Parse(%.40q) error = %v, want a *filter.Error", tt.expr, err)
+			continue
+		}
+		if ferr.Pos != tt.pos || !strings.Contains(ferr.Msg, tt.msg) {
+			t.Errorf("Parse(%.40q) error at %d: %q, want at %d: %q", tt.expr, ferr.Pos, ferr.Msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	long := "title:" + strings.Repeat("a", maxLength-len("title:"))
+	if _, err := Parse(long); err != nil {
+		t.Errorf("Parse of %d characters: %v", len(long), err)
+	}
+	deep := strings.Repeat("(", maxDepth) + "id:1" + strings.Repeat(")", maxDepth)
+	if _, err := Parse(deep); err != nil {
+		t.Errorf("Parse nested %d deep: %v", maxDepth, err)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	_, err := Parse("status:new)")
+	if got, want := err.Error(), `filter: position 11: unexpected ")"`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
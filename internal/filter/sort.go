@@ -0,0 +1,41 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+var sortColumns = map[string]string{
+	"id":        "id",
+	"title":     "title",
+	"priority":  "(CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END)",
+	"status":    "status",
+	"assignee":  "assignee_id",
+	"project":   "project_id",
+	"created":   "created_at",
+	"due":       "due_at",
+	"completed": "completed_at",
+}
+
+// CompileSort turns a sort specification such as "-priority,due" into an
+// ORDER BY list over the tasks table. Fields prefixed with "-" sort in
+// descending order; ties are broken by id.
+func CompileSort(spec string) (string, error) {
+	var terms []string
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			field, direction = field[1:], "DESC"
+		}
+		column, ok := sortColumns[strings.ToLower(field)]
+		if !ok {
+			return "", fmt.Errorf("filter: cannot sort by %q", field)
+		}
+		terms = append(terms, column+" "+direction+" NULLS LAST")
+	}
+	return strings.Join(append(terms, "id ASC"), ", "), nil
+}
@@ -0,0 +1,59 @@
+// Package graph serves a GraphQL API over users, projects and tasks. Related
+// objects are loaded in batches per request, so a query for a page of tasks
+// with their assignees and projects takes a fixed number of queries.
+package graph
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+const maxDepth = 10
+
+// NewHandler returns the /graphql endpoint. It answers POST requests with a
+// JSON body holding query, operationName and variables. With playground set,
+// GET requests get the GraphiQL IDE.
+func NewHandler(playground bool) http.Handler {
+	schema := graphql.MustParseSchema(schemaSDL, &resolver{},
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxParallelism(maxPageSize),
+	)
+	api := &relay.Handler{Schema: schema}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			api.ServeHTTP(w, r.WithContext(withLoaders(r.Context())))
+		case r.Method == http.MethodGet && playground:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(graphiQL))
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+const graphiQL = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>GraphiQL</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+</head>
+<body>
+  <div id="graphiql"></div>
+  <script src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(React.createElement(GraphiQL, { fetcher }));
+  </script>
+</body>
+</html>`
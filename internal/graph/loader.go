@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// batchWait is how long a loader collects keys before querying them.
+	batchWait = 2 * time.Millisecond
+	maxBatch  = 500
+)
+
+// loader batches lookups by ID made while resolving a request: keys asked
+// for within batchWait of each other are fetched with one query, and every
+// key is fetched at most once per request.
+type loader[V any] struct {
+	fetch func(keys []int) (map[int]V, error)
+
+	mu      sync.Mutex
+	cache   map[int]*result[V]
+	pending []int
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	found bool
+	err   error
+}
+
+func newLoader[V any](fetch func(keys []int) (map[int]V, error)) *loader[V] {
+	return &loader[V]{fetch: fetch, cache: map[int]*result[V]{}}
+}
+
+// Load returns the value for key and whether it exists.
+func (l *loader[V]) Load(key int) (V, bool, error) {
+	l.mu.Lock()
+	res, ok := l.cache[key]
+	if !ok {
+		res = &result[V]{done: make(chan struct{})}
+		l.cache[key] = res
+		l.pending = append(l.pending, key)
+		switch len(l.pending) {
+		case 1:
+			time.AfterFunc(batchWait, l.dispatch)
+		case maxBatch:
+			go l.dispatch()
+		}
+	}
+	l.mu.Unlock()
+
+	<-res.done
+	return res.value, res.found, res.err
+}
+
+// Prime stores a value that is already known, such as one from a list query.
+func (l *loader[V]) Prime(key int, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+	res := &result[V]{done: make(chan struct{}), value: value, found: true}
+	close(res.done)
+	l.cache[key] = res
+}
+
+func (l *loader[V]) dispatch() {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	results := make([]*result[V], len(keys))
+	for i, key := range keys {
+		results[i] = l.cache[key]
+	}
+	l.mu.Unlock()
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.fetch(keys)
+	for i, key := range keys {
+		results[i].value, results[i].found = values[key]
+		results[i].err = err
+		close(results[i].done)
+	}
+}
+
+// loaders holds the loaders of one request. Batched queries run in the
+// request's context, so they are traced as part of it.
+type loaders struct {
+	ctx             context.Context
+	users           *loader[model.User]
+	projects        *loader[model.Project]
+	managedProjects *loader[[]model.Project]
+
+	mu    sync.Mutex
+	tasks map[string]*loader[[]model.Task]
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		ctx: ctx,
+		users: newLoader(func(ids []int) (map[int]model.User, error) {
+			users, err := repository.GetUsersByIDs(ctx, ids)
+			byID := map[int]model.User{}
+			for _, u := range users {
+				byID[u.ID] = u
+			}
+			return byID, err
+		}),
+		projects: newLoader(func(ids []int) (map[int]model.Project, error) {
+			projects, err := repository.GetProjectsByIDs(ctx, ids)
+			byID := map[int]model.Project{}
+			for _, p := range projects {
+				byID[p.ID] = p
+			}
+			return byID, err
+		}),
+		managedProjects: newLoader(func(ids []int) (map[int][]model.Project, error) {
+			projects, err := repository.GetProjectsByManagerIDs(ctx, ids)
+			byManager := map[int][]model.Project{}
+			for _, p := range projects {
+				byManager[p.ManagerID] = append(byManager[p.ManagerID], p)
+			}
+			return byManager, err
+		}),
+		tasks: map[string]*loader[[]model.Task]{},
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// taskLists returns the loader of the tasks of assignees or projects for one
+// set of list arguments, so that sibling fields with the same arguments
+// share a query.
+func (l *loaders) taskLists(column string, list taskList) *loader[[]model.Task] {
+	key := fmt.Sprintf("%s|%q|%q|%d|%d", column, list.filter, list.sort, list.limit, list.offset)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ld, ok := l.tasks[key]; ok {
+		return ld
+	}
+	ld := newLoader(func(ids []int) (map[int][]model.Task, error) {
+		return repository.GetTasksGroupedBy(l.ctx, column, ids, list.where, list.orderBy, list.args, list.limit, list.offset)
+	})
+	l.tasks[key] = ld
+	return ld
+}
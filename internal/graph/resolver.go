@@ -0,0 +1,262 @@
+package graph
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/filter"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+const maxPageSize = 100
+
+type resolver struct{}
+
+// pageArgs are the first and offset arguments of lists; the schema gives
+// them defaults.
+type pageArgs struct {
+	First  int32
+	Offset int32
+}
+
+func (a pageArgs) page() (limit, offset int, err error) {
+	limit, offset = int(a.First), int(a.Offset)
+	if limit < 1 || limit > maxPageSize {
+		return 0, 0, fmt.Errorf("first must be between 1 and %d", maxPageSize)
+	}
+	if offset < 0 {
+		return 0, 0, errors.New("offset must not be negative")
+	}
+	return limit, offset, nil
+}
+
+type taskListArgs struct {
+	Filter *string
+	Sort   *string
+	pageArgs
+}
+
+// taskList is a compiled task list query.
+type taskList struct {
+	filter, sort  string
+	where         string
+	orderBy       string
+	args          []any
+	limit, offset int
+}
+
+// compile turns the arguments into SQL whose placeholders start after
+// argOffset.
+func (a taskListArgs) compile(ctx context.Context, argOffset int) (taskList, error) {
+	list := taskList{where: "TRUE"}
+	var err error
+	if list.limit, list.offset, err = a.page(); err != nil {
+		return taskList{}, err
+	}
+	if a.Filter != nil && *a.Filter != "" {
+		list.filter = *a.Filter
+		userID, _ := auth.UserID(ctx)
+		if list.where, list.args, err = filter.ParseAndCompile(list.filter, filter.Context{UserID: userID}, argOffset); err != nil {
+			return taskList{}, err
+		}
+	}
+	if a.Sort != nil {
+		list.sort = *a.Sort
+	}
+	if list.orderBy, err = filter.CompileSort(list.sort); err != nil {
+		return taskList{}, err
+	}
+	return list, nil
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID int32 }) (*userResolver, error) {
+	return loadUser(ctx, int(args.ID))
+}
+
+func (r *resolver) Users(ctx context.Context, args struct {
+	Name  *string
+	Email *string
+	pageArgs
+}) ([]*userResolver, error) {
+	limit, offset, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+	where, params := "TRUE", []any(nil)
+	if deref(args.Name) != "" || deref(args.Email) != "" {
+		where, params = repository.UserSearchCondition(deref(args.Name), deref(args.Email))
+	}
+	users := loadersFrom(ctx).users
+	resolvers := []*userResolver{}
+	err = repository.EachUser(ctx, where, repository.Paged("id", limit, offset), params, func(u model.User) error {
+		users.Prime(u.ID, u)
+		resolvers = append(resolvers, &userResolver{u})
+		return nil
+	})
+	return resolvers, err
+}
+
+func (r *resolver) Project(ctx context.Context, args struct{ ID int32 }) (*projectResolver, error) {
+	return loadProject(ctx, int(args.ID))
+}
+
+func (r *resolver) Projects(ctx context.Context, args struct {
+	Title     *string
+	ManagerID *int32
+	pageArgs
+}) ([]*projectResolver, error) {
+	limit, offset, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+	managerID := 0
+	if args.ManagerID != nil {
+		managerID = int(*args.ManagerID)
+	}
+	where, params := repository.ProjectSearchCondition(deref(args.Title), managerID)
+	projects := loadersFrom(ctx).projects
+	resolvers := []*projectResolver{}
+	err = repository.EachProject(ctx, where, repository.Paged("id", limit, offset), params, func(p model.Project) error {
+		projects.Prime(p.ID, p)
+		resolvers = append(resolvers, &projectResolver{p})
+		return nil
+	})
+	return resolvers, err
+}
+
+func (r *resolver) Task(ctx context.Context, args struct{ ID int32 }) (*taskResolver, error) {
+	task, err := repository.GetTaskByID(ctx, int(args.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &taskResolver{task}, nil
+}
+
+func (r *resolver) Tasks(ctx context.Context, args taskListArgs) ([]*taskResolver, error) {
+	list, err := args.compile(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := []*taskResolver{}
+	err = repository.EachTask(ctx, list.where, repository.Paged(list.orderBy, list.limit, list.offset), list.args, func(t model.Task) error {
+		resolvers = append(resolvers, &taskResolver{t})
+		return nil
+	})
+	return resolvers, err
+}
+
+func loadUser(ctx context.Context, id int) (*userResolver, error) {
+	user, ok, err := loadersFrom(ctx).users.Load(id)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &userResolver{user}, nil
+}
+
+func loadProject(ctx context.Context, id int) (*projectResolver, error) {
+	project, ok, err := loadersFrom(ctx).projects.Load(id)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &projectResolver{project}, nil
+}
+
+// loadTasks loads a page of the tasks of an assignee or project.
+func loadTasks(ctx context.Context, column string, id int, args taskListArgs) ([]*taskResolver, error) {
+	// $1 holds the IDs of the assignees or projects.
+	list, err := args.compile(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	tasks, _, err := loadersFrom(ctx).taskLists(column, list).Load(id)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*taskResolver, len(tasks))
+	for i, t := range tasks {
+		resolvers[i] = &taskResolver{t}
+	}
+	return resolvers, nil
+}
+
+type userResolver struct{ u model.User }
+
+func (r *userResolver) ID() int32                    { return int32(r.u.ID) }
+func (r *userResolver) Name() string                 { return r.u.Name }
+func (r *userResolver) Email() string                { return r.u.Email }
+func (r *userResolver) Role() string                 { return r.u.Role }
+func (r *userResolver) RegistrationAt() graphql.Time { return graphql.Time{Time: r.u.RegistrationAt} }
+
+func (r *userResolver) Tasks(ctx context.Context, args taskListArgs) ([]*taskResolver, error) {
+	return loadTasks(ctx, "assignee_id", r.u.ID, args)
+}
+
+func (r *userResolver) ManagedProjects(ctx context.Context) ([]*projectResolver, error) {
+	projects, _, err := loadersFrom(ctx).managedProjects.Load(r.u.ID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*projectResolver, len(projects))
+	for i, p := range projects {
+		resolvers[i] = &projectResolver{p}
+	}
+	return resolvers, nil
+}
+
+type projectResolver struct{ p model.Project }
+
+func (r *projectResolver) ID() int32               { return int32(r.p.ID) }
+func (r *projectResolver) Title() string           { return r.p.Title }
+func (r *projectResolver) Description() string     { return r.p.Description }
+func (r *projectResolver) StartDate() graphql.Time { return graphql.Time{Time: r.p.StartDate} }
+func (r *projectResolver) EndDate() *graphql.Time  { return optionalTime(r.p.EndDate) }
+
+func (r *projectResolver) Manager(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.p.ManagerID)
+}
+
+func (r *projectResolver) Tasks(ctx context.Context, args taskListArgs) ([]*taskResolver, error) {
+	return loadTasks(ctx, "project_id", r.p.ID, args)
+}
+
+type taskResolver struct{ t model.Task }
+
+func (r *taskResolver) ID() int32                  { return int32(r.t.ID) }
+func (r *taskResolver) Title() string              { return r.t.Title }
+func (r *taskResolver) Description() string        { return r.t.Description }
+func (r *taskResolver) Priority() string           { return r.t.Priority }
+func (r *taskResolver) Status() string             { return r.t.Status }
+func (r *taskResolver) CreatedAt() graphql.Time    { return graphql.Time{Time: r.t.CreatedAt} }
+func (r *taskResolver) CompletedAt() *graphql.Time { return optionalTime(r.t.CompletedAt) }
+func (r *taskResolver) DueAt() *graphql.Time       { return optionalTime(r.t.DueAt) }
+
+func (r *taskResolver) Assignee(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.t.AssigneeID)
+}
+
+func (r *taskResolver) Project(ctx context.Context) (*projectResolver, error) {
+	return loadProject(ctx, r.t.ProjectID)
+}
+
+func optionalTime(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: t}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
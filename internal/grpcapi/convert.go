@@ -0,0 +1,115 @@
+package grpcapi
+
+import (
+	"HL_project_management/internal/grpcapi/pmv1"
+	"HL_project_management/internal/model"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// timestamp leaves zero times unset.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func userToProto(u model.User) *pmv1.User {
+	return &pmv1.User{
+		Id:             int64(u.ID),
+		Name:           u.Name,
+		Email:          u.Email,
+		Role:           u.Role,
+		RegistrationAt: timestamp(u.RegistrationAt),
+	}
+}
+
+func userFromProto(u *pmv1.User) model.User {
+	return model.User{
+		Name:  u.GetName(),
+		Email: u.GetEmail(),
+		Role:  u.GetRole(),
+	}
+}
+
+func usersToProto(users []model.User) *pmv1.ListUsersResponse {
+	resp := &pmv1.ListUsersResponse{Users: make([]*pmv1.User, len(users))}
+	for i, u := range users {
+		resp.Users[i] = userToProto(u)
+	}
+	return resp
+}
+
+func taskToProto(t model.Task) *pmv1.Task {
+	return &pmv1.Task{
+		Id:          int64(t.ID),
+		Title:       t.Title,
+		Description: t.Description,
+		Priority:    t.Priority,
+		Status:      t.Status,
+		AssigneeId:  int64(t.AssigneeID),
+		ProjectId:   int64(t.ProjectID),
+		CreatedAt:   timestamp(t.CreatedAt),
+		CompletedAt: timestamp(t.CompletedAt),
+		DueAt:       timestamp(t.DueAt),
+	}
+}
+
+func taskFromProto(t *pmv1.Task) model.Task {
+	return model.Task{
+		Title:       t.GetTitle(),
+		Description: t.GetDescription(),
+		Priority:    t.GetPriority(),
+		Status:      t.GetStatus(),
+		AssigneeID:  int(t.GetAssigneeId()),
+		ProjectID:   int(t.GetProjectId()),
+		CompletedAt: fromTimestamp(t.GetCompletedAt()),
+		DueAt:       fromTimestamp(t.GetDueAt()),
+	}
+}
+
+func tasksToProto(tasks []model.Task) *pmv1.ListTasksResponse {
+	resp := &pmv1.ListTasksResponse{Tasks: make([]*pmv1.Task, len(tasks))}
+	for i, t := range tasks {
+		resp.Tasks[i] = taskToProto(t)
+	}
+	return resp
+}
+
+func projectToProto(p model.Project) *pmv1.Project {
+	return &pmv1.Project{
+		Id:          int64(p.ID),
+		Title:       p.Title,
+		Description: p.Description,
+		StartDate:   timestamp(p.StartDate),
+		EndDate:     timestamp(p.EndDate),
+		ManagerId:   int64(p.ManagerID),
+	}
+}
+
+func projectFromProto(p *pmv1.Project) model.Project {
+	return model.Project{
+		Title:       p.GetTitle(),
+		Description: p.GetDescription(),
+		StartDate:   fromTimestamp(p.GetStartDate()),
+		EndDate:     fromTimestamp(p.GetEndDate()),
+		ManagerID:   int(p.GetManagerId()),
+	}
+}
+
+func projectsToProto(projects []model.Project) *pmv1.ListProjectsResponse {
+	resp := &pmv1.ListProjectsResponse{Projects: make([]*pmv1.Project, len(projects))}
+	for i, p := range projects {
+		resp.Projects[i] = projectToProto(p)
+	}
+	return resp
+}
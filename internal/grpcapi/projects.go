@@ -0,0 +1,89 @@
+package grpcapi
+
+import (
+	"HL_project_management/internal/grpcapi/pmv1"
+	"HL_project_management/internal/repository"
+	"HL_project_management/internal/service"
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type projectService struct {
+	pmv1.UnimplementedProjectServiceServer
+}
+
+func (s *projectService) ListProjects(ctx context.Context, _ *pmv1.ListProjectsRequest) (*pmv1.ListProjectsResponse, error) {
+	projects, err := repository.GetAllProjects(ctx)
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return projectsToProto(projects), nil
+}
+
+func (s *projectService) GetProject(ctx context.Context, req *pmv1.GetByIDRequest) (*pmv1.Project, error) {
+	project, err := repository.GetProjectByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "Project not found")
+	}
+	return projectToProto(project), nil
+}
+
+func (s *projectService) CreateProject(ctx context.Context, req *pmv1.CreateProjectRequest) (*pmv1.Project, error) {
+	project := projectFromProto(req.GetProject())
+	if err := validate.Struct(project); err != nil {
+		return nil, invalid(err)
+	}
+	if err := service.PrepareNewProject(&project); err != nil {
+		return nil, invalid(err)
+	}
+	project, err := repository.CreateProject(ctx, project)
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return projectToProto(project), nil
+}
+
+func (s *projectService) UpdateProject(ctx context.Context, req *pmv1.UpdateProjectRequest) (*pmv1.Project, error) {
+	project := projectFromProto(req.GetProject())
+	if err := validate.Struct(project); err != nil {
+		return nil, invalid(err)
+	}
+	if _, err := repository.GetProjectByID(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "Project not found")
+	}
+	project, err := repository.UpdateProject(ctx, int(req.GetId()), project)
+	if err != nil {
+		return nil, toStatus(err, "Project not found")
+	}
+	return projectToProto(project), nil
+}
+
+func (s *projectService) DeleteProject(ctx context.Context, req *pmv1.GetByIDRequest) (*emptypb.Empty, error) {
+	if _, err := repository.GetProjectByID(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "Project not found")
+	}
+	if err := repository.DeleteProject(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "Project not found")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *projectService) SearchProjects(ctx context.Context, req *pmv1.SearchProjectsRequest) (*pmv1.ListProjectsResponse, error) {
+	projects, err := repository.SearchProjects(ctx, req.GetTitle(), int(req.GetManagerId()))
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return projectsToProto(projects), nil
+}
+
+func (s *projectService) ListProjectTasks(ctx context.Context, req *pmv1.GetByIDRequest) (*pmv1.ListTasksResponse, error) {
+	if _, err := repository.GetProjectByID(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "Project not found")
+	}
+	tasks, err := repository.GetTasksByProjectID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return tasksToProto(tasks), nil
+}
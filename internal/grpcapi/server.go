@@ -0,0 +1,185 @@
+// Package grpcapi serves the user, task and project operations of the REST
+// API over gRPC for other backend services.
+package grpcapi
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/grpcapi/pmv1"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+var validate = validator.New()
+
+// stopping is closed by Shutdown to end WatchTasks streams, which would
+// otherwise keep a graceful stop waiting forever.
+var (
+	stopping     = make(chan struct{})
+	stoppingOnce sync.Once
+)
+
+// NewServer returns a gRPC server with the user, task and project services,
+// health checking and reflection registered. The health server is returned
+// so that the caller can report the service as not serving on shutdown.
+func NewServer() (*grpc.Server, *health.Server) {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryAuth),
+		grpc.ChainStreamInterceptor(streamAuth),
+	)
+	pmv1.RegisterUserServiceServer(s, &userService{})
+	pmv1.RegisterTaskServiceServer(s, &taskService{})
+	pmv1.RegisterProjectServiceServer(s, &projectService{})
+
+	hs := health.NewServer()
+	for _, name := range []string{
+		"",
+		pmv1.UserService_ServiceDesc.ServiceName,
+		pmv1.TaskService_ServiceDesc.ServiceName,
+		pmv1.ProjectService_ServiceDesc.ServiceName,
+	} {
+		hs.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(s, hs)
+	reflection.Register(s)
+	return s, hs
+}
+
+// Shutdown reports the server as not serving, ends open WatchTasks streams
+// and waits for running calls to finish. If ctx is done first, the remaining
+// calls are cancelled.
+func Shutdown(ctx context.Context, s *grpc.Server, hs *health.Server) {
+	hs.Shutdown()
+	stoppingOnce.Do(func() { close(stopping) })
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Stop()
+		<-done
+	}
+}
+
+// methodScopes lists the scopes each method needs when called with an API
+// key or a scoped token, like the routes of the REST API. Methods that are
+// not listed, such as reflection, are closed to such callers.
+var methodScopes = map[string][]string{
+	healthpb.Health_Check_FullMethodName: {},
+	healthpb.Health_Watch_FullMethodName: {},
+
+	pmv1.UserService_ListUsers_FullMethodName:     {auth.UsersRead},
+	pmv1.UserService_GetUser_FullMethodName:       {auth.UsersRead},
+	pmv1.UserService_CreateUser_FullMethodName:    {auth.UsersWrite},
+	pmv1.UserService_UpdateUser_FullMethodName:    {auth.UsersWrite},
+	pmv1.UserService_DeleteUser_FullMethodName:    {auth.UsersWrite},
+	pmv1.UserService_SearchUsers_FullMethodName:   {auth.UsersRead},
+	pmv1.UserService_ListUserTasks_FullMethodName: {auth.UsersRead, auth.TasksRead},
+
+	pmv1.TaskService_ListTasks_FullMethodName:   {auth.TasksRead},
+	pmv1.TaskService_GetTask_FullMethodName:     {auth.TasksRead},
+	pmv1.TaskService_CreateTask_FullMethodName:  {auth.TasksWrite},
+	pmv1.TaskService_UpdateTask_FullMethodName:  {auth.TasksWrite},
+	pmv1.TaskService_DeleteTask_FullMethodName:  {auth.TasksWrite},
+	pmv1.TaskService_SearchTasks_FullMethodName: {auth.TasksRead},
+	pmv1.TaskService_WatchTasks_FullMethodName:  {auth.TasksRead},
+
+	pmv1.ProjectService_ListProjects_FullMethodName:     {auth.ProjectsRead},
+	pmv1.ProjectService_GetProject_FullMethodName:       {auth.ProjectsRead},
+	pmv1.ProjectService_CreateProject_FullMethodName:    {auth.ProjectsWrite},
+	pmv1.ProjectService_UpdateProject_FullMethodName:    {auth.ProjectsWrite},
+	pmv1.ProjectService_DeleteProject_FullMethodName:    {auth.ProjectsAdmin},
+	pmv1.ProjectService_SearchProjects_FullMethodName:   {auth.ProjectsRead},
+	pmv1.ProjectService_ListProjectTasks_FullMethodName: {auth.ProjectsRead, auth.TasksRead},
+}
+
+// authorize identifies the caller from the authorization and x-user-id
+// metadata, the gRPC counterparts of the headers read by auth.Middleware,
+// and checks that it may call method.
+func authorize(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx, err := auth.Authenticate(ctx, first(md, "authorization"), first(md, "x-user-id"))
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		return nil, status.Error(codes.Unauthenticated, "Invalid user")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	needed, ok := methodScopes[method]
+	if err := auth.CheckScopes(ctx, needed, ok); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	return ctx, nil
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func unaryAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func streamAuth(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &userStream{ServerStream: ss, ctx: ctx})
+}
+
+type userStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *userStream) Context() context.Context {
+	return s.ctx
+}
+
+func actor(ctx context.Context) int {
+	id, _ := auth.UserID(ctx)
+	return id
+}
+
+// toStatus maps repository errors to gRPC status codes.
+func toStatus(err error, notFound string) error {
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, notFound)
+	case errors.As(err, &pqErr) && pqErr.Code == "23505":
+		return status.Error(codes.AlreadyExists, pqErr.Message)
+	case errors.As(err, &pqErr) && pqErr.Code.Class() == "23":
+		return status.Error(codes.FailedPrecondition, pqErr.Message)
+	case errors.As(err, &pqErr) && pqErr.Code.Class() == "22":
+		return status.Error(codes.InvalidArgument, pqErr.Message)
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func invalid(err error) error {
+	return status.Error(codes.InvalidArgument, err.Error())
+}
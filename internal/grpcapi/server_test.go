@@ -0,0 +1,127 @@
+package grpcapi
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/grpcapi/pmv1"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func token(t *testing.T, scope string) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "7",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"scope": scope,
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestAuthorize(t *testing.T) {
+	auth.Configure(auth.Config{JWTSecret: secret})
+	t.Cleanup(func() { auth.Configure(auth.Config{}) })
+
+	lis := bufconn.Listen(1 << 20)
+	s, _ := NewServer()
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	health := healthpb.NewHealthClient(conn)
+	tasks := pmv1.NewTaskServiceClient(conn)
+	projects := pmv1.NewProjectServiceClient(conn)
+	with := func(kv ...string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), kv...)
+	}
+	reflect := func(ctx context.Context) error {
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+		if err != nil {
+			return err
+		}
+		stream.Send(&reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{}})
+		_, err = stream.Recv()
+		return err
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+		msg  string
+	}{
+		{"anonymous health check", func() error {
+			_, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+			return err
+		}, codes.OK, ""},
+		{"untrusted x-user-id", func() error {
+			_, err := tasks.GetTask(with("x-user-id", "1"), &pmv1.GetByIDRequest{Id: 1})
+			return err
+		}, codes.Unauthenticated, "Invalid user"},
+		{"forged token", func() error {
+			_, err := tasks.GetTask(with("authorization", "Bearer "+token(t, "")+"x"), &pmv1.GetByIDRequest{Id: 1})
+			return err
+		}, codes.Unauthenticated, "Invalid user"},
+		{"scoped health check", func() error {
+			_, err := health.Check(with("authorization", "Bearer "+token(t, "users:read")), &healthpb.HealthCheckRequest{})
+			return err
+		}, codes.OK, ""},
+		{"missing scope", func() error {
+			_, err := tasks.GetTask(with("authorization", "Bearer "+token(t, "users:read")), &pmv1.GetByIDRequest{Id: 1})
+			return err
+		}, codes.PermissionDenied, "Missing scope tasks:read"},
+		{"read scope cannot write", func() error {
+			_, err := tasks.DeleteTask(with("authorization", "Bearer "+token(t, "tasks:read")), &pmv1.GetByIDRequest{Id: 1})
+			return err
+		}, codes.PermissionDenied, "Missing scope tasks:write"},
+		{"write scope cannot delete projects", func() error {
+			_, err := projects.DeleteProject(with("authorization", "Bearer "+token(t, "projects:write")), &pmv1.GetByIDRequest{Id: 1})
+			return err
+		}, codes.PermissionDenied, "Missing scope projects:admin"},
+		{"streams are checked", func() error {
+			stream, err := tasks.WatchTasks(with("authorization", "Bearer "+token(t, "projects:read")), &pmv1.WatchTasksRequest{})
+			if err != nil {
+				return err
+			}
+			_, err = stream.Recv()
+			return err
+		}, codes.PermissionDenied, "Missing scope tasks:read"},
+		{"unlisted method", func() error {
+			return reflect(with("authorization", "Bearer "+token(t, "tasks:write")))
+		}, codes.PermissionDenied, "Not available with an API key or scoped token"},
+		{"unlisted method without scopes", func() error {
+			return reflect(context.Background())
+		}, codes.OK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			st := status.Convert(err)
+			if st.Code() != tt.code || tt.msg != "" && st.Message() != tt.msg {
+				t.Errorf("got %v %q, want %v %q", st.Code(), st.Message(), tt.code, tt.msg)
+			}
+		})
+	}
+}
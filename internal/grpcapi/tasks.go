@@ -0,0 +1,169 @@
+package grpcapi
+
+import (
+	"HL_project_management/internal/events"
+	"HL_project_management/internal/filter"
+	"HL_project_management/internal/grpcapi/pmv1"
+	"HL_project_management/internal/repository"
+	"HL_project_management/internal/service"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// watchBuffer is how many events a WatchTasks stream may fall behind before
+// it is closed.
+const watchBuffer = 256
+
+type taskService struct {
+	pmv1.UnimplementedTaskServiceServer
+}
+
+func (s *taskService) ListTasks(ctx context.Context, _ *pmv1.ListTasksRequest) (*pmv1.ListTasksResponse, error) {
+	tasks, err := repository.GetAllTasks(ctx)
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return tasksToProto(tasks), nil
+}
+
+func (s *taskService) GetTask(ctx context.Context, req *pmv1.GetByIDRequest) (*pmv1.Task, error) {
+	task, err := repository.GetTaskByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "Task not found")
+	}
+	return taskToProto(task), nil
+}
+
+func (s *taskService) CreateTask(ctx context.Context, req *pmv1.CreateTaskRequest) (*pmv1.Task, error) {
+	task := taskFromProto(req.GetTask())
+	if err := validate.Struct(task); err != nil {
+		return nil, invalid(err)
+	}
+	if err := service.PrepareNewTask(&task); err != nil {
+		return nil, invalid(err)
+	}
+	task, err := repository.CreateTask(ctx, task)
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	service.AnnounceTaskCreated(ctx, task, actor(ctx))
+	return taskToProto(task), nil
+}
+
+func (s *taskService) UpdateTask(ctx context.Context, req *pmv1.UpdateTaskRequest) (*pmv1.Task, error) {
+	task := taskFromProto(req.GetTask())
+	if err := validate.Struct(task); err != nil {
+		return nil, invalid(err)
+	}
+	existing, err := repository.GetTaskByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "Task not found")
+	}
+	task, err = repository.UpdateTask(ctx, existing.ID, task)
+	if err != nil {
+		return nil, toStatus(err, "Task not found")
+	}
+	service.AnnounceTaskUpdated(ctx, existing, task, actor(ctx))
+	return taskToProto(task), nil
+}
+
+func (s *taskService) DeleteTask(ctx context.Context, req *pmv1.GetByIDRequest) (*emptypb.Empty, error) {
+	task, err := repository.GetTaskByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "Task not found")
+	}
+	if err := service.RemoveTask(ctx, task, actor(ctx)); err != nil {
+		return nil, toStatus(err, "Task not found")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *taskService) SearchTasks(ctx context.Context, req *pmv1.SearchTasksRequest) (*pmv1.ListTasksResponse, error) {
+	if req.GetFilter() != "" {
+		where, args, err := filter.ParseAndCompile(req.GetFilter(), filter.Context{UserID: actor(ctx)}, 0)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		tasks, err := repository.FilterTasks(ctx, where, "id", args...)
+		if err != nil {
+			return nil, toStatus(err, "")
+		}
+		return tasksToProto(tasks), nil
+	}
+	tasks, err := repository.SearchTasks(ctx, req.GetTitle(), req.GetPriority(), req.GetStatus(), int(req.GetAssigneeId()), int(req.GetProjectId()))
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return tasksToProto(tasks), nil
+}
+
+// WatchTasks relays task events from the event bus. Streams that fall too far
+// behind are closed with ResourceExhausted rather than slowing down the
+// publishers.
+func (s *taskService) WatchTasks(req *pmv1.WatchTasksRequest, stream pmv1.TaskService_WatchTasksServer) error {
+	types := map[events.Type]bool{}
+	for _, t := range req.GetTypes() {
+		types[events.Type(t)] = true
+	}
+
+	queue := make(chan events.Event, watchBuffer)
+	overflow := make(chan struct{})
+	var once sync.Once
+	unsubscribe := events.Subscribe(func(e events.Event) {
+		if len(types) > 0 && !types[e.Type] {
+			return
+		}
+		if req.GetProjectId() != 0 && int64(e.ProjectID) != req.GetProjectId() {
+			return
+		}
+		select {
+		case queue <- e:
+		default:
+			once.Do(func() { close(overflow) })
+		}
+	})
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-stopping:
+			return status.Error(codes.Unavailable, "Server is shutting down")
+		case <-overflow:
+			return status.Error(codes.ResourceExhausted, "Too many pending task events")
+		case e := <-queue:
+			msg := &pmv1.TaskEvent{
+				Type:    string(e.Type),
+				TaskId:  int64(e.TaskID),
+				ActorId: int64(e.ActorID),
+				At:      timestamp(e.At),
+			}
+			assigneeID := e.UserID
+			if e.Type != events.TaskDeleted {
+				task, err := repository.GetTaskByID(stream.Context(), e.TaskID)
+				if errors.Is(err, sql.ErrNoRows) {
+					// Deleted since; its own event follows.
+					continue
+				}
+				if err != nil {
+					return toStatus(err, "")
+				}
+				assigneeID = task.AssigneeID
+				msg.Task = taskToProto(task)
+			}
+			if req.GetAssigneeId() != 0 && int64(assigneeID) != req.GetAssigneeId() {
+				continue
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
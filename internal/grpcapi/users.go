@@ -0,0 +1,87 @@
+package grpcapi
+
+import (
+	"HL_project_management/internal/grpcapi/pmv1"
+	"HL_project_management/internal/repository"
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type userService struct {
+	pmv1.UnimplementedUserServiceServer
+}
+
+func (s *userService) ListUsers(ctx context.Context, _ *pmv1.ListUsersRequest) (*pmv1.ListUsersResponse, error) {
+	users, err := repository.GetAllUsers(ctx)
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return usersToProto(users), nil
+}
+
+func (s *userService) GetUser(ctx context.Context, req *pmv1.GetByIDRequest) (*pmv1.User, error) {
+	user, err := repository.GetUserByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "User not found")
+	}
+	return userToProto(user), nil
+}
+
+func (s *userService) CreateUser(ctx context.Context, req *pmv1.CreateUserRequest) (*pmv1.User, error) {
+	user := userFromProto(req.GetUser())
+	if err := validate.Struct(user); err != nil {
+		return nil, invalid(err)
+	}
+	user.RegistrationAt = time.Now()
+	user, err := repository.CreateUser(ctx, user)
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return userToProto(user), nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, req *pmv1.UpdateUserRequest) (*pmv1.User, error) {
+	user := userFromProto(req.GetUser())
+	if err := validate.Struct(user); err != nil {
+		return nil, invalid(err)
+	}
+	if _, err := repository.GetUserByID(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "User not found")
+	}
+	user, err := repository.UpdateUser(ctx, int(req.GetId()), user)
+	if err != nil {
+		return nil, toStatus(err, "User not found")
+	}
+	return userToProto(user), nil
+}
+
+func (s *userService) DeleteUser(ctx context.Context, req *pmv1.GetByIDRequest) (*emptypb.Empty, error) {
+	if _, err := repository.GetUserByID(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "User not found")
+	}
+	if err := repository.DeleteUser(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "User not found")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *userService) SearchUsers(ctx context.Context, req *pmv1.SearchUsersRequest) (*pmv1.ListUsersResponse, error) {
+	users, err := repository.SearchUsers(ctx, req.GetName(), req.GetEmail())
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return usersToProto(users), nil
+}
+
+func (s *userService) ListUserTasks(ctx context.Context, req *pmv1.GetByIDRequest) (*pmv1.ListTasksResponse, error) {
+	if _, err := repository.GetUserByID(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err, "User not found")
+	}
+	tasks, err := repository.GetTasksByUserID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return tasksToProto(tasks), nil
+}
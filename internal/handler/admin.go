@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"HL_project_management/internal/logging"
+	"encoding/json"
+	"net/http"
+)
+
+// LogLevel is the minimum level the service logs at.
+type LogLevel struct {
+	Level string `json:"level" example:"info"`
+}
+
+// @Summary Get log level
+// @Description Get the minimum level the service logs at. Needs the admin token as a bearer token.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} LogLevel
+// @Failure 401 {string} string "Unauthorized"
+// @Router /admin/log-level [get]
+func GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LogLevel{Level: logging.Level()})
+}
+
+// @Summary Set log level
+// @Description Change the minimum level the service logs at until it restarts. Needs the admin token as a bearer token.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param level body LogLevel true "Level: debug, info, warn or error"
+// @Success 200 {object} LogLevel
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /admin/log-level [put]
+func SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req LogLevel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	previous := logging.Level()
+	if err := logging.SetLevel(req.Level); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logging.FromContext(r.Context()).Warn("log level changed", "from", previous, "to", logging.Level())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LogLevel{Level: logging.Level()})
+}
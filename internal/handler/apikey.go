@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// @Summary Get my API keys
+// @Description List the API keys of the current user. The keys themselves are not included.
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} model.APIKey
+// @Failure 401 {string} string "Sign in with an access token"
+// @Failure 403 {string} string "Not available with an API key or scoped token"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/api-keys [get]
+func GetMyAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := verifiedUser(w, r)
+	if !ok {
+		return
+	}
+	keys, err := repository.GetAPIKeysByUserID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(keys)
+}
+
+// @Summary Create an API key
+// @Description Create an API key acting as the current user, who has to sign in with an access token, limited to the given scopes: tasks:read, tasks:write, projects:read, projects:write, projects:admin, users:read and users:write. Write includes read, and admin includes both. Send it as "Authorization: Bearer pm_...". The key is only returned in this response.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body model.APIKey true "Key name, scopes and optional expiry"
+// @Success 201 {object} model.APIKey
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Sign in with an access token"
+// @Failure 403 {string} string "Not available with an API key or scoped token"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/api-keys [post]
+func CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := verifiedUser(w, r)
+	if !ok {
+		return
+	}
+	var key model.APIKey
+	if err := json.NewDecoder(r.Body).Decode(&key); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := validate.Struct(key); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+		http.Error(w, "expiresAt should be in the future", http.StatusBadRequest)
+		return
+	}
+
+	secret, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	key.UserID = userID
+	key.Prefix = prefix
+	key.LastUsedAt = nil
+	key.CreatedAt = now
+	key, err = repository.CreateAPIKey(r.Context(), key, hash)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	key.Key = secret
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+// @Summary Revoke an API key
+// @Description Delete an API key of the current user. Requests made with it fail from then on.
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {string} string "Deleted successfully"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 401 {string} string "Sign in with an access token"
+// @Failure 403 {string} string "Not available with an API key or scoped token"
+// @Failure 404 {string} string "API key not found"
+// @Router /me/api-keys/{id} [delete]
+func DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := verifiedUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	deleted, err := repository.DeleteAPIKey(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode("Deleted successfully")
+}
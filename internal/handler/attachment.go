@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"HL_project_management/internal/service"
+	"HL_project_management/internal/storage"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AttachmentConfig limits what can be uploaded and decides where it is kept.
+type AttachmentConfig struct {
+	Store   storage.BlobStore
+	MaxSize int64
+	// AllowedTypes lists accepted media types, e.g. "image/png". The type is
+	// sniffed from the content rather than trusted from the client.
+	AllowedTypes []string
+}
+
+var attachments AttachmentConfig
+
+func ConfigureAttachments(cfg AttachmentConfig) {
+	attachments = cfg
+}
+
+func allowedType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range attachments.AllowedTypes {
+		if strings.EqualFold(mediaType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// @Summary Get task attachments
+// @Description Get metadata of files attached to a task
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {array} model.Attachment
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 404 {string} string "Task not found"
+// @Router /tasks/{id}/attachments [get]
+func GetTaskAttachments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := repository.GetTaskByID(r.Context(), id); err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	list, err := repository.GetAttachmentsByTaskID(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Upload task attachment
+// @Description Attach a file to a task. The file is sent in the "file" field of a multipart form. If X-Checksum-SHA256 is given, the upload is rejected unless the content matches it.
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param file formData file true "File to attach"
+// @Param X-Checksum-SHA256 header string false "Hex-encoded SHA-256 of the file"
+// @Success 201 {object} model.Attachment
+// @Failure 400 {string} string "Invalid input"
+// @Failure 404 {string} string "Task not found"
+// @Failure 413 {string} string "File too large"
+// @Failure 415 {string} string "Unsupported file type"
+// @Failure 500 {string} string "Internal server error"
+// @Router /tasks/{id}/attachments [post]
+func UploadTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	task, err := repository.GetTaskByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	// Leave some room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, attachments.MaxSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected multipart/form-data", http.StatusBadRequest)
+		return
+	}
+	part, err := reader.NextPart()
+	for err == nil && part.FormName() != "file" {
+		part, err = reader.NextPart()
+	}
+	if err != nil {
+		http.Error(w, "Missing file", http.StatusBadRequest)
+		return
+	}
+	fileName := filepath.Base(part.FileName())
+	if fileName == "." || fileName == string(filepath.Separator) || len(fileName) > 255 {
+		http.Error(w, "Invalid file name", http.StatusBadRequest)
+		return
+	}
+
+	// Spool the upload to disk to check its size, type and checksum before it
+	// reaches the blob store.
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(part, attachments.MaxSize+1))
+	var maxBytesErr *http.MaxBytesError
+	if size > attachments.MaxSize || errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("File too large, the limit is %d bytes", attachments.MaxSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if size == 0 {
+		http.Error(w, "Empty file", http.StatusBadRequest)
+		return
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if expected := r.Header.Get("X-Checksum-SHA256"); expected != "" && !strings.EqualFold(expected, checksum) {
+		http.Error(w, "Checksum mismatch", http.StatusBadRequest)
+		return
+	}
+
+	sniff := make([]byte, 512)
+	n, _ := tmp.ReadAt(sniff, 0)
+	contentType := http.DetectContentType(sniff[:n])
+	if !allowedType(contentType) {
+		http.Error(w, fmt.Sprintf("Unsupported file type %s", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	key := fmt.Sprintf("tasks/%d/%s", task.ID, hex.EncodeToString(random))
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := attachments.Store.Put(r.Context(), key, tmp, size, contentType); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	uploaderID, _ := auth.UserID(r.Context())
+	attachment, err := repository.CreateAttachment(r.Context(), model.Attachment{
+		TaskID:      task.ID,
+		UploaderID:  uploaderID,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    checksum,
+		StorageKey:  key,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		service.DeleteBlobs(r.Context(), []model.Attachment{{StorageKey: key}})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// @Summary Download task attachment
+// @Description Download an attached file. Range requests are supported.
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Task ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 404 {string} string "Attachment not found"
+// @Router /tasks/{id}/attachments/{attachmentId} [get]
+func DownloadTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	taskID, err := strconv.Atoi(params["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(params["attachmentId"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	attachment, err := repository.GetAttachmentByID(r.Context(), taskID, id)
+	if err != nil {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	content, err := attachments.Store.Open(r.Context(), attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Checksum-SHA256", attachment.Checksum)
+	w.Header().Set("ETag", `"`+attachment.Checksum+`"`)
+	http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, content)
+}
+
+// @Summary Delete task attachment
+// @Description Delete an attached file
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {string} string "Deleted successfully"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 404 {string} string "Attachment not found"
+// @Router /tasks/{id}/attachments/{attachmentId} [delete]
+func DeleteTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	taskID, err := strconv.Atoi(params["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(params["attachmentId"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	attachment, err := repository.GetAttachmentByID(r.Context(), taskID, id)
+	if err != nil {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	if err := repository.DeleteAttachment(r.Context(), attachment.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	service.DeleteBlobs(r.Context(), []model.Attachment{attachment})
+	json.NewEncoder(w).Encode("Deleted successfully")
+}
@@ -0,0 +1,403 @@
+package handler
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"HL_project_management/internal/service"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	maxBatchSize     = 1000
+	maxBatchBodySize = 10 << 20
+)
+
+// batchKind tells runBatch how to write one kind of entity. The write
+// functions run inside the batch transaction; work that must wait for the
+// commit, such as publishing events, is queued with after.
+type batchKind[T any] struct {
+	// prepare validates an entity and fills in defaults before it is written.
+	prepare func(op string, item *T) error
+	create  func(b *repository.Batch, items []T, after func(func())) ([]T, error)
+	update  func(b *repository.Batch, id int, item T, after func(func())) (T, error)
+	delete  func(b *repository.Batch, ids []int, after func(func())) ([]int, error)
+	id      func(T) int
+}
+
+// runBatch executes the operations of a batch request in one transaction.
+// Consecutive creates and deletes are written with a single statement; if
+// one fails its operations are retried one by one to find the culprit.
+// Without continue_on_error any failure rolls back the whole batch.
+func runBatch[T any](w http.ResponseWriter, r *http.Request, kind batchKind[T]) {
+	continueOnError := r.URL.Query().Get("continue_on_error") == "true" || r.URL.Query().Get("continue_on_error") == "1"
+
+	var ops []model.BatchOperation
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+		http.Error(w, "Invalid input: expected an array of operations", http.StatusBadRequest)
+		return
+	}
+	if len(ops) == 0 {
+		http.Error(w, "No operations", http.StatusBadRequest)
+		return
+	}
+	if len(ops) > maxBatchSize {
+		http.Error(w, fmt.Sprintf("Too many operations, the limit is %d", maxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]model.BatchResult, len(ops))
+	items := make([]T, len(ops))
+	invalid := false
+	for i, op := range ops {
+		results[i] = model.BatchResult{Index: i, Op: op.Op, ID: op.ID}
+		if err := checkBatchOperation(op, &items[i], kind.prepare); err != nil {
+			results[i].Status = http.StatusBadRequest
+			results[i].Error = err.Error()
+			invalid = true
+		}
+	}
+
+	var hooks []func()
+	after := func(fn func()) { hooks = append(hooks, fn) }
+	exec := func(b *repository.Batch, group []int) error {
+		switch ops[group[0]].Op {
+		case "create":
+			batch := make([]T, len(group))
+			for k, i := range group {
+				batch[k] = items[i]
+			}
+			created, err := kind.create(b, batch, after)
+			if err != nil {
+				return err
+			}
+			for k, i := range group {
+				results[i].Status = http.StatusCreated
+				results[i].ID = kind.id(created[k])
+				results[i].Data = created[k]
+			}
+		case "update":
+			i := group[0]
+			updated, err := kind.update(b, ops[i].ID, items[i], after)
+			if err != nil {
+				return err
+			}
+			results[i].Status = http.StatusOK
+			results[i].Data = updated
+		case "delete":
+			ids := make([]int, len(group))
+			for k, i := range group {
+				ids[k] = ops[i].ID
+			}
+			deleted, err := kind.delete(b, ids, after)
+			if err != nil {
+				return err
+			}
+			found := map[int]bool{}
+			for _, id := range deleted {
+				found[id] = true
+			}
+			for _, i := range group {
+				if found[ops[i].ID] {
+					results[i].Status = http.StatusOK
+				} else {
+					results[i].Status = http.StatusNotFound
+					results[i].Error = "Not found"
+				}
+			}
+		}
+		return nil
+	}
+
+	b, err := repository.BeginBatch(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer b.Rollback()
+
+	if !invalid || continueOnError {
+		execGroups(batchGroups(ops, results),
+			func(fn func() error) error { return b.Try(r.Context(), fn) },
+			func(group []int) error { return exec(b, group) },
+			func(i int, err error) { failBatchResult(&results[i], err) })
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if batchFailed(results) && !continueOnError {
+		abortBatchResults(results)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(model.BatchResponse{Results: results})
+		return
+	}
+
+	if err := b.Commit(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, fn := range hooks {
+		fn()
+	}
+	json.NewEncoder(w).Encode(model.BatchResponse{Committed: true, Results: results})
+}
+
+// batchGroups splits the operations that have no result yet into the groups
+// they are written in: runs of consecutive creates or deletes, and single
+// updates.
+func batchGroups(ops []model.BatchOperation, results []model.BatchResult) [][]int {
+	var groups [][]int
+	for start := 0; start < len(ops); {
+		if results[start].Status != 0 {
+			start++
+			continue
+		}
+		group := []int{start}
+		if ops[start].Op != "update" {
+			for next := start + 1; next < len(ops) && results[next].Status == 0 && ops[next].Op == ops[start].Op; next++ {
+				group = append(group, next)
+			}
+		}
+		start = group[len(group)-1] + 1
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// execGroups writes each group with exec under try, which undoes the writes
+// of a call that fails. A group that fails is retried one operation at a
+// time, and the operations that still fail are passed to fail.
+func execGroups(groups [][]int, try func(func() error) error, exec func(group []int) error, fail func(i int, err error)) {
+	for _, group := range groups {
+		err := try(func() error { return exec(group) })
+		if err != nil && len(group) > 1 {
+			for _, i := range group {
+				if err := try(func() error { return exec([]int{i}) }); err != nil {
+					fail(i, err)
+				}
+			}
+		} else if err != nil {
+			fail(group[0], err)
+		}
+	}
+}
+
+// batchFailed reports whether an operation failed or was not run.
+func batchFailed(results []model.BatchResult) bool {
+	for _, res := range results {
+		if res.Status == 0 || res.Status >= 400 {
+			return true
+		}
+	}
+	return false
+}
+
+// abortBatchResults marks the operations that succeeded as not applied, for
+// a batch that is rolled back.
+func abortBatchResults(results []model.BatchResult) {
+	for i := range results {
+		if results[i].Status < 400 {
+			if results[i].Op == "create" {
+				results[i].ID = 0
+			}
+			results[i].Status = http.StatusFailedDependency
+			results[i].Error = "Not applied because another operation failed"
+			results[i].Data = nil
+		}
+	}
+}
+
+// checkBatchOperation validates an operation and decodes its data into item.
+func checkBatchOperation[T any](op model.BatchOperation, item *T, prepare func(string, *T) error) error {
+	if err := validate.Struct(op); err != nil {
+		return errors.New("op must be one of create, update or delete")
+	}
+	if op.Op != "create" && op.ID <= 0 {
+		return errors.New("id is required")
+	}
+	if op.Op == "delete" {
+		return nil
+	}
+	if len(op.Data) == 0 {
+		return errors.New("data is required")
+	}
+	if err := json.Unmarshal(op.Data, item); err != nil {
+		return err
+	}
+	return prepare(op.Op, item)
+}
+
+// failBatchResult records a failed write, telling missing rows, constraint
+// violations and invalid values apart from other errors.
+func failBatchResult(res *model.BatchResult, err error) {
+	res.Data = nil
+	res.Error = err.Error()
+	res.Status = http.StatusInternalServerError
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		res.Status = http.StatusNotFound
+		res.Error = "Not found"
+	case errors.As(err, &pqErr) && pqErr.Code.Class() == "23":
+		res.Status = http.StatusConflict
+	case errors.As(err, &pqErr) && pqErr.Code.Class() == "22":
+		res.Status = http.StatusBadRequest
+	}
+	if res.Op == "create" {
+		res.ID = 0
+	}
+}
+
+// @Summary Batch write users
+// @Description Create, update and delete up to 1000 users in one transaction. Each operation has an op (create, update or delete), the id for update and delete, and the user as data for create and update.
+// @Description The response has a result with an HTTP status for every operation. Without continue_on_error any failure rolls back the whole batch and the response is 422.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param operations body []model.BatchOperation true "Operations"
+// @Param continue_on_error query bool false "Commit the operations that succeed even if others fail"
+// @Param Idempotency-Key header string false "Unique key that makes retrying the request safe"
+// @Success 200 {object} model.BatchResponse
+// @Failure 400 {string} string "Invalid input"
+// @Failure 422 {object} model.BatchResponse "Some operations failed, nothing was written"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users:batch [post]
+func BatchUsers(w http.ResponseWriter, r *http.Request) {
+	runBatch(w, r, batchKind[model.User]{
+		prepare: func(op string, user *model.User) error {
+			if err := validate.Struct(user); err != nil {
+				return err
+			}
+			if op == "create" {
+				user.RegistrationAt = time.Now()
+			}
+			return nil
+		},
+		create: func(b *repository.Batch, users []model.User, _ func(func())) ([]model.User, error) {
+			return b.CreateUsers(r.Context(), users)
+		},
+		update: func(b *repository.Batch, id int, user model.User, _ func(func())) (model.User, error) {
+			return b.UpdateUser(r.Context(), id, user)
+		},
+		delete: func(b *repository.Batch, ids []int, _ func(func())) ([]int, error) {
+			return b.DeleteUsers(r.Context(), ids)
+		},
+		id: func(user model.User) int { return user.ID },
+	})
+}
+
+// @Summary Batch write tasks
+// @Description Create, update and delete up to 1000 tasks in one transaction. Each operation has an op (create, update or delete), the id for update and delete, and the task as data for create and update.
+// @Description The response has a result with an HTTP status for every operation. Without continue_on_error any failure rolls back the whole batch and the response is 422.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param operations body []model.BatchOperation true "Operations"
+// @Param continue_on_error query bool false "Commit the operations that succeed even if others fail"
+// @Param Idempotency-Key header string false "Unique key that makes retrying the request safe"
+// @Success 200 {object} model.BatchResponse
+// @Failure 400 {string} string "Invalid input"
+// @Failure 422 {object} model.BatchResponse "Some operations failed, nothing was written"
+// @Failure 500 {string} string "Internal server error"
+// @Router /tasks:batch [post]
+func BatchTasks(w http.ResponseWriter, r *http.Request) {
+	actorID, _ := auth.UserID(r.Context())
+	runBatch(w, r, batchKind[model.Task]{
+		prepare: func(op string, task *model.Task) error {
+			if err := validate.Struct(task); err != nil {
+				return err
+			}
+			if op == "create" {
+				return service.PrepareNewTask(task)
+			}
+			return nil
+		},
+		create: func(b *repository.Batch, tasks []model.Task, after func(func())) ([]model.Task, error) {
+			created, err := b.CreateTasks(r.Context(), tasks)
+			if err != nil {
+				return nil, err
+			}
+			after(func() {
+				for _, task := range created {
+					service.AnnounceTaskCreated(r.Context(), task, actorID)
+				}
+			})
+			return created, nil
+		},
+		update: func(b *repository.Batch, id int, task model.Task, after func(func())) (model.Task, error) {
+			existing, err := b.GetTaskByID(r.Context(), id)
+			if err != nil {
+				return model.Task{}, err
+			}
+			task, err = b.UpdateTask(r.Context(), id, task)
+			if err != nil {
+				return model.Task{}, err
+			}
+			after(func() { service.AnnounceTaskUpdated(r.Context(), existing, task, actorID) })
+			return task, nil
+		},
+		delete: func(b *repository.Batch, ids []int, after func(func())) ([]int, error) {
+			deleted, files, err := b.DeleteTasks(r.Context(), ids)
+			if err != nil {
+				return nil, err
+			}
+			after(func() {
+				service.DeleteBlobs(r.Context(), files)
+				for _, task := range deleted {
+					service.AnnounceTaskDeleted(task, actorID)
+				}
+			})
+			deletedIDs := make([]int, len(deleted))
+			for i, task := range deleted {
+				deletedIDs[i] = task.ID
+			}
+			return deletedIDs, nil
+		},
+		id: func(task model.Task) int { return task.ID },
+	})
+}
+
+// @Summary Batch write projects
+// @Description Create, update and delete up to 1000 projects in one transaction. Each operation has an op (create, update or delete), the id for update and delete, and the project as data for create and update.
+// @Description The response has a result with an HTTP status for every operation. Without continue_on_error any failure rolls back the whole batch and the response is 422.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param operations body []model.BatchOperation true "Operations"
+// @Param continue_on_error query bool false "Commit the operations that succeed even if others fail"
+// @Param Idempotency-Key header string false "Unique key that makes retrying the request safe"
+// @Success 200 {object} model.BatchResponse
+// @Failure 400 {string} string "Invalid input"
+// @Failure 422 {object} model.BatchResponse "Some operations failed, nothing was written"
+// @Failure 500 {string} string "Internal server error"
+// @Router /projects:batch [post]
+func BatchProjects(w http.ResponseWriter, r *http.Request) {
+	runBatch(w, r, batchKind[model.Project]{
+		prepare: func(op string, project *model.Project) error {
+			if err := validate.Struct(project); err != nil {
+				return err
+			}
+			if op == "create" {
+				return service.PrepareNewProject(project)
+			}
+			return nil
+		},
+		create: func(b *repository.Batch, projects []model.Project, _ func(func())) ([]model.Project, error) {
+			return b.CreateProjects(r.Context(), projects)
+		},
+		update: func(b *repository.Batch, id int, project model.Project, _ func(func())) (model.Project, error) {
+			return b.UpdateProject(r.Context(), id, project)
+		},
+		delete: func(b *repository.Batch, ids []int, _ func(func())) ([]int, error) {
+			return b.DeleteProjects(r.Context(), ids)
+		},
+		id: func(project model.Project) int { return project.ID },
+	})
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"HL_project_management/internal/model"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestBatchGroups(t *testing.T) {
+	tests := []struct {
+		ops     []string
+		invalid []int
+		want    [][]int
+	}{
+		{[]string{"create", "create", "create"}, nil, [][]int{{0, 1, 2}}},
+		{[]string{"update", "update"}, nil, [][]int{{0}, {1}}},
+		{[]string{"create", "update", "update", "create", "delete", "delete"}, nil, [][]int{{0}, {1}, {2}, {3}, {4, 5}}},
+		{[]string{"delete", "delete", "create", "create"}, nil, [][]int{{0, 1}, {2, 3}}},
+		// Operations that already have a result are skipped and split runs.
+		{[]string{"create", "create", "create"}, []int{1}, [][]int{{0}, {2}}},
+		{[]string{"create", "create", "delete"}, []int{0}, [][]int{{1}, {2}}},
+		{[]string{"delete", "delete"}, []int{0, 1}, nil},
+	}
+	for _, tt := range tests {
+		ops := make([]model.BatchOperation, len(tt.ops))
+		results := make([]model.BatchResult, len(tt.ops))
+		for i, op := range tt.ops {
+			ops[i].Op = op
+		}
+		for _, i := range tt.invalid {
+			results[i].Status = http.StatusBadRequest
+		}
+		if got := batchGroups(ops, results); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("batchGroups(%v, invalid %v) = %v, want %v", tt.ops, tt.invalid, got, tt.want)
+		}
+	}
+}
+
+func TestExecGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  [][]int
+		bad     []int
+		written []int
+		failed  []int
+		calls   int
+	}{
+		{"all succeed", [][]int{{0, 1, 2}, {3}}, nil, []int{0, 1, 2, 3}, nil, 2},
+		{"one of a group fails", [][]int{{0, 1, 2}}, []int{1}, []int{0, 2}, []int{1}, 4},
+		{"all of a group fail", [][]int{{0, 1}}, []int{0, 1}, nil, []int{0, 1}, 3},
+		{"single fails", [][]int{{0}, {1, 2}}, []int{0}, []int{1, 2}, []int{0}, 2},
+		{"several groups", [][]int{{0, 1}, {2}, {3, 4}}, []int{4}, []int{0, 1, 2, 3}, []int{4}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var written, pending, failed []int
+			calls := 0
+			// try keeps the writes of fn only if it succeeds, like a savepoint.
+			try := func(fn func() error) error {
+				pending = nil
+				if err := fn(); err != nil {
+					return err
+				}
+				written = append(written, pending...)
+				return nil
+			}
+			exec := func(group []int) error {
+				calls++
+				for _, i := range group {
+					if slices.Contains(tt.bad, i) {
+						return fmt.Errorf("operation %d failed", i)
+					}
+					pending = append(pending, i)
+				}
+				return nil
+			}
+			fail := func(i int, err error) {
+				if want := fmt.Sprintf("operation %d failed", i); err.Error() != want {
+					t.Errorf("error for %d = %v, want %s", i, err, want)
+				}
+				failed = append(failed, i)
+			}
+
+			execGroups(tt.groups, try, exec, fail)
+			slices.Sort(written)
+			if !slices.Equal(written, tt.written) || !slices.Equal(failed, tt.failed) || calls != tt.calls {
+				t.Errorf("written %v, failed %v in %d calls; want %v, %v in %d", written, failed, calls, tt.written, tt.failed, tt.calls)
+			}
+		})
+	}
+}
+
+func TestFailBatchResult(t *testing.T) {
+	tests := []struct {
+		op     string
+		err    error
+		status int
+		msg    string
+	}{
+		{"update", sql.ErrNoRows, http.StatusNotFound, "Not found"},
+		{"update", fmt.Errorf("get task: %w", sql.ErrNoRows), http.StatusNotFound, "Not found"},
+		{"create", &pq.Error{Code: "23503", Message: "foreign key violation"}, http.StatusConflict, "pq: foreign key violation"},
+		{"create", &pq.Error{Code: "23505", Message: "duplicate key"}, http.StatusConflict, "pq: duplicate key"},
+		{"update", &pq.Error{Code: "22001", Message: "value too long"}, http.StatusBadRequest, "pq: value too long"},
+		{"delete", &pq.Error{Code: "40001", Message: "serialization failure"}, http.StatusInternalServerError, "pq: serialization failure"},
+		{"create", errors.New("connection reset"), http.StatusInternalServerError, "connection reset"},
+	}
+	for _, tt := range tests {
+		res := model.BatchResult{Op: tt.op, ID: 7, Status: http.StatusCreated, Data: "written"}
+		failBatchResult(&res, tt.err)
+		if res.Status != tt.status || res.Error != tt.msg || res.Data != nil {
+			t.Errorf("%s %v: got %d %q %v, want %d %q", tt.op, tt.err, res.Status, res.Error, res.Data, tt.status, tt.msg)
+		}
+		wantID := 7
+		if tt.op == "create" {
+			wantID = 0
+		}
+		if res.ID != wantID {
+			t.Errorf("%s %v: id = %d, want %d", tt.op, tt.err, res.ID, wantID)
+		}
+	}
+}
+
+func TestAbortBatchResults(t *testing.T) {
+	results := []model.BatchResult{
+		{Index: 0, Op: "create", ID: 10, Status: http.StatusCreated, Data: "created"},
+		{Index: 1, Op: "update", ID: 3, Status: http.StatusOK, Data: "updated"},
+		{Index: 2, Op: "delete", ID: 4, Status: http.StatusNotFound, Error: "Not found"},
+	}
+	if !batchFailed(results) {
+		t.Fatal("batchFailed = false with a 404")
+	}
+	abortBatchResults(results)
+	want := []model.BatchResult{
+		{Index: 0, Op: "create", Status: http.StatusFailedDependency, Error: "Not applied because another operation failed"},
+		{Index: 1, Op: "update", ID: 3, Status: http.StatusFailedDependency, Error: "Not applied because another operation failed"},
+		{Index: 2, Op: "delete", ID: 4, Status: http.StatusNotFound, Error: "Not found"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %+v\nwant %+v", results, want)
+	}
+
+	if batchFailed([]model.BatchResult{{Status: http.StatusCreated}, {Status: http.StatusOK}}) {
+		t.Error("batchFailed = true when all succeeded")
+	}
+	if !batchFailed([]model.BatchResult{{Status: http.StatusOK}, {}}) {
+		t.Error("batchFailed = false with an operation not run")
+	}
+}
+
+func TestCheckBatchOperation(t *testing.T) {
+	prepare := func(op string, p *model.Project) error {
+		if p.Title == "" {
+			return errors.New("title is required")
+		}
+		return nil
+	}
+	tests := []struct {
+		op   model.BatchOperation
+		want string
+	}{
+		{model.BatchOperation{Op: "create", Data: json.RawMessage(`{"title":"P"}`)}, ""},
+		{model.BatchOperation{Op: "update", ID: 1, Data: json.RawMessage(`{"title":"P"}`)}, ""},
+		{model.BatchOperation{Op: "delete", ID: 1}, ""},
+		{model.BatchOperation{Op: "upsert", Data: json.RawMessage(`{}`)}, "op must be one of create, update or delete"},
+		{model.BatchOperation{Data: json.RawMessage(`{}`)}, "op must be one of create, update or delete"},
+		{model.BatchOperation{Op: "update", Data: json.RawMessage(`{"title":"P"}`)}, "id is required"},
+		{model.BatchOperation{Op: "delete", ID: -1}, "id is required"},
+		{model.BatchOperation{Op: "create"}, "data is required"},
+		{model.BatchOperation{Op: "create", Data: json.RawMessage(`{"title":`)}, "unexpected end of JSON input"},
+		{model.BatchOperation{Op: "create", Data: json.RawMessage(`{}`)}, "title is required"},
+	}
+	for _, tt := range tests {
+		var project model.Project
+		err := checkBatchOperation(tt.op, &project, prepare)
+		if got := fmt.Sprint(err); (tt.want == "" && err != nil) || (tt.want != "" && got != tt.want) {
+			t.Errorf("checkBatchOperation(%s %d %s) = %v, want %q", tt.op.Op, tt.op.ID, tt.op.Data, err, tt.want)
+		}
+	}
+}
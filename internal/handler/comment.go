@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"HL_project_management/internal/events"
+	"HL_project_management/internal/mention"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"HL_project_management/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// @Summary Get task comments
+// @Description Get comments of a task, oldest first
+// @Tags comments
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {array} model.Comment
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 404 {string} string "Task not found"
+// @Router /tasks/{id}/comments [get]
+func GetTaskComments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := repository.GetTaskByID(r.Context(), id); err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	comments, err := repository.GetCommentsByTaskID(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(comments)
+}
+
+// @Summary Comment on task
+// @Description Add a comment to a task as the current user. Users @mentioned in the comment start watching the task.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param comment body model.Comment true "Comment data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying the request safe"
+// @Success 201 {object} model.Comment
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Task not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /tasks/{id}/comments [post]
+func CreateTaskComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	var comment model.Comment
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := validate.Struct(comment); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	task, err := repository.GetTaskByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	comment.TaskID = task.ID
+	comment.AuthorID = userID
+	comment.CreatedAt = time.Now()
+	comment, err = repository.CreateComment(r.Context(), comment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	service.Watch(r.Context(), task.ID, userID)
+	events.Publish(events.Event{
+		Type:      events.TaskCommented,
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		ActorID:   userID,
+		Mentioned: service.WatchMentions(r.Context(), task.ID, mention.Parse(comment.Body)),
+		CommentID: comment.ID,
+	})
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
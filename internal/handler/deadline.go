@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"HL_project_management/internal/logging"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// streamTimeout replaces the server's read and write timeouts for requests
+// that move large bodies: exports, imports and attachments.
+var streamTimeout = time.Hour
+
+// ConfigureStreaming sets how long a request that moves a large body may
+// take to read it and write its response.
+func ConfigureStreaming(timeout time.Duration) {
+	streamTimeout = timeout
+}
+
+// Streaming gives the requests next handles streamTimeout instead of the
+// server's timeouts. Use it for routes that read large bodies, as the body
+// may be read before next runs.
+func Streaming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		extendDeadlines(w, r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// extendDeadlines moves the read and write deadlines of the connection to
+// streamTimeout from now. Writers without deadlines, as in tests, are left
+// alone.
+func extendDeadlines(w http.ResponseWriter, r *http.Request) {
+	deadline := time.Now().Add(streamTimeout)
+	rc := http.NewResponseController(w)
+	err := errors.Join(rc.SetReadDeadline(deadline), rc.SetWriteDeadline(deadline))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logging.FromContext(r.Context()).Warn("could not extend the connection deadlines", "error", err)
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/metrics"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStreamingOutlastsWriteTimeout(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+	for _, tt := range []struct {
+		name    string
+		handler http.Handler
+		ok      bool
+	}{
+		{"plain", slow, false},
+		{"streaming", Streaming(slow), true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// The writers of the middlewares sit between the server and
+			// the handler, as in the router.
+			srv := httptest.NewUnstartedServer(logging.Middleware(metrics.Middleware(tt.handler)))
+			srv.Config.WriteTimeout = 100 * time.Millisecond
+			srv.Start()
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL)
+			var body []byte
+			if err == nil {
+				body, err = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+			if ok := err == nil && string(body) == "done"; ok != tt.ok {
+				t.Errorf("response %q, %v; want it to complete: %v", body, err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"HL_project_management/internal/export"
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportFormat picks the response format of a list endpoint from the format
+// parameter or, failing that, the Accept header. It returns "" for JSON.
+func exportFormat(r *http.Request) string {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case export.FormatCSV:
+		return export.FormatCSV
+	case export.FormatXLSX:
+		return export.FormatXLSX
+	case "json":
+		return ""
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		var format string
+		switch mediaType {
+		case export.ContentTypeCSV:
+			format = export.FormatCSV
+		case export.ContentTypeXLSX:
+			format = export.FormatXLSX
+		case "application/json", "application/*", "*/*":
+			format = ""
+		default:
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+var userColumns = []export.Column[model.User]{
+	{Name: "id", Value: func(u model.User) string { return strconv.Itoa(u.ID) }},
+	{Name: "name", Value: func(u model.User) string { return u.Name }},
+	{Name: "email", Value: func(u model.User) string { return u.Email }},
+	{Name: "registrationAt", Value: func(u model.User) string { return formatTime(u.RegistrationAt) }},
+	{Name: "role", Value: func(u model.User) string { return u.Role }},
+}
+
+var taskColumns = []export.Column[model.Task]{
+	{Name: "id", Value: func(t model.Task) string { return strconv.Itoa(t.ID) }},
+	{Name: "title", Value: func(t model.Task) string { return t.Title }},
+	{Name: "description", Value: func(t model.Task) string { return t.Description }},
+	{Name: "priority", Value: func(t model.Task) string { return t.Priority }},
+	{Name: "status", Value: func(t model.Task) string { return t.Status }},
+	{Name: "assigneeId", Value: func(t model.Task) string { return strconv.Itoa(t.AssigneeID) }},
+	{Name: "projectId", Value: func(t model.Task) string { return strconv.Itoa(t.ProjectID) }},
+	{Name: "createdAt", Value: func(t model.Task) string { return formatTime(t.CreatedAt) }},
+	{Name: "completedAt", Value: func(t model.Task) string { return formatTime(t.CompletedAt) }},
+	{Name: "dueAt", Value: func(t model.Task) string { return formatTime(t.DueAt) }},
+}
+
+var projectColumns = []export.Column[model.Project]{
+	{Name: "id", Value: func(p model.Project) string { return strconv.Itoa(p.ID) }},
+	{Name: "title", Value: func(p model.Project) string { return p.Title }},
+	{Name: "description", Value: func(p model.Project) string { return p.Description }},
+	{Name: "startDate", Value: func(p model.Project) string { return formatTime(p.StartDate) }},
+	{Name: "endDate", Value: func(p model.Project) string { return formatTime(p.EndDate) }},
+	{Name: "managerId", Value: func(p model.Project) string { return strconv.Itoa(p.ManagerID) }},
+}
+
+func exportUsers(w http.ResponseWriter, r *http.Request, format, where string, args []any) {
+	exportTable(w, r, format, "users", userColumns, func(fn func(model.User) error) error {
+		return repository.EachUser(r.Context(), where, "id", args, fn)
+	})
+}
+
+func exportTasks(w http.ResponseWriter, r *http.Request, format, where, orderBy string, args []any) {
+	exportTable(w, r, format, "tasks", taskColumns, func(fn func(model.Task) error) error {
+		return repository.EachTask(r.Context(), where, orderBy, args, fn)
+	})
+}
+
+func exportProjects(w http.ResponseWriter, r *http.Request, format, where string, args []any) {
+	exportTable(w, r, format, "projects", projectColumns, func(fn func(model.Project) error) error {
+		return repository.EachProject(r.Context(), where, "id", args, fn)
+	})
+}
+
+// exportTable streams the rows produced by each as a spreadsheet. The
+// columns parameter selects and orders columns and bom adds a byte order
+// mark to CSV output. Large exports may take longer than the server's write
+// timeout, so the deadlines are extended.
+func exportTable[T any](w http.ResponseWriter, r *http.Request, format, name string, all []export.Column[T], each func(func(T) error) error) {
+	extendDeadlines(w, r)
+	columns, err := export.Select(all, r.URL.Query().Get("columns"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	bom, _ := strconv.ParseBool(r.URL.Query().Get("bom"))
+
+	// The writer is created with the first row so that a failing query can
+	// still be reported with an error status.
+	var out export.Writer
+	start := func() error {
+		if format == export.FormatXLSX {
+			w.Header().Set("Content-Type", export.ContentTypeXLSX)
+		} else {
+			w.Header().Set("Content-Type", export.ContentTypeCSV+"; charset=utf-8")
+		}
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + "." + format}))
+		if format == export.FormatXLSX {
+			out, err = export.NewXLSXWriter(w, name)
+		} else {
+			out, err = export.NewCSVWriter(w, bom)
+		}
+		if err != nil {
+			return err
+		}
+		return out.Write(export.Header(columns))
+	}
+
+	err = each(func(v T) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return out.Write(export.Row(columns, v))
+	})
+	if err != nil && out == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("export aborted", "export", name, "error", err)
+		return
+	}
+	if out == nil {
+		if err := start(); err != nil {
+			logging.FromContext(r.Context()).Warn("export aborted", "export", name, "error", err)
+			return
+		}
+	}
+	if err := out.Close(); err != nil {
+		logging.FromContext(r.Context()).Warn("export aborted", "export", name, "error", err)
+	}
+}
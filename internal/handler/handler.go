@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/filter"
 	"HL_project_management/internal/model"
 	"HL_project_management/internal/repository"
+	"HL_project_management/internal/service"
 	"encoding/json"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -14,7 +17,7 @@ import (
 var validate = validator.New()
 
 // @Summary Health check
-// @Description Health check
+// @Description Always answers OK while the process is up. Kept for existing monitors; use /livez and /readyz instead.
 // @Tags health
 // @Produce plain
 // @Success 200 {string} string "OK"
@@ -28,11 +31,25 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 // @Description Get all users
 // @Tags users
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Response format, also negotiated with the Accept header" Enums(json, csv, xlsx)
+// @Param columns query string false "Comma-separated columns to export, all by default"
+// @Param bom query bool false "Start CSV output with a UTF-8 byte order mark"
+// @Param limit query int false "Page size (1-100); without limit and offset the whole list is returned"
+// @Param offset query int false "Number of items to skip"
 // @Success 200 {array} model.User
 // @Failure 500 {string} string "Internal server error"
 // @Router /users [get]
 func GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := repository.GetAllUsers()
+	if format := exportFormat(r); format != "" {
+		exportUsers(w, r, format, "TRUE", nil)
+		return
+	}
+	if listPage(w, r, repository.EachUser, "TRUE", "id", nil) {
+		return
+	}
+	users, err := repository.GetAllUsers(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -46,6 +63,7 @@ func GetAllUsers(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param user body model.User true "User data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying the request safe"
 // @Success 201 {object} model.User
 // @Failure 400 {string} string "Invalid input"
 // @Failure 500 {string} string "Internal server error"
@@ -63,7 +81,7 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user.RegistrationAt = time.Now()
-	createdUser, err := repository.CreateUser(user)
+	createdUser, err := repository.CreateUser(r.Context(), user)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -88,7 +106,7 @@ func GetUserByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
-	user, err := repository.GetUserByID(id)
+	user, err := repository.GetUserByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
@@ -127,7 +145,7 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	updatedUser, err := repository.UpdateUser(id, user)
+	updatedUser, err := repository.UpdateUser(r.Context(), id, user)
 	if err != nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
@@ -151,7 +169,7 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
-	err = repository.DeleteUser(id)
+	err = repository.DeleteUser(r.Context(), id)
 	if err != nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
@@ -164,7 +182,14 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 // @Description Get tasks by user ID
 // @Tags users
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param id path int true "User ID"
+// @Param format query string false "Response format, also negotiated with the Accept header" Enums(json, csv, xlsx)
+// @Param columns query string false "Comma-separated columns to export, all by default"
+// @Param bom query bool false "Start CSV output with a UTF-8 byte order mark"
+// @Param limit query int false "Page size (1-100); without limit and offset the whole list is returned"
+// @Param offset query int false "Number of items to skip"
 // @Success 200 {array} model.Task
 // @Failure 400 {string} string "Invalid ID"
 // @Failure 404 {string} string "Tasks not found"
@@ -176,12 +201,19 @@ func GetTasksByUserID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
-	_, err = repository.GetUserByID(id)
+	_, err = repository.GetUserByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
-	tasks, err := repository.GetTasksByUserID(id)
+	if format := exportFormat(r); format != "" {
+		exportTasks(w, r, format, "assignee_id = $1", "id", []any{id})
+		return
+	}
+	if listPage(w, r, repository.EachTask, "assignee_id = $1", "id", []any{id}) {
+		return
+	}
+	tasks, err := repository.GetTasksByUserID(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Tasks not found", http.StatusNotFound)
 		return
@@ -190,18 +222,35 @@ func GetTasksByUserID(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Search users by name or email
-// @Description Search users by name or email
+// @Description Search users by a case-insensitive substring of their name or email.
+// @Description Deprecated: use GET /search?types=user, which matches words and ranks the results.
 // @Tags users
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param name query string false "User name"
 // @Param email query string false "User email"
+// @Param format query string false "Response format, also negotiated with the Accept header" Enums(json, csv, xlsx)
+// @Param columns query string false "Comma-separated columns to export, all by default"
+// @Param bom query bool false "Start CSV output with a UTF-8 byte order mark"
+// @Param limit query int false "Page size (1-100); without limit and offset the whole list is returned"
+// @Param offset query int false "Number of items to skip"
 // @Success 200 {array} model.User
 // @Failure 400 {string} string "Invalid input"
+// @Deprecated
 // @Router /search/users [get]
 func SearchUsers(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	email := r.URL.Query().Get("email")
-	users, err := repository.SearchUsers(name, email)
+	if format := exportFormat(r); format != "" {
+		where, args := repository.UserSearchCondition(name, email)
+		exportUsers(w, r, format, where, args)
+		return
+	}
+	if where, args := repository.UserSearchCondition(name, email); listPage(w, r, repository.EachUser, where, "id", args) {
+		return
+	}
+	users, err := repository.SearchUsers(r.Context(), name, email)
 	if err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
@@ -213,11 +262,25 @@ func SearchUsers(w http.ResponseWriter, r *http.Request) {
 // @Description Get all tasks
 // @Tags tasks
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Response format, also negotiated with the Accept header" Enums(json, csv, xlsx)
+// @Param columns query string false "Comma-separated columns to export, all by default"
+// @Param bom query bool false "Start CSV output with a UTF-8 byte order mark"
+// @Param limit query int false "Page size (1-100); without limit and offset the whole list is returned"
+// @Param offset query int false "Number of items to skip"
 // @Success 200 {array} model.Task
 // @Failure 500 {string} string "Internal server error"
 // @Router /tasks [get]
 func GetAllTasks(w http.ResponseWriter, r *http.Request) {
-	tasks, err := repository.GetAllTasks()
+	if format := exportFormat(r); format != "" {
+		exportTasks(w, r, format, "TRUE", "id", nil)
+		return
+	}
+	if listPage(w, r, repository.EachTask, "TRUE", "id", nil) {
+		return
+	}
+	tasks, err := repository.GetAllTasks(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -231,6 +294,7 @@ func GetAllTasks(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param task body model.Task true "Task data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying the request safe"
 // @Success 201 {object} model.Task
 // @Failure 400 {string} string "Invalid input"
 // @Failure 500 {string} string "Internal server error"
@@ -246,21 +310,17 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	task.CreatedAt = time.Now()
-	if task.CompletedAt.Before(task.CreatedAt) && !task.CompletedAt.IsZero() {
-		http.Error(w, "Completed date should be after created date", http.StatusBadRequest)
+	if err := service.PrepareNewTask(&task); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
-
-	}
-	if task.CompletedAt.IsZero() {
-		task.CompletedAt = time.Now().AddDate(0, 1, 0)
-
 	}
-	createdTask, err := repository.CreateTask(task)
+	createdTask, err := repository.CreateTask(r.Context(), task)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	actorID, _ := auth.UserID(r.Context())
+	service.AnnounceTaskCreated(r.Context(), createdTask, actorID)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(createdTask)
 }
@@ -283,7 +343,7 @@ func GetTaskByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := repository.GetTaskByID(id)
+	task, err := repository.GetTaskByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -321,18 +381,20 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	_, err = repository.GetTaskByID(id)
+	existing, err := repository.GetTaskByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 
 	}
 
-	task, err = repository.UpdateTask(id, task)
+	task, err = repository.UpdateTask(r.Context(), id, task)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	actorID, _ := auth.UserID(r.Context())
+	service.AnnounceTaskUpdated(r.Context(), existing, task, actorID)
 	json.NewEncoder(w).Encode(task)
 }
 
@@ -352,14 +414,15 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
-	_, err = repository.GetTaskByID(id)
+	task, err := repository.GetTaskByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Task not found", http.StatusNotFound)
 		return
 	}
 
-	if err := repository.DeleteTask(id); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	actorID, _ := auth.UserID(r.Context())
+	if err := service.RemoveTask(r.Context(), task, actorID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -367,24 +430,65 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Search tasks
-// @Description Search tasks by title, priority, status, assignee, or project
+// @Description Search tasks with q, a filter expression such as priority:high AND (status:new OR status:in_progress) AND created>2024-01-01 AND assignee:me.
+// @Description Fields: id, title, description, text, priority, status, assignee, project, created, due, completed. Operators: : = != > >= < <=. Terms combine with AND, OR, NOT (or -) and parentheses.
+// @Description Deprecated: without q, tasks are matched by case-insensitive substrings of title, priority and status and by assignee and project; use q, or GET /search?types=task for ranked full-text search.
 // @Tags tasks
 // @Produce json
-// @Param title query string false "Task title"
-// @Param priority query string false "Task priority"
-// @Param status query string false "Task status"
-// @Param assignee query int false "Assignee ID"
-// @Param project query int false "Project ID"
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param q query string false "Filter expression; the other search parameters are ignored when it is set"
+// @Param title query string false "Deprecated: substring of the task title"
+// @Param priority query string false "Deprecated: substring of the task priority"
+// @Param status query string false "Deprecated: substring of the task status"
+// @Param assignee query int false "Deprecated: assignee ID"
+// @Param project query int false "Deprecated: project ID"
+// @Param format query string false "Response format, also negotiated with the Accept header" Enums(json, csv, xlsx)
+// @Param columns query string false "Comma-separated columns to export, all by default"
+// @Param bom query bool false "Start CSV output with a UTF-8 byte order mark"
+// @Param limit query int false "Page size (1-100); without limit and offset the whole list is returned"
+// @Param offset query int false "Number of items to skip"
 // @Success 200 {array} model.Task
 // @Failure 400 {string} string "Invalid input"
 // @Router /search/tasks [get]
 func SearchTasks(w http.ResponseWriter, r *http.Request) {
+	if q := r.URL.Query().Get("q"); q != "" {
+		userID, _ := auth.UserID(r.Context())
+		where, args, err := filter.ParseAndCompile(q, filter.Context{UserID: userID}, 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if format := exportFormat(r); format != "" {
+			exportTasks(w, r, format, where, "id", args)
+			return
+		}
+		if listPage(w, r, repository.EachTask, where, "id", args) {
+			return
+		}
+		tasks, err := repository.FilterTasks(r.Context(), where, "id", args...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(tasks)
+		return
+	}
+
 	title := r.URL.Query().Get("title")
 	priority := r.URL.Query().Get("priority")
 	status := r.URL.Query().Get("status")
 	assigneeID, err := strconv.Atoi(r.URL.Query().Get("assignee"))
 	projectID, err := strconv.Atoi(r.URL.Query().Get("project"))
-	tasks, err := repository.SearchTasks(title, priority, status, assigneeID, projectID)
+	where, args := repository.TaskSearchCondition(title, priority, status, assigneeID, projectID)
+	if format := exportFormat(r); format != "" {
+		exportTasks(w, r, format, where, "id", args)
+		return
+	}
+	if listPage(w, r, repository.EachTask, where, "id", args) {
+		return
+	}
+	tasks, err := repository.FilterTasks(r.Context(), where, "id", args...)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -397,11 +501,25 @@ func SearchTasks(w http.ResponseWriter, r *http.Request) {
 // @Description Get all projects
 // @Tags projects
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Response format, also negotiated with the Accept header" Enums(json, csv, xlsx)
+// @Param columns query string false "Comma-separated columns to export, all by default"
+// @Param bom query bool false "Start CSV output with a UTF-8 byte order mark"
+// @Param limit query int false "Page size (1-100); without limit and offset the whole list is returned"
+// @Param offset query int false "Number of items to skip"
 // @Success 200 {array} model.Project
 // @Failure 500 {string} string "Internal server error"
 // @Router /projects [get]
 func GetAllProjects(w http.ResponseWriter, r *http.Request) {
-	projects, err := repository.GetAllProjects()
+	if format := exportFormat(r); format != "" {
+		exportProjects(w, r, format, "TRUE", nil)
+		return
+	}
+	if listPage(w, r, repository.EachProject, "TRUE", "id", nil) {
+		return
+	}
+	projects, err := repository.GetAllProjects(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -415,6 +533,7 @@ func GetAllProjects(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param project body model.Project true "Project data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying the request safe"
 // @Success 201 {object} model.Project
 // @Failure 400 {string} string "Invalid input"
 // @Failure 500 {string} string "Internal server error"
@@ -430,16 +549,12 @@ func CreateProject(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	project.StartDate = time.Now()
-	if project.EndDate.Before(project.StartDate) && !project.EndDate.IsZero() {
-		http.Error(w, "End date should be after start date", http.StatusBadRequest)
+	if err := service.PrepareNewProject(&project); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if project.EndDate.IsZero() {
-		project.EndDate = time.Now().AddDate(1, 0, 0)
-	}
 
-	project, err := repository.CreateProject(project)
+	project, err := repository.CreateProject(r.Context(), project)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -464,7 +579,7 @@ func GetProjectByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	project, err := repository.GetProjectByID(id)
+	project, err := repository.GetProjectByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -503,13 +618,13 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = repository.GetProjectByID(id)
+	_, err = repository.GetProjectByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	project, err = repository.UpdateProject(id, project)
+	project, err = repository.UpdateProject(r.Context(), id, project)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -533,14 +648,14 @@ func DeleteProject(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
-	_, err = repository.GetProjectByID(id)
+	_, err = repository.GetProjectByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Project not found", http.StatusNotFound)
 		return
 
 	}
 
-	if err := repository.DeleteProject(id); err != nil {
+	if err := repository.DeleteProject(r.Context(), id); err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
@@ -552,7 +667,14 @@ func DeleteProject(w http.ResponseWriter, r *http.Request) {
 // @Description Get tasks by project ID
 // @Tags projects
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param id path int true "Project ID"
+// @Param format query string false "Response format, also negotiated with the Accept header" Enums(json, csv, xlsx)
+// @Param columns query string false "Comma-separated columns to export, all by default"
+// @Param bom query bool false "Start CSV output with a UTF-8 byte order mark"
+// @Param limit query int false "Page size (1-100); without limit and offset the whole list is returned"
+// @Param offset query int false "Number of items to skip"
 // @Success 200 {array} model.Task
 // @Failure 400 {string} string "Invalid ID"
 // @Failure 404 {string} string "Tasks not found"
@@ -564,12 +686,19 @@ func GetTasksByProjectID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
-	_, err = repository.GetProjectByID(id)
+	_, err = repository.GetProjectByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Project not found", http.StatusNotFound)
 		return
 	}
-	tasks, err := repository.GetTasksByProjectID(id)
+	if format := exportFormat(r); format != "" {
+		exportTasks(w, r, format, "project_id = $1", "id", []any{id})
+		return
+	}
+	if listPage(w, r, repository.EachTask, "project_id = $1", "id", []any{id}) {
+		return
+	}
+	tasks, err := repository.GetTasksByProjectID(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -578,18 +707,35 @@ func GetTasksByProjectID(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Search projects
-// @Description Search projects by title or manager
+// @Description Search projects by a case-insensitive substring of their title and by manager.
+// @Description Deprecated: use GET /search?types=project, which matches words and ranks the results.
 // @Tags projects
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param title query string false "Project title"
 // @Param manager query int false "Manager ID"
+// @Param format query string false "Response format, also negotiated with the Accept header" Enums(json, csv, xlsx)
+// @Param columns query string false "Comma-separated columns to export, all by default"
+// @Param bom query bool false "Start CSV output with a UTF-8 byte order mark"
+// @Param limit query int false "Page size (1-100); without limit and offset the whole list is returned"
+// @Param offset query int false "Number of items to skip"
 // @Success 200 {array} model.Project
 // @Failure 400 {string} string "Invalid input"
+// @Deprecated
 // @Router /search/projects [get]
 func SearchProjects(w http.ResponseWriter, r *http.Request) {
 	title := r.URL.Query().Get("title")
 	managerID, err := strconv.Atoi(r.URL.Query().Get("manager"))
-	projects, err := repository.SearchProjects(title, managerID)
+	if format := exportFormat(r); format != "" {
+		where, args := repository.ProjectSearchCondition(title, managerID)
+		exportProjects(w, r, format, where, args)
+		return
+	}
+	if where, args := repository.ProjectSearchCondition(title, managerID); listPage(w, r, repository.EachProject, where, "id", args) {
+		return
+	}
+	projects, err := repository.SearchProjects(r.Context(), title, managerID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"HL_project_management/internal/health"
+	"encoding/json"
+	"net/http"
+)
+
+// @Summary Liveness probe
+// @Description Reports whether the process is working. Fails when a background worker has stalled.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /livez [get]
+func Livez(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, health.Live())
+}
+
+// @Summary Readiness probe
+// @Description Reports whether the server can take traffic: the database answers, its schema is at the version the binary expects and the server is not shutting down. Background workers are listed for information.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, health.Ready(r.Context()))
+}
+
+func writeReport(w http.ResponseWriter, report health.Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !report.OK() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"HL_project_management/internal/importer"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+const maxImportSize = 10 << 20
+
+// @Summary Import tasks
+// @Description Import tasks into a project from a CSV file, a Jira CSV export or a Trello JSON export, sent as the request body or as the "file" field of a multipart form.
+// @Description Assignees are matched to users by email, or for Trello by username. Every row is validated first: with dry_run the report is returned without importing,
+// @Description otherwise the tasks are created in one transaction only when no row has errors.
+// @Tags tasks
+// @Accept text/csv,application/json,multipart/form-data
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param format query string false "Format of the file: csv, jira or trello, by default trello for JSON and csv otherwise"
+// @Param mapping query string false "JSON object mapping fields (title, description, priority, status, assignee, due) to CSV column names, e.g. {\"title\":\"Name\"}"
+// @Param default_assignee query int false "ID of the user assigned to rows without an assignee"
+// @Param dry_run query bool false "Only validate the file"
+// @Param Idempotency-Key header string false "Unique key that makes retrying the request safe"
+// @Success 200 {object} model.ImportReport "Dry run report"
+// @Success 201 {object} model.ImportReport
+// @Failure 400 {string} string "Invalid input"
+// @Failure 404 {string} string "Project not found"
+// @Failure 413 {string} string "File too large"
+// @Failure 422 {object} model.ImportReport "Some rows are invalid, nothing was imported"
+// @Failure 500 {string} string "Internal server error"
+// @Router /projects/{id}/import [post]
+func ImportTasks(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := repository.GetProjectByID(r.Context(), projectID); err != nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	dryRun := query.Get("dry_run") == "true" || query.Get("dry_run") == "1"
+	var mapping map[string]string
+	if m := query.Get("mapping"); m != "" {
+		if err := json.Unmarshal([]byte(m), &mapping); err != nil {
+			http.Error(w, "Invalid mapping: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	defaultAssignee := 0
+	if a := query.Get("default_assignee"); a != "" {
+		defaultAssignee, err = strconv.Atoi(a)
+		if err != nil {
+			http.Error(w, "Invalid default_assignee", http.StatusBadRequest)
+			return
+		}
+		if _, err := repository.GetUserByID(r.Context(), defaultAssignee); err != nil {
+			http.Error(w, "Default assignee not found", http.StatusBadRequest)
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	body, contentType, err := importFile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = importer.FormatCSV
+		if strings.HasSuffix(contentType, "json") {
+			format = importer.FormatTrello
+		}
+	}
+
+	records, err := importer.Parse(format, body, mapping)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("File too large, the limit is %d bytes", maxImportSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var assignees []string
+	for _, rec := range records {
+		if a := strings.TrimSpace(rec.Assignee); a != "" {
+			assignees = append(assignees, a)
+		}
+	}
+	users, err := repository.MatchUsers(r.Context(), assignees)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resolve := func(value string) (int, bool) {
+		id, ok := users[value]
+		return id, ok
+	}
+
+	report := model.ImportReport{DryRun: dryRun, Total: len(records), Errors: []model.ImportRowError{}}
+	tasks := make([]model.Task, 0, len(records))
+	for _, rec := range records {
+		task, problems := rec.Task(projectID, defaultAssignee, resolve)
+		if err := validate.Struct(task); err != nil {
+			problems = append(problems, validationMessages(err)...)
+		}
+		if len(problems) > 0 {
+			report.Errors = append(report.Errors, model.ImportRowError{Line: rec.Line, Messages: problems})
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	report.Valid = len(tasks)
+
+	w.Header().Set("Content-Type", "application/json")
+	if dryRun {
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+	if len(report.Errors) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	report.Tasks, err = repository.CreateTasks(r.Context(), tasks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report.Imported = len(report.Tasks)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// importFile returns the uploaded file and its content type, taking it from
+// the "file" field of a multipart form or else from the body itself.
+func importFile(r *http.Request) (io.Reader, string, error) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != "multipart/form-data" {
+		return r.Body, contentType, nil
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, "", errors.New("Missing file")
+		}
+		if part.FormName() == "file" {
+			contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if strings.HasSuffix(strings.ToLower(part.FileName()), ".json") {
+				contentType = "application/json"
+			}
+			return part, contentType, nil
+		}
+	}
+}
+
+// validationMessages turns validator errors into one message per field.
+func validationMessages(err error) []string {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []string{err.Error()}
+	}
+	messages := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		switch fe.Tag() {
+		case "required":
+			messages = append(messages, fe.Field()+" is required")
+		case "max":
+			messages = append(messages, fmt.Sprintf("%s is longer than %s characters", fe.Field(), fe.Param()))
+		case "oneof":
+			messages = append(messages, fmt.Sprintf("%s %q must be one of %s", fe.Field(), fe.Value(), fe.Param()))
+		default:
+			messages = append(messages, fmt.Sprintf("%s failed the %s check", fe.Field(), fe.Tag()))
+		}
+	}
+	return messages
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"HL_project_management/internal/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// @Summary Get my notifications
+// @Description Get notifications of the current user, newest first
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param offset query int false "Number of notifications to skip" default(0)
+// @Success 200 {array} model.Notification
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/notifications [get]
+func GetMyNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	limit, offset, err := pagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var unread bool
+	if v := r.URL.Query().Get("unread"); v != "" {
+		unread, err = strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "unread should be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	notifications, err := repository.GetNotificationsByUserID(r.Context(), userID, unread, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// @Summary Mark notification as read
+// @Description Mark a notification of the current user as read
+// @Tags notifications
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Success 200 {string} string "Marked as read"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Notification not found"
+// @Router /me/notifications/{id}/read [post]
+func MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	err = repository.MarkNotificationRead(r.Context(), userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode("Marked as read")
+}
+
+// @Summary Mark all notifications as read
+// @Description Mark all notifications of the current user as read
+// @Tags notifications
+// @Produce json
+// @Success 200 {string} string "Marked as read"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/notifications/read-all [post]
+func MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if err := repository.MarkAllNotificationsRead(r.Context(), userID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode("Marked as read")
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"HL_project_management/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetMyNotificationsInvalidInput(t *testing.T) {
+	for _, query := range []string{"unread=yes", "unread=1x", "limit=0", "offset=-1"} {
+		r := httptest.NewRequest("GET", "/me/notifications?"+query, nil)
+		r = r.WithContext(auth.WithUserID(r.Context(), 1))
+		w := httptest.NewRecorder()
+		GetMyNotifications(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d %s, want 400", query, w.Code, w.Body)
+		}
+	}
+}
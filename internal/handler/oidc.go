@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/oidc"
+	"HL_project_management/internal/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type LoginConfig struct {
+	// DefaultRole is given to new users whose groups map to no role; without
+	// one they cannot log in.
+	DefaultRole string
+	TokenTTL    time.Duration
+	// PostLoginURL, if set, receives the browser after a login, with the
+	// token in the URL fragment.
+	PostLoginURL string
+}
+
+var loginConfig = LoginConfig{TokenTTL: time.Hour}
+
+func ConfigureLogin(cfg LoginConfig) {
+	loginConfig = cfg
+}
+
+// LoginResponse carries the access token issued at login.
+type LoginResponse struct {
+	AccessToken string     `json:"accessToken"`
+	TokenType   string     `json:"tokenType" example:"Bearer"`
+	ExpiresIn   int        `json:"expiresIn" example:"3600"`
+	User        model.User `json:"user"`
+}
+
+// @Summary Log in with single sign-on
+// @Description Redirect the browser to the OpenID Connect identity provider to log in. The provider sends it back to /auth/oidc/callback.
+// @Tags auth
+// @Success 302
+// @Failure 404 {string} string "Single sign-on is not configured"
+// @Failure 502 {string} string "Identity provider unavailable"
+// @Router /auth/oidc/login [get]
+func OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	target, err := oidc.Begin(w, r)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("oidc: could not start login", "error", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// @Summary Finish a single sign-on login
+// @Description Called by the identity provider after the user has logged in. Creates the user on their first login, or links the existing user with the same email address, and sets their role from their groups. Returns an access token to send as "Authorization: Bearer ...", or redirects to the configured post-login URL with the token in the URL fragment.
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "Login state"
+// @Success 200 {object} LoginResponse
+// @Success 302
+// @Failure 401 {string} string "Login failed"
+// @Failure 403 {string} string "No role for this user"
+// @Failure 502 {string} string "Identity provider unavailable"
+// @Router /auth/oidc/callback [get]
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	id, err := oidc.Finish(w, r)
+	if errors.Is(err, oidc.ErrLogin) {
+		logger.Info("oidc: login failed", "error", err)
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logger.Error("oidc: login failed", "error", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	role := oidc.Role(id.Groups)
+	user, err := repository.GetUserByEmail(r.Context(), id.Email)
+	created := errors.Is(err, sql.ErrNoRows)
+	switch {
+	case created:
+		if role == "" {
+			role = loginConfig.DefaultRole
+		}
+		if role == "" {
+			logger.Info("oidc: no role for new user", "email", id.Email, "groups", id.Groups)
+			http.Error(w, "No role for this user", http.StatusForbidden)
+			return
+		}
+		user, err = repository.CreateUser(r.Context(), model.User{
+			Name:           loginName(id),
+			Email:          id.Email,
+			RegistrationAt: time.Now(),
+			Role:           role,
+		})
+	case err == nil && role != "" && role != user.Role:
+		user.Role = role
+		user, err = repository.UpdateUser(r.Context(), user.ID, user)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token, err := auth.NewToken(user.ID, loginConfig.TokenTTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Info("user logged in", "user_id", user.ID, "created", created, "role", user.Role)
+	resp := LoginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(loginConfig.TokenTTL.Seconds()),
+		User:        user,
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if loginConfig.PostLoginURL != "" {
+		fragment := url.Values{
+			"access_token": {resp.AccessToken},
+			"token_type":   {resp.TokenType},
+			"expires_in":   {strconv.Itoa(resp.ExpiresIn)},
+		}
+		http.Redirect(w, r, loginConfig.PostLoginURL+"#"+fragment.Encode(), http.StatusFound)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// loginName is the name of a new user, which must fit the users table.
+func loginName(id oidc.Identity) string {
+	name := []rune(id.Name)
+	if len(name) == 0 {
+		name = []rune(id.Email)
+	}
+	if len(name) > 50 {
+		name = name[:50]
+	}
+	return string(name)
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/config"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/oidc"
+	"HL_project_management/internal/oidc/oidctest"
+	"HL_project_management/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupLogin serves single sign-on against a stub provider. It skips unless
+// PM_TEST_DATABASE_URL points at a PostgreSQL database, which is migrated
+// first.
+func setupLogin(t *testing.T, cfg LoginConfig) *oidctest.Provider {
+	t.Helper()
+	dsn := os.Getenv("PM_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("PM_TEST_DATABASE_URL is not set")
+	}
+	if _, err := repository.OpenDB(config.DB{DSN: dsn}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(repository.CloseDB)
+	if err := repository.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	p := oidctest.NewProvider("pm")
+	t.Cleanup(p.Close)
+	secret := []byte("secret")
+	auth.Configure(auth.Config{JWTSecret: secret})
+	oidc.Configure(oidc.Config{
+		Issuer:       p.Issuer(),
+		ClientID:     "pm",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://pm.test/auth/oidc/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+		GroupsClaim:  "groups",
+		GroupRoles:   []oidc.GroupRole{{Group: "admins", Role: "admin"}, {Group: "staff", Role: "member"}},
+		Secret:       secret,
+	})
+	cfg.TokenTTL = time.Hour
+	ConfigureLogin(cfg)
+	t.Cleanup(func() {
+		auth.Configure(auth.Config{})
+		oidc.Configure(oidc.Config{})
+		ConfigureLogin(LoginConfig{TokenTTL: time.Hour})
+	})
+	return p
+}
+
+// ssoLogin logs in through the login and callback endpoints.
+func ssoLogin(t *testing.T) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	OIDCLogin(w, httptest.NewRequest("GET", "/auth/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login: %d %s", w.Code, w.Body)
+	}
+	c := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := c.Get(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := resp.Location()
+	if err != nil {
+		t.Fatalf("provider answered %s: %v", resp.Status, err)
+	}
+
+	r := httptest.NewRequest("GET", callback.String(), nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	OIDCCallback(w, r)
+	return w
+}
+
+// loggedIn checks that w carries a token for a user and returns the user.
+func loggedIn(t *testing.T, w *httptest.ResponseRecorder) model.User {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("callback: %d %s", w.Code, w.Body)
+	}
+	var resp LoginResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := auth.Authenticate(context.Background(), "Bearer "+resp.AccessToken, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := auth.VerifiedUserID(ctx); id != resp.User.ID {
+		t.Errorf("token is for user %d, want %d", id, resp.User.ID)
+	}
+	return resp.User
+}
+
+// testEmail returns an address no other run of the tests has used.
+func testEmail(name string) string {
+	return fmt.Sprintf("%s-%d@example.com", name, time.Now().UnixNano())
+}
+
+func TestOIDCCreatesUser(t *testing.T) {
+	p := setupLogin(t, LoginConfig{})
+	email := testEmail("new")
+	p.SetUser(oidctest.User{Subject: "new", Email: email, EmailVerified: true, Name: "New User", Groups: []string{"staff"}})
+
+	user := loggedIn(t, ssoLogin(t))
+	if user.Email != email || user.Name != "New User" || user.Role != "member" {
+		t.Errorf("created %+v, want %s with role member", user, email)
+	}
+	if again := loggedIn(t, ssoLogin(t)); again.ID != user.ID {
+		t.Errorf("second login is user %d, want %d", again.ID, user.ID)
+	}
+}
+
+func TestOIDCLinksUserByEmail(t *testing.T) {
+	p := setupLogin(t, LoginConfig{})
+	email := testEmail("linked")
+	existing, err := repository.CreateUser(context.Background(), model.User{
+		Name:           "Linked",
+		Email:          strings.ToUpper(email),
+		RegistrationAt: time.Now(),
+		Role:           "member",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Groups without a role leave the role as it is.
+	p.SetUser(oidctest.User{Subject: "linked", Email: email, EmailVerified: true, Groups: []string{"guests"}})
+	user := loggedIn(t, ssoLogin(t))
+	if user.ID != existing.ID || user.Role != "member" {
+		t.Errorf("logged in as %+v, want user %d with role member", user, existing.ID)
+	}
+
+	// Groups with a role change it.
+	p.SetUser(oidctest.User{Subject: "linked", Email: email, EmailVerified: true, Groups: []string{"staff", "admins"}})
+	user = loggedIn(t, ssoLogin(t))
+	if user.ID != existing.ID || user.Role != "admin" {
+		t.Errorf("logged in as %+v, want user %d with role admin", user, existing.ID)
+	}
+	stored, err := repository.GetUserByID(context.Background(), existing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Role != "admin" {
+		t.Errorf("stored role = %q, want admin", stored.Role)
+	}
+}
+
+func TestOIDCNoRole(t *testing.T) {
+	p := setupLogin(t, LoginConfig{})
+	p.SetUser(oidctest.User{Subject: "guest", Email: testEmail("guest"), EmailVerified: true, Groups: []string{"guests"}})
+	if w := ssoLogin(t); w.Code != http.StatusForbidden {
+		t.Errorf("new user without a role: %d %s, want 403", w.Code, w.Body)
+	}
+
+	ConfigureLogin(LoginConfig{DefaultRole: "viewer", TokenTTL: time.Hour})
+	if user := loggedIn(t, ssoLogin(t)); user.Role != "viewer" {
+		t.Errorf("role = %q, want the default role viewer", user.Role)
+	}
+}
+
+func TestOIDCUnverifiedEmail(t *testing.T) {
+	p := setupLogin(t, LoginConfig{DefaultRole: "viewer"})
+	email := testEmail("unverified")
+	p.SetUser(oidctest.User{Subject: "unverified", Email: email, EmailVerified: false})
+	if w := ssoLogin(t); w.Code != http.StatusUnauthorized {
+		t.Errorf("unverified email: %d %s, want 401", w.Code, w.Body)
+	}
+	if _, err := repository.GetUserByEmail(context.Background(), email); err == nil {
+		t.Error("a user was created for an unverified email address")
+	}
+}
+
+func TestOIDCKeyRotation(t *testing.T) {
+	p := setupLogin(t, LoginConfig{DefaultRole: "viewer"})
+	p.SetUser(oidctest.User{Subject: "rotated", Email: testEmail("rotated"), EmailVerified: true})
+	first := loggedIn(t, ssoLogin(t))
+	p.RotateKey()
+	if user := loggedIn(t, ssoLogin(t)); user.ID != first.ID {
+		t.Errorf("login after a key rotation is user %d, want %d", user.ID, first.ID)
+	}
+}
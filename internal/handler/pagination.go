@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// currentUser returns the authenticated user's ID, replying 401 if there is none.
+func currentUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, ok := auth.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}
+	return id, ok
+}
+
+// verifiedUser is currentUser for requests that have to prove the user with
+// an access token, not just name it in a header.
+func verifiedUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, ok := auth.VerifiedUserID(r.Context())
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Sign in with an access token", http.StatusUnauthorized)
+	}
+	return id, ok
+}
+
+// pagination reads the limit and offset query parameters.
+func pagination(r *http.Request) (limit, offset int, err error) {
+	limit, offset = defaultPageSize, 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, errors.New("limit should be between 1 and 100")
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset should be a non-negative number")
+		}
+	}
+	return limit, offset, nil
+}
+
+// listPage writes one page of a list when the request has a limit or an
+// offset and reports whether it did. Lists requested without either are
+// returned whole.
+func listPage[T any](w http.ResponseWriter, r *http.Request, each func(ctx context.Context, where, orderBy string, args []any, fn func(T) error) error, where, orderBy string, args []any) bool {
+	query := r.URL.Query()
+	if !query.Has("limit") && !query.Has("offset") {
+		return false
+	}
+	limit, offset, err := pagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return true
+	}
+	items := []T{}
+	err = each(r.Context(), where, repository.Paged(orderBy, limit, offset), args, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+	json.NewEncoder(w).Encode(items)
+	return true
+}
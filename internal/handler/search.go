@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"HL_project_management/internal/repository"
+	"HL_project_management/internal/search"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// @Summary Full-text search
+// @Description Search tasks, projects, comments and users at once, best matches first.
+// @Description Words are matched together, "quoted phrases" match adjacent words, word* matches prefixes, -word excludes and OR matches either side.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query" example(deploy* "release notes" -draft)
+// @Param types query string false "Comma-separated types to search (task, project, comment, user)" default(task,project,comment,user)
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {array} model.SearchResult
+// @Failure 400 {string} string "Invalid input"
+// @Failure 500 {string} string "Internal server error"
+// @Router /search [get]
+func Search(w http.ResponseWriter, r *http.Request) {
+	tsquery, err := search.ToTSQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	types := repository.SearchTypes
+	if v := r.URL.Query().Get("types"); v != "" {
+		types = strings.Split(v, ",")
+		for _, t := range types {
+			if !validSearchType(t) {
+				http.Error(w, "Unknown search type "+t, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	results, err := repository.Search(r.Context(), tsquery, types, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(results)
+}
+
+func validSearchType(t string) bool {
+	for _, known := range repository.SearchTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
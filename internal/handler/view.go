@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"HL_project_management/internal/filter"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// checkView validates a view's fields, including its filter and sort. The
+// filter is compiled as owner would run it, so that a view that saves can
+// also be run.
+func checkView(ctx context.Context, v model.SavedView, owner int) error {
+	if err := validate.Struct(v); err != nil {
+		return err
+	}
+	if v.Filter != "" {
+		if _, _, err := filter.ParseAndCompile(v.Filter, filter.Context{UserID: owner}, 0); err != nil {
+			return err
+		}
+	}
+	if _, err := filter.CompileSort(v.Sort); err != nil {
+		return err
+	}
+	if v.Shared && v.ProjectID == 0 {
+		return errors.New("shared views need a projectId")
+	}
+	if v.ProjectID != 0 {
+		if _, err := repository.GetProjectByID(ctx, v.ProjectID); err != nil {
+			return errors.New("project not found")
+		}
+	}
+	return nil
+}
+
+// canSeeView reports whether the user owns the view or it is shared with them.
+func canSeeView(ctx context.Context, v model.SavedView, userID int) (bool, error) {
+	if v.OwnerID == userID {
+		return true, nil
+	}
+	if !v.Shared {
+		return false, nil
+	}
+	return repository.IsProjectMember(ctx, v.ProjectID, userID)
+}
+
+// ownView loads a view of the current user, replying with an error if there
+// is none.
+func ownView(w http.ResponseWriter, r *http.Request, userID int) (model.SavedView, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return model.SavedView{}, false
+	}
+	view, err := repository.GetViewByID(r.Context(), id)
+	if err != nil || view.OwnerID != userID {
+		http.Error(w, "View not found", http.StatusNotFound)
+		return model.SavedView{}, false
+	}
+	return view, true
+}
+
+// @Summary Get my views
+// @Description Get the current user's saved views followed by views shared with them
+// @Tags views
+// @Produce json
+// @Success 200 {array} model.SavedView
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/views [get]
+func GetMyViews(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	views, err := repository.GetViewsVisibleTo(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(views)
+}
+
+// @Summary Create a view
+// @Description Save a task filter as a view of the current user
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param view body model.SavedView true "View data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying the request safe"
+// @Success 201 {object} model.SavedView
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/views [post]
+func CreateView(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var view model.SavedView
+	if err := json.NewDecoder(r.Body).Decode(&view); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := checkView(r.Context(), view, userID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	view.OwnerID = userID
+	view.CreatedAt = time.Now()
+	view.UpdatedAt = view.CreatedAt
+	view, err := repository.CreateView(r.Context(), view)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(view)
+}
+
+// @Summary Get my view
+// @Description Get a saved view of the current user
+// @Tags views
+// @Produce json
+// @Param id path int true "View ID"
+// @Success 200 {object} model.SavedView
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "View not found"
+// @Router /me/views/{id} [get]
+func GetMyView(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	view, ok := ownView(w, r, userID)
+	if !ok {
+		return
+	}
+	json.NewEncoder(w).Encode(view)
+}
+
+// @Summary Update a view
+// @Description Update a saved view of the current user
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path int true "View ID"
+// @Param view body model.SavedView true "View data"
+// @Success 200 {object} model.SavedView
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "View not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/views/{id} [put]
+func UpdateView(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	existing, ok := ownView(w, r, userID)
+	if !ok {
+		return
+	}
+	var view model.SavedView
+	if err := json.NewDecoder(r.Body).Decode(&view); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if err := checkView(r.Context(), view, userID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	view.UpdatedAt = time.Now()
+	view, err := repository.UpdateView(r.Context(), existing.ID, view)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(view)
+}
+
+// @Summary Delete a view
+// @Description Delete a saved view of the current user
+// @Tags views
+// @Produce json
+// @Param id path int true "View ID"
+// @Success 200 {string} string "Deleted successfully"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "View not found"
+// @Router /me/views/{id} [delete]
+func DeleteView(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	view, ok := ownView(w, r, userID)
+	if !ok {
+		return
+	}
+	if err := repository.DeleteView(r.Context(), view.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode("Deleted successfully")
+}
+
+// @Summary Get tasks of a view
+// @Description Run a saved view owned by or shared with the current user. In a shared view, assignee:me refers to the user running it.
+// @Tags views
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path int true "View ID"
+// @Param format query string false "Response format, also negotiated with the Accept header" Enums(json, csv, xlsx)
+// @Param columns query string false "Comma-separated columns to export, all by default"
+// @Param bom query bool false "Start CSV output with a UTF-8 byte order mark"
+// @Param limit query int false "Page size (1-100); without limit and offset the whole list is returned"
+// @Param offset query int false "Number of items to skip"
+// @Success 200 {array} model.Task
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "View not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /views/{id}/tasks [get]
+func GetViewTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	view, err := repository.GetViewByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "View not found", http.StatusNotFound)
+		return
+	}
+	visible, err := canSeeView(r.Context(), view, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		http.Error(w, "View not found", http.StatusNotFound)
+		return
+	}
+
+	where, args := "TRUE", []any{}
+	if view.Filter != "" {
+		where, args, err = filter.ParseAndCompile(view.Filter, filter.Context{UserID: userID}, 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if view.ProjectID != 0 {
+		args = append(args, view.ProjectID)
+		where = "(" + where + ") AND project_id = $" + strconv.Itoa(len(args))
+	}
+	orderBy, err := filter.CompileSort(view.Sort)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if format := exportFormat(r); format != "" {
+		exportTasks(w, r, format, where, orderBy, args)
+		return
+	}
+	if listPage(w, r, repository.EachTask, where, orderBy, args) {
+		return
+	}
+	tasks, err := repository.FilterTasks(r.Context(), where, orderBy, args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(tasks)
+}
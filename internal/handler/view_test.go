@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"HL_project_management/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateViewInvalidFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"syntax", `{"name":"v","filter":"priority:high AND ("}`},
+		{"unknown field", `{"name":"v","filter":"colour:red"}`},
+		{"bad value", `{"name":"v","filter":"due>someday"}`},
+		{"bad sort", `{"name":"v","sort":"colour"}`},
+		{"no name", `{"filter":"priority:high"}`},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/me/views", strings.NewReader(tt.body))
+		r = r.WithContext(auth.WithUserID(r.Context(), 1))
+		w := httptest.NewRecorder()
+		CreateView(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d %s, want 400", tt.name, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"HL_project_management/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// @Summary Get task watchers
+// @Description Get users watching a task
+// @Tags watchers
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {array} model.User
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 404 {string} string "Task not found"
+// @Router /tasks/{id}/watchers [get]
+func GetTaskWatchers(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := repository.GetTaskByID(r.Context(), id); err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	users, err := repository.GetTaskWatchers(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(users)
+}
+
+// @Summary Watch task
+// @Description Subscribe the current user to changes of a task
+// @Tags watchers
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {string} string "Watching"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Task not found"
+// @Router /tasks/{id}/watchers [post]
+func WatchTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := repository.GetTaskByID(r.Context(), id); err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if err := repository.WatchTask(r.Context(), id, userID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode("Watching")
+}
+
+// @Summary Unwatch task
+// @Description Unsubscribe the current user from changes of a task
+// @Tags watchers
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {string} string "Not watching"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /tasks/{id}/watchers [delete]
+func UnwatchTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	if err := repository.UnwatchTask(r.Context(), id, userID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode("Not watching")
+}
+
+// @Summary Get project watchers
+// @Description Get users watching all tasks of a project
+// @Tags watchers
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {array} model.User
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 404 {string} string "Project not found"
+// @Router /projects/{id}/watchers [get]
+func GetProjectWatchers(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := repository.GetProjectByID(r.Context(), id); err != nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	users, err := repository.GetProjectWatchers(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(users)
+}
+
+// @Summary Watch project
+// @Description Subscribe the current user to changes of all tasks of a project
+// @Tags watchers
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {string} string "Watching"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Project not found"
+// @Router /projects/{id}/watchers [post]
+func WatchProject(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := repository.GetProjectByID(r.Context(), id); err != nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	if err := repository.WatchProject(r.Context(), id, userID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode("Watching")
+}
+
+// @Summary Unwatch project
+// @Description Unsubscribe the current user from changes of a project
+// @Tags watchers
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {string} string "Not watching"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /projects/{id}/watchers [delete]
+func UnwatchProject(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	if err := repository.UnwatchProject(r.Context(), id, userID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode("Not watching")
+}
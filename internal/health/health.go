@@ -0,0 +1,218 @@
+// Package health answers the liveness and readiness probes. Liveness only
+// asks whether the process is working: it fails when a background worker has
+// stalled. Readiness also checks the database and its schema, and fails as
+// soon as the server starts shutting down so that load balancers stop sending
+// it traffic.
+package health
+
+import (
+	"HL_project_management/internal/repository"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+// checkTimeout bounds the database checks of a readiness probe.
+const checkTimeout = 2 * time.Second
+
+// Component is the state of one dependency or worker.
+type Component struct {
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Report is the answer to a probe. Status is failing if any component is.
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+func (r *Report) add(name string, c Component) {
+	if r.Components == nil {
+		r.Components = map[string]Component{}
+	}
+	r.Components[name] = c
+	if c.Status != StatusOK {
+		r.Status = StatusFailing
+	}
+}
+
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+var draining atomic.Bool
+
+// Drain makes readiness fail from now on. The server calls it first thing
+// on shutdown.
+func Drain() {
+	draining.Store(true)
+}
+
+func Draining() bool {
+	return draining.Load()
+}
+
+// Live reports whether the background workers are making progress.
+func Live() Report {
+	r := Report{Status: StatusOK}
+	for name, c := range workerComponents() {
+		r.add(name, c)
+	}
+	return r
+}
+
+// Ready reports whether the server can take traffic: it is not shutting
+// down, the database answers within checkTimeout and its schema is at the
+// version the binary was built with. Workers are included for information
+// but do not affect readiness; a stalled worker fails liveness instead.
+func Ready(ctx context.Context) Report {
+	r := Report{Status: StatusOK}
+	if Draining() {
+		r.add("server", Component{Status: StatusFailing, Error: "shutting down"})
+	} else {
+		r.add("server", Component{Status: StatusOK})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	database := checkDatabase(ctx)
+	r.add("database", database)
+	if database.Status == StatusOK {
+		r.add("migrations", checkMigrations(ctx))
+	} else {
+		r.add("migrations", Component{Status: StatusFailing, Error: "database unreachable"})
+	}
+
+	for name, c := range workerComponents() {
+		r.Components[name] = c
+	}
+	return r
+}
+
+func checkDatabase(ctx context.Context) Component {
+	start := time.Now()
+	if err := repository.PingDB(ctx); err != nil {
+		return Component{Status: StatusFailing, Error: err.Error()}
+	}
+	return Component{Status: StatusOK, Details: map[string]any{
+		"latency_ms": time.Since(start).Milliseconds(),
+	}}
+}
+
+var latestMigration = sync.OnceValues(repository.LatestMigration)
+
+func checkMigrations(ctx context.Context) Component {
+	expected, err := latestMigration()
+	if err != nil {
+		return Component{Status: StatusFailing, Error: err.Error()}
+	}
+	version, dirty, err := repository.SchemaVersion(ctx)
+	if err != nil {
+		return Component{Status: StatusFailing, Error: err.Error()}
+	}
+	c := Component{Status: StatusOK, Details: map[string]any{
+		"version":  version,
+		"expected": expected,
+	}}
+	switch {
+	case dirty:
+		c.Status, c.Error = StatusFailing, fmt.Sprintf("migration %d is dirty", version)
+	case version != expected:
+		c.Status, c.Error = StatusFailing, fmt.Sprintf("schema is at version %d, expected %d", version, expected)
+	}
+	return c
+}
+
+// Worker tracks a background loop through its heartbeats.
+type Worker struct {
+	name     string
+	interval time.Duration
+
+	mu       sync.Mutex
+	started  time.Time
+	lastBeat time.Time
+	stopped  bool
+}
+
+var (
+	workersMu sync.Mutex
+	workers   = map[string]*Worker{}
+)
+
+// NewWorker registers a worker that is expected to call Beat every
+// interval. A worker with the same name replaces the previous one.
+func NewWorker(name string, interval time.Duration) *Worker {
+	w := &Worker{name: name, interval: interval, started: time.Now()}
+	workersMu.Lock()
+	workers[name] = w
+	workersMu.Unlock()
+	return w
+}
+
+// Beat records that the worker has completed a round of work.
+func (w *Worker) Beat() {
+	w.mu.Lock()
+	w.lastBeat = time.Now()
+	w.mu.Unlock()
+}
+
+// Stop records that the worker has exited. Unless the server is shutting
+// down, a stopped worker fails liveness.
+func (w *Worker) Stop() {
+	w.mu.Lock()
+	w.stopped = true
+	w.mu.Unlock()
+}
+
+// stallAfter is how many intervals may pass without a beat before a worker
+// counts as stalled.
+const stallAfter = 3
+
+func (w *Worker) component(now time.Time) Component {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c := Component{Status: StatusOK, Details: map[string]any{
+		"interval": w.interval.String(),
+	}}
+	if w.stopped {
+		c.Details["stopped"] = true
+	}
+	since := w.started
+	if !w.lastBeat.IsZero() {
+		c.Details["last_beat"] = w.lastBeat.UTC().Format(time.RFC3339)
+		since = w.lastBeat
+	}
+	switch {
+	case w.stopped && !Draining():
+		c.Status, c.Error = StatusFailing, "stopped"
+	case !w.stopped && now.Sub(since) > stallAfter*w.interval:
+		c.Status, c.Error = StatusFailing, fmt.Sprintf("no progress for %s", now.Sub(since).Round(time.Millisecond))
+	}
+	return c
+}
+
+// workerComponents returns the state of every worker by name.
+func workerComponents() map[string]Component {
+	workersMu.Lock()
+	list := make([]*Worker, 0, len(workers))
+	for _, w := range workers {
+		list = append(list, w)
+	}
+	workersMu.Unlock()
+
+	now := time.Now()
+	components := make(map[string]Component, len(list))
+	for _, w := range list {
+		components["worker:"+w.name] = w.component(now)
+	}
+	return components
+}
@@ -0,0 +1,206 @@
+// Package idempotency makes POST requests safe to retry. A client sends an
+// Idempotency-Key header; the first response for a key is stored and replayed
+// to later requests with the same key instead of handling them again.
+package idempotency
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/health"
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/repository"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	Header       = "Idempotency-Key"
+	ReplayHeader = "Idempotent-Replayed"
+
+	maxKeyLength   = 255
+	maxRequestSize = 10 << 20
+	// Larger responses are not stored; retrying such a request runs it again.
+	maxResponseSize = 1 << 20
+)
+
+type Config struct {
+	// TTL is how long a key and its response are kept.
+	TTL time.Duration
+	// LockTimeout is how long a key stays reserved by a request that has
+	// not finished, for instance because the server stopped while handling it.
+	LockTimeout time.Duration
+}
+
+var config = Config{TTL: 24 * time.Hour, LockTimeout: time.Minute}
+
+func Configure(cfg Config) {
+	config = cfg
+}
+
+// Handler wraps a handler so that requests carrying an Idempotency-Key are
+// handled at most once per key and user, or client address for anonymous
+// requests. A repeat with the same body gets the
+// stored response, one with a different body 422, and one arriving while the
+// first is still running 409. Server errors are not stored, so they can be
+// retried with the same key.
+func Handler(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "Request too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID, _ := auth.UserID(r.Context())
+		key = scopedKey(r, userID, key)
+		hash := requestHash(r, body)
+		claimed, stored, err := repository.ClaimIdempotencyKey(r.Context(), userID, key, hash, config.TTL, time.Now().Add(-config.LockTimeout))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !claimed {
+			switch {
+			case stored.RequestHash != hash:
+				http.Error(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+			case stored.Status == 0:
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+			default:
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set(ReplayHeader, "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+			}
+			return
+		}
+
+		// The outcome is recorded even if the client has gone away.
+		ctx := context.WithoutCancel(r.Context())
+		rec := &recorder{ResponseWriter: w}
+		defer func() {
+			if p := recover(); p != nil {
+				release(ctx, userID, key)
+				panic(p)
+			}
+		}()
+		next(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if rec.status >= 500 || rec.overflow {
+			release(ctx, userID, key)
+			return
+		}
+		err = repository.SaveIdempotentResponse(ctx, userID, key, repository.StoredResponse{
+			Status:      rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("idempotency: could not store response", "key", key, "error", err)
+			release(ctx, userID, key)
+		}
+	})
+}
+
+// scopedKey is the key as stored. Anonymous callers all have user 0, so
+// their keys are scoped to the client address as well, hashed to fit.
+func scopedKey(r *http.Request, userID int, key string) string {
+	if userID != 0 {
+		return key
+	}
+	sum := sha256.Sum256([]byte(auth.ClientAddr(r) + "\n" + key))
+	return "anon:" + hex.EncodeToString(sum[:])
+}
+
+// requestHash identifies a request by its method, URL and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func release(ctx context.Context, userID int, key string) {
+	if err := repository.ReleaseIdempotencyKey(ctx, userID, key); err != nil {
+		logging.FromContext(ctx).Error("idempotency: could not release key", "key", key, "error", err)
+	}
+}
+
+// Sweep deletes expired keys every interval until ctx is done.
+func Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	worker := health.NewWorker("idempotency-sweep", interval)
+	defer worker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := repository.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+				slog.Error("idempotency: could not delete expired keys", "error", err)
+			}
+			worker.Beat()
+		}
+	}
+}
+
+// recorder passes a response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if !rec.overflow && rec.body.Len()+len(p) <= maxResponseSize {
+		rec.body.Write(p)
+	} else {
+		rec.overflow = true
+		rec.body.Reset()
+	}
+	return rec.ResponseWriter.Write(p)
+}
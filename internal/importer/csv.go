@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Default column names of each field, tried in order.
+var (
+	csvColumns = map[string][]string{
+		"title":       {"title"},
+		"description": {"description"},
+		"priority":    {"priority"},
+		"status":      {"status"},
+		"assignee":    {"assignee", "assignee email"},
+		"due":         {"due", "due date", "dueAt"},
+	}
+	jiraColumns = map[string][]string{
+		"title":       {"Summary"},
+		"description": {"Description"},
+		"priority":    {"Priority"},
+		"status":      {"Status"},
+		"assignee":    {"Assignee Email", "Assignee"},
+		"due":         {"Due Date", "Due date"},
+	}
+)
+
+func parseCSV(r io.Reader, defaults map[string][]string, mapping map[string]string) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+	}
+
+	// Jira repeats some columns, such as Labels, so the first match wins.
+	index := map[string]int{}
+	for field, names := range defaults {
+		if name, ok := mapping[field]; ok {
+			names = []string{name}
+		}
+		for _, name := range names {
+			if i := findColumn(header, name); i >= 0 {
+				index[field] = i
+				break
+			}
+		}
+		if _, found := index[field]; !found && mapping[field] != "" {
+			return nil, fmt.Errorf("column %q mapped to %s is not in the file", mapping[field], field)
+		}
+	}
+	if _, ok := index["title"]; !ok {
+		return nil, errors.New("no title column found, map one with the mapping parameter")
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		get := func(field string) string {
+			i, ok := index[field]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return row[i]
+		}
+		records = append(records, Record{
+			Line:        line,
+			Title:       get("title"),
+			Description: get("description"),
+			Priority:    get("priority"),
+			Status:      get("status"),
+			Assignee:    get("assignee"),
+			Due:         get("due"),
+		})
+	}
+	return records, nil
+}
+
+func findColumn(header []string, name string) int {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return i
+		}
+	}
+	return -1
+}
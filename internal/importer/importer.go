@@ -0,0 +1,145 @@
+// Package importer reads task backlogs exported from other tools: plain CSV,
+// Jira CSV exports and Trello JSON exports.
+package importer
+
+import (
+	"HL_project_management/internal/model"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJira   = "jira"
+	FormatTrello = "trello"
+)
+
+// Fields that can be imported, used as keys of a column mapping.
+var Fields = []string{"title", "description", "priority", "status", "assignee", "due"}
+
+// Record is one task as found in the source, before validation.
+type Record struct {
+	// Line is the line of a CSV row or the position of a Trello card.
+	Line        int
+	Title       string
+	Description string
+	Priority    string
+	Status      string
+	// Assignee is an email address or, when the source has none, a username.
+	Assignee string
+	Due      string
+}
+
+// Parse reads records in the given format. mapping overrides which CSV column
+// holds each field and is ignored for Trello.
+func Parse(format string, r io.Reader, mapping map[string]string) ([]Record, error) {
+	for field := range mapping {
+		if !known(field) {
+			return nil, fmt.Errorf("unknown field %q in mapping, expected one of %s", field, strings.Join(Fields, ", "))
+		}
+	}
+	switch format {
+	case FormatCSV:
+		return parseCSV(r, csvColumns, mapping)
+	case FormatJira:
+		return parseCSV(r, jiraColumns, mapping)
+	case FormatTrello:
+		return parseTrello(r)
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected csv, jira or trello", format)
+	}
+}
+
+func known(field string) bool {
+	for _, f := range Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Task converts the record into a task of the project. Assignees are looked
+// up with resolve; defaultAssignee is used for records without one. Problems
+// are returned as messages instead of failing, so all of them can be reported.
+func (rec Record) Task(projectID, defaultAssignee int, resolve func(string) (int, bool)) (model.Task, []string) {
+	var problems []string
+	task := model.Task{
+		Title:       strings.TrimSpace(rec.Title),
+		Description: strings.TrimSpace(rec.Description),
+		Priority:    normalizePriority(rec.Priority),
+		Status:      normalizeStatus(rec.Status),
+		ProjectID:   projectID,
+		AssigneeID:  defaultAssignee,
+		CreatedAt:   time.Now(),
+	}
+	if assignee := strings.TrimSpace(rec.Assignee); assignee != "" {
+		id, ok := resolve(assignee)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("no user matches assignee %q", assignee))
+		}
+		task.AssigneeID = id
+	}
+	if due := strings.TrimSpace(rec.Due); due != "" {
+		at, err := parseDate(due)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("cannot read due date %q", due))
+		}
+		task.DueAt = at
+	}
+	return task, problems
+}
+
+// normalizePriority maps priorities of other tools, such as Jira's Highest
+// and Lowest, onto low, medium and high. Unknown values are kept so that
+// validation reports them.
+func normalizePriority(p string) string {
+	switch p = strings.ToLower(strings.TrimSpace(p)); p {
+	case "":
+		return "medium"
+	case "highest", "critical", "blocker", "urgent":
+		return "high"
+	case "lowest", "trivial", "minor":
+		return "low"
+	case "major", "normal":
+		return "medium"
+	}
+	return p
+}
+
+// normalizeStatus maps common workflow states onto new, in_progress and
+// done, and otherwise turns the status into snake case.
+func normalizeStatus(s string) string {
+	s = strings.Join(strings.Fields(strings.ToLower(s)), "_")
+	switch s {
+	case "", "to_do", "todo", "open", "backlog", "new":
+		return "new"
+	case "doing", "in_progress", "in_review", "review":
+		return "in_progress"
+	case "done", "closed", "resolved", "complete", "completed":
+		return model.TaskStatusDone
+	}
+	return s
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"02/Jan/06 3:04 PM", // Jira
+	"02/Jan/06",
+	"02.01.2006",
+	"1/2/2006",
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if at, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return at, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", value)
+}
@@ -0,0 +1,255 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		mapping map[string]string
+		want    []Record
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			input: "title,description,priority,status,assignee,due\n" +
+				"Fix login,Users cannot log in,high,In Progress,ann@example.com,2024-03-12\n" +
+				"Write docs,,,,,\n",
+			want: []Record{
+				{Line: 2, Title: "Fix login", Description: "Users cannot log in", Priority: "high", Status: "In Progress", Assignee: "ann@example.com", Due: "2024-03-12"},
+				{Line: 3, Title: "Write docs"},
+			},
+		},
+		{
+			name:   "header case, spaces, byte order mark and column order",
+			format: FormatCSV,
+			input:  "\uFEFFDue Date, Title ,Assignee Email\n2024-01-01,Plan,bob@example.com\n",
+			want:   []Record{{Line: 2, Title: "Plan", Assignee: "bob@example.com", Due: "2024-01-01"}},
+		},
+		{
+			name:   "long and multi-line values",
+			format: FormatCSV,
+			input: "title,description\n" +
+				"\"" + strings.Repeat("Long title ", 20) + "\",\"first line\nsecond line, with a comma\"\n" +
+				"Next,\"say \"\"hi\"\"\"\n",
+			want: []Record{
+				{Line: 2, Title: strings.Repeat("Long title ", 20), Description: "first line\nsecond line, with a comma"},
+				{Line: 4, Title: "Next", Description: `say "hi"`},
+			},
+		},
+		{
+			name:   "short rows",
+			format: FormatCSV,
+			input:  "title,description,status\nOnly title\n",
+			want:   []Record{{Line: 2, Title: "Only title"}},
+		},
+		{
+			name:    "mapping",
+			format:  FormatCSV,
+			input:   "Name,Notes,title\nTask A,Some notes,ignored\n",
+			mapping: map[string]string{"title": "Name", "description": "notes"},
+			want:    []Record{{Line: 2, Title: "Task A", Description: "Some notes"}},
+		},
+		{
+			name:   "jira",
+			format: FormatJira,
+			input: "Issue key,Summary,Description,Priority,Status,Assignee,Assignee Email,Due Date,Labels,Labels\n" +
+				"PM-1,Fix login,\"Steps:\n1. Open the page\",Highest,In Review,ann,ann@example.com,12/Mar/24 5:00 PM,auth,web\n" +
+				"PM-2,Tidy up,,Lowest,To Do,,,,,\n",
+			want: []Record{
+				{Line: 2, Title: "Fix login", Description: "Steps:\n1. Open the page", Priority: "Highest", Status: "In Review", Assignee: "ann@example.com", Due: "12/Mar/24 5:00 PM"},
+				{Line: 4, Title: "Tidy up", Priority: "Lowest", Status: "To Do"},
+			},
+		},
+		{
+			name:   "jira without assignee emails",
+			format: FormatJira,
+			input:  "Summary,Assignee\nFix login,ann\n",
+			want:   []Record{{Line: 2, Title: "Fix login", Assignee: "ann"}},
+		},
+		{
+			name:   "header only",
+			format: FormatCSV,
+			input:  "title\n",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.format, strings.NewReader(tt.input), tt.mapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		mapping map[string]string
+		want    string
+	}{
+		{"unknown format", "xml", "", nil, `unknown format "xml", expected csv, jira or trello`},
+		{"unknown field", FormatCSV, "title\n", map[string]string{"owner": "Owner"}, `unknown field "owner" in mapping, expected one of title, description, priority, status, assignee, due`},
+		{"empty file", FormatCSV, "", nil, "the file is empty"},
+		{"no title", FormatCSV, "name,description\nA,B\n", nil, "no title column found, map one with the mapping parameter"},
+		{"jira without summary", FormatJira, "Title\nA\n", nil, "no title column found, map one with the mapping parameter"},
+		{"mapped column missing", FormatCSV, "title\nA\n", map[string]string{"due": "Deadline"}, `column "Deadline" mapped to due is not in the file`},
+		{"bad quoting", FormatCSV, "title\n\"unterminated\n", nil, `extraneous or missing " in quoted-field`},
+		{"trello not json", FormatTrello, "title\nA\n", nil, "invalid Trello export: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.format, strings.NewReader(tt.input), tt.mapping)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrello(t *testing.T) {
+	const board = `{
+		"lists": [{"id": "l1", "name": "To Do"}, {"id": "l2", "name": "Doing"}, {"id": "l3", "name": "Done"}],
+		"members": [{"id": "m1", "username": "ann"}, {"id": "m2", "username": "bob"}],
+		"cards": [
+			{"name": "Fix login", "desc": "Users cannot log in", "idList": "l2", "idMembers": ["m2", "m1"],
+			 "labels": [{"name": "bug"}, {"name": "High"}], "due": "2024-03-12T17:00:00.000Z"},
+			{"name": "Archived", "idList": "l1", "closed": true},
+			{"name": "Write docs", "idList": "l3", "labels": [{"name": "low"}]},
+			{"name": "Orphan", "idList": "gone", "idMembers": ["m9"]}
+		],
+		"actions": [{"type": "createCard"}]
+	}`
+	got, err := Parse(FormatTrello, strings.NewReader(board), map[string]string{"title": "ignored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Record{
+		{Line: 1, Title: "Fix login", Description: "Users cannot log in", Priority: "high", Status: "Doing", Assignee: "bob", Due: "2024-03-12T17:00:00.000Z"},
+		{Line: 3, Title: "Write docs", Priority: "low", Status: "Done"},
+		{Line: 4, Title: "Orphan"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestRecordTask(t *testing.T) {
+	users := map[string]int{"ann@example.com": 1, "bob": 2}
+	resolve := func(name string) (int, bool) {
+		id, ok := users[name]
+		return id, ok
+	}
+	tests := []struct {
+		name     string
+		rec      Record
+		assignee int
+		priority string
+		status   string
+		due      time.Time
+		problems []string
+	}{
+		{
+			name:     "defaults",
+			rec:      Record{Title: "  Plan  "},
+			assignee: 9, priority: "medium", status: "new",
+		},
+		{
+			name:     "jira values",
+			rec:      Record{Title: "Fix", Priority: "Highest", Status: "In Review", Assignee: "ann@example.com", Due: "12/Mar/24 5:00 PM"},
+			assignee: 1, priority: "high", status: "in_progress",
+			due: time.Date(2024, 3, 12, 17, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "unknown values are kept for validation",
+			rec:      Record{Title: "Fix", Priority: "Someday", Status: "Waiting For Customer", Assignee: "bob", Due: "2024-03-12"},
+			assignee: 2, priority: "someday", status: "waiting_for_customer",
+			due: time.Date(2024, 3, 12, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "problems",
+			rec:      Record{Title: "Fix", Assignee: "carol", Due: "next week"},
+			assignee: 0, priority: "medium", status: "new",
+			problems: []string{`no user matches assignee "carol"`, `cannot read due date "next week"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, problems := tt.rec.Task(5, 9, resolve)
+			if task.Title != strings.TrimSpace(tt.rec.Title) || task.ProjectID != 5 || task.CreatedAt.IsZero() {
+				t.Errorf("task = %+v", task)
+			}
+			if task.AssigneeID != tt.assignee || task.Priority != tt.priority || task.Status != tt.status || !task.DueAt.Equal(tt.due) {
+				t.Errorf("task = assignee %d, %s, %s, due %v; want %d, %s, %s, %v",
+					task.AssigneeID, task.Priority, task.Status, task.DueAt, tt.assignee, tt.priority, tt.status, tt.due)
+			}
+			if !reflect.DeepEqual(problems, tt.problems) {
+				t.Errorf("problems = %q, want %q", problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	priorities := map[string]string{
+		"": "medium", "High": "high", " LOW ": "low", "Blocker": "high", "Critical": "high",
+		"Trivial": "low", "Minor": "low", "Major": "medium", "Normal": "medium", "p1": "p1",
+	}
+	for in, want := range priorities {
+		if got := normalizePriority(in); got != want {
+			t.Errorf("normalizePriority(%q) = %q, want %q", in, got, want)
+		}
+	}
+	statuses := map[string]string{
+		"": "new", "To Do": "new", "Backlog": "new", "OPEN": "new",
+		"In Progress": "in_progress", "in   review": "in_progress", "Doing": "in_progress",
+		"Done": "done", "Resolved": "done", "Closed": "done",
+		"Won't Fix": "won't_fix",
+	}
+	for in, want := range statuses {
+		if got := normalizeStatus(in); got != want {
+			t.Errorf("normalizeStatus(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-03-12T17:00:00Z", time.Date(2024, 3, 12, 17, 0, 0, 0, time.UTC)},
+		{"2024-03-12T17:00:00.000Z", time.Date(2024, 3, 12, 17, 0, 0, 0, time.UTC)},
+		{"2024-03-12", time.Date(2024, 3, 12, 0, 0, 0, 0, time.Local)},
+		{"2024-03-12 09:30", time.Date(2024, 3, 12, 9, 30, 0, 0, time.Local)},
+		{"2024-03-12 09:30:15", time.Date(2024, 3, 12, 9, 30, 15, 0, time.Local)},
+		{"12/Mar/24 5:00 PM", time.Date(2024, 3, 12, 17, 0, 0, 0, time.Local)},
+		{"12/Mar/24", time.Date(2024, 3, 12, 0, 0, 0, 0, time.Local)},
+		{"12.03.2024", time.Date(2024, 3, 12, 0, 0, 0, 0, time.Local)},
+		{"3/12/2024", time.Date(2024, 3, 12, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.in)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"tomorrow", "2024-13-01", "12/03/24", "32.01.2024"} {
+		if _, err := parseDate(in); err == nil {
+			t.Errorf("parseDate(%q) succeeded", in)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// trelloBoard is the part of a Trello board export that is imported.
+type trelloBoard struct {
+	Lists []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"lists"`
+	Members []struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"members"`
+	Cards []struct {
+		Name      string   `json:"name"`
+		Desc      string   `json:"desc"`
+		Due       string   `json:"due"`
+		Closed    bool     `json:"closed"`
+		IDList    string   `json:"idList"`
+		IDMembers []string `json:"idMembers"`
+		Labels    []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	} `json:"cards"`
+}
+
+// parseTrello reads the cards of a board export. The list a card is in
+// becomes its status, a label named low, medium or high its priority and its
+// first member its assignee. Archived cards are skipped.
+func parseTrello(r io.Reader) ([]Record, error) {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return nil, fmt.Errorf("invalid Trello export: %w", err)
+	}
+	lists := map[string]string{}
+	for _, l := range board.Lists {
+		lists[l.ID] = l.Name
+	}
+	members := map[string]string{}
+	for _, m := range board.Members {
+		members[m.ID] = m.Username
+	}
+
+	var records []Record
+	for i, card := range board.Cards {
+		if card.Closed {
+			continue
+		}
+		rec := Record{
+			Line:        i + 1,
+			Title:       card.Name,
+			Description: card.Desc,
+			Status:      lists[card.IDList],
+			Due:         card.Due,
+		}
+		for _, label := range card.Labels {
+			switch p := strings.ToLower(label.Name); p {
+			case "low", "medium", "high":
+				rec.Priority = p
+			}
+		}
+		if len(card.IDMembers) > 0 {
+			rec.Assignee = members[card.IDMembers[0]]
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
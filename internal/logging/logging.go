@@ -0,0 +1,91 @@
+// Package logging sets up structured logging with log/slog and gives every
+// request a logger of its own, tagged with the request ID, that handlers and
+// repository code take from the context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// level is the minimum level logged; it can be changed while running.
+var level = new(slog.LevelVar)
+
+// Setup makes a JSON or text logger writing to w the default, for log/slog
+// and for the standard log package alike.
+func Setup(w io.Writer, format, lvl string) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	switch format {
+	case "json", "":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+	slog.SetDefault(slog.New(h))
+	// Messages of the standard log package are logged at info level; the
+	// handler adds its own timestamp.
+	log.SetFlags(0)
+	return nil
+}
+
+// Level returns the current minimum level, such as "info".
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// SetLevel changes the minimum level: debug, info, warn or error.
+func SetLevel(lvl string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(lvl)); err != nil {
+		return fmt.Errorf("unknown log level %q", lvl)
+	}
+	level.Set(l)
+	return nil
+}
+
+type contextKey struct{}
+
+// entry is the logger of a request. It is shared by pointer so that
+// attributes added further down, such as the user ID, also appear in the
+// access log written at the end.
+type entry struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// NewContext returns a context carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &entry{logger: logger})
+}
+
+// FromContext returns the logger of the request ctx belongs to, or the
+// default logger outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if e, ok := ctx.Value(contextKey{}).(*entry); ok {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.logger
+	}
+	return slog.Default()
+}
+
+// AddAttrs adds attributes to the logger of the request ctx belongs to. It
+// does nothing outside of a request.
+func AddAttrs(ctx context.Context, args ...any) {
+	if e, ok := ctx.Value(contextKey{}).(*entry); ok {
+		e.mu.Lock()
+		e.logger = e.logger.With(args...)
+		e.mu.Unlock()
+	}
+}
@@ -0,0 +1,41 @@
+// Package mention finds @username mentions in free text.
+package mention
+
+import (
+	"regexp"
+	"strings"
+)
+
+var pattern = regexp.MustCompile(`(?:^|[^\w.@])@([A-Za-z0-9_][A-Za-z0-9_.\-]*)`)
+
+// Parse returns the distinct lower-cased handles mentioned in text, in order
+// of first appearance. Email addresses are not treated as mentions.
+func Parse(text string) []string {
+	var handles []string
+	seen := map[string]bool{}
+	for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+		handle := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		if handle == "" || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// New returns the handles mentioned in text that were not already mentioned
+// in previous.
+func New(previous, text string) []string {
+	old := map[string]bool{}
+	for _, h := range Parse(previous) {
+		old[h] = true
+	}
+	var handles []string
+	for _, h := range Parse(text) {
+		if !old[h] {
+			handles = append(handles, h)
+		}
+	}
+	return handles
+}
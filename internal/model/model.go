@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	ID             int       `json:"id" readonly:"true"`
@@ -20,8 +23,12 @@ type Task struct {
 	ProjectID   int       `json:"projectId" validate:"required" example:"1"`
 	CreatedAt   time.Time `json:"createdAt" readonly:"true"`
 	CompletedAt time.Time `json:"completedAt" example:"2024-09-20T15:04:05Z"`
+	DueAt       time.Time `json:"dueAt" example:"2024-09-20T15:04:05Z"`
 }
 
+// TaskStatusDone marks a task as finished; done tasks never become overdue.
+const TaskStatusDone = "done"
+
 type Project struct {
 	ID          int       `json:"id" readonly:"true"`
 	Title       string    `json:"title" validate:"required"`
@@ -30,3 +37,115 @@ type Project struct {
 	EndDate     time.Time `json:"endDate" example:"2024-09-20T15:04:05Z"`
 	ManagerID   int       `json:"managerId" validate:"required" example:"1"`
 }
+
+type Notification struct {
+	ID        int        `json:"id" readonly:"true"`
+	UserID    int        `json:"userId"`
+	Type      string     `json:"type"`
+	TaskID    int        `json:"taskId,omitempty"`
+	Message   string     `json:"message"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+}
+
+type Comment struct {
+	ID        int       `json:"id" readonly:"true"`
+	TaskID    int       `json:"taskId" readonly:"true"`
+	AuthorID  int       `json:"authorId" readonly:"true"`
+	Body      string    `json:"body" validate:"required"`
+	CreatedAt time.Time `json:"createdAt" readonly:"true"`
+}
+
+type Attachment struct {
+	ID          int       `json:"id"`
+	TaskID      int       `json:"taskId"`
+	UploaderID  int       `json:"uploaderId,omitempty"`
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum" example:"hex-encoded SHA-256 of the content"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type SearchResult struct {
+	Type  string `json:"type" example:"task"`
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	// Snippet is an HTML excerpt of the matching text: the text is escaped
+	// and matches are wrapped in <mark></mark>.
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+	// TaskID is the task a matching comment belongs to.
+	TaskID int `json:"taskId,omitempty"`
+}
+
+// SavedView is a named task filter. Shared views are visible to all members
+// of their project.
+type SavedView struct {
+	ID        int    `json:"id" readonly:"true"`
+	OwnerID   int    `json:"ownerId" readonly:"true"`
+	ProjectID int    `json:"projectId,omitempty" example:"1"`
+	Name      string `json:"name" validate:"required,max=100"`
+	Filter    string `json:"filter" example:"priority:high AND assignee:me"`
+	// Sort lists fields to order by, descending if prefixed with "-".
+	Sort      string    `json:"sort" validate:"max=100" example:"-priority,due"`
+	Shared    bool      `json:"shared"`
+	CreatedAt time.Time `json:"createdAt" readonly:"true"`
+	UpdatedAt time.Time `json:"updatedAt" readonly:"true"`
+}
+
+// ImportRowError lists what is wrong with one row of an imported file.
+type ImportRowError struct {
+	Line     int      `json:"line"`
+	Messages []string `json:"messages"`
+}
+
+type ImportReport struct {
+	DryRun   bool             `json:"dryRun"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+	Tasks    []Task           `json:"tasks,omitempty"`
+}
+
+// BatchOperation is one create, update or delete in a batch request. Data
+// holds the entity for create and update.
+type BatchOperation struct {
+	Op   string          `json:"op" validate:"required,oneof=create update delete" example:"create"`
+	ID   int             `json:"id,omitempty" example:"1"`
+	Data json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+// BatchResult reports the outcome of one operation with an HTTP status code.
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status int    `json:"status" example:"201"`
+	ID     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Data   any    `json:"data,omitempty" swaggertype:"object"`
+}
+
+type BatchResponse struct {
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// APIKey lets scripts and CI jobs act as its owner, limited to its scopes.
+// Only a hash of the key is stored; the key itself is returned once, when
+// it is created.
+type APIKey struct {
+	ID     int      `json:"id" readonly:"true"`
+	UserID int      `json:"userId" readonly:"true"`
+	Name   string   `json:"name" validate:"required,max=100" example:"CI"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=tasks:read tasks:write projects:read projects:write projects:admin users:read users:write" example:"tasks:read,tasks:write"`
+	// Prefix is the start of the key, enough to tell keys apart.
+	Prefix     string     `json:"prefix" readonly:"true" example:"pm_1a2b3c4d"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" example:"2025-09-20T15:04:05Z"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" readonly:"true"`
+	CreatedAt  time.Time  `json:"createdAt" readonly:"true"`
+	// Key is the key itself, set only in the response to creating it.
+	Key string `json:"key,omitempty" readonly:"true"`
+}
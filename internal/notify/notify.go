@@ -0,0 +1,110 @@
+// Package notify turns task events into in-app notifications for assignees,
+// mentioned users and watchers.
+package notify
+
+import (
+	"HL_project_management/internal/events"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	NotificationAssigned  = "task_assigned"
+	NotificationMentioned = "task_mentioned"
+	NotificationCreated   = "task_created"
+	NotificationUpdated   = "task_updated"
+	NotificationCommented = "task_commented"
+)
+
+var tracer = otel.Tracer("HL_project_management/internal/notify")
+
+// inflight counts events still being turned into notifications.
+var inflight sync.WaitGroup
+
+// Register subscribes the notifier to the event bus.
+func Register() (unsubscribe func()) {
+	return events.Subscribe(func(e events.Event) {
+		inflight.Add(1)
+		go func() {
+			defer inflight.Done()
+			handle(e)
+		}()
+	})
+}
+
+// Wait blocks until the events received so far have been handled. Call it
+// after unsubscribing and before closing the database.
+func Wait() {
+	inflight.Wait()
+}
+
+func handle(e events.Event) {
+	var kind, change, mentioned string
+	switch e.Type {
+	case events.TaskCreated:
+		kind, change, mentioned = NotificationCreated, "New task %q", "You were mentioned in task %q"
+	case events.TaskUpdated:
+		kind, change, mentioned = NotificationUpdated, "Task %q was updated", "You were mentioned in task %q"
+	case events.TaskCommented:
+		kind, change, mentioned = NotificationCommented, "New comment on task %q", "You were mentioned in a comment on task %q"
+	default:
+		return
+	}
+	// Events are handled after the request that caused them has finished,
+	// so each gets a trace of its own.
+	ctx, span := tracer.Start(context.Background(), "notify."+string(e.Type))
+	defer span.End()
+
+	task, err := repository.GetTaskByID(ctx, e.TaskID)
+	if err != nil {
+		slog.ErrorContext(ctx, "notify: could not load task", "task_id", e.TaskID, "error", err)
+		return
+	}
+
+	// Everyone gets at most one notification per event, the most specific
+	// one, and nobody is notified about their own changes.
+	notified := map[int]bool{e.ActorID: true}
+	if e.UserID != 0 && !notified[e.UserID] {
+		send(ctx, e.UserID, NotificationAssigned, task.ID, fmt.Sprintf("You were assigned to task %q", task.Title))
+	}
+	notified[e.UserID] = true
+	for _, id := range e.Mentioned {
+		if notified[id] {
+			continue
+		}
+		notified[id] = true
+		send(ctx, id, NotificationMentioned, task.ID, fmt.Sprintf(mentioned, task.Title))
+	}
+
+	watchers, err := repository.GetTaskWatcherIDs(ctx, task.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "notify: could not load watchers", "task_id", task.ID, "error", err)
+		return
+	}
+	for _, id := range watchers {
+		if notified[id] {
+			continue
+		}
+		send(ctx, id, kind, task.ID, fmt.Sprintf(change, task.Title))
+	}
+}
+
+func send(ctx context.Context, userID int, kind string, taskID int, message string) {
+	_, err := repository.CreateNotification(ctx, model.Notification{
+		UserID:    userID,
+		Type:      kind,
+		TaskID:    taskID,
+		Message:   message,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "notify: could not notify user", "user_id", userID, "error", err)
+	}
+}
@@ -0,0 +1,261 @@
+// Package oidc logs users in through an OpenID Connect identity provider
+// with the authorization code flow and PKCE. The provider is discovered on
+// first use; its signing keys are fetched from its JWKS endpoint and fetched
+// again whenever a token is signed with a key not seen before, so keys can
+// be rotated without restarting the service.
+package oidc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	cookieName = "pm_oidc"
+	cookiePath = "/auth/oidc"
+	// loginTimeout is how long the user has to log in at the provider.
+	loginTimeout = 10 * time.Minute
+)
+
+// ErrLogin is returned for logins that fail because of the user or the
+// browser, such as a stale or forged callback, rather than because of the
+// service or the provider.
+var ErrLogin = errors.New("login failed")
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupsClaim  string
+	// GroupRoles gives the role of users by group, the first match winning.
+	GroupRoles []GroupRole
+	// Secret is the key of access tokens. The cookie that carries the login
+	// state between the start of the login and the callback is signed with
+	// a key derived from it, so that neither can pass for the other.
+	Secret []byte
+}
+
+var (
+	config   Config
+	stateKey []byte
+
+	mu       sync.Mutex
+	provider *gooidc.Provider
+)
+
+func Configure(cfg Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	config = cfg
+	stateKey = deriveKey(cfg.Secret, "oidc-state")
+	provider = nil
+}
+
+// deriveKey returns a key for purpose derived from secret.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// GroupRole gives members of Group the role Role.
+type GroupRole struct {
+	Group string
+	Role  string
+}
+
+// Role returns the role the first of config.GroupRoles matching one of
+// groups gives, or "" if none does.
+func Role(groups []string) string {
+	for _, gr := range config.GroupRoles {
+		for _, g := range groups {
+			if g == gr.Group {
+				return gr.Role
+			}
+		}
+	}
+	return ""
+}
+
+// Enabled reports whether a provider is configured.
+func Enabled() bool {
+	return config.Issuer != ""
+}
+
+// getProvider discovers the provider, retrying on every login until it
+// succeeds, so that an unreachable provider does not stop the service.
+func getProvider() (*gooidc.Provider, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if provider != nil {
+		return provider, nil
+	}
+	// The provider keeps this context to fetch keys later on.
+	ctx := gooidc.ClientContext(context.Background(), &http.Client{Timeout: 10 * time.Second})
+	p, err := gooidc.NewProvider(ctx, config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: could not discover %s: %w", config.Issuer, err)
+	}
+	provider = p
+	return p, nil
+}
+
+func oauth2Config(p *gooidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		Endpoint:     p.Endpoint(),
+		RedirectURL:  config.RedirectURL,
+		Scopes:       config.Scopes,
+	}
+}
+
+// state is what the callback needs to know about the login it finishes.
+type state struct {
+	jwt.RegisteredClaims
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// Begin starts a login. It stores the login state in a short-lived cookie
+// and returns the provider URL to send the browser to.
+func Begin(w http.ResponseWriter, r *http.Request) (string, error) {
+	p, err := getProvider()
+	if err != nil {
+		return "", err
+	}
+	s := state{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(loginTimeout))},
+		State:            randomString(),
+		Nonce:            randomString(),
+		Verifier:         oauth2.GenerateVerifier(),
+	}
+	cookie, err := jwt.NewWithClaims(jwt.SigningMethodHS256, s).SignedString(stateKey)
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieName,
+		Value:    cookie,
+		Path:     cookiePath,
+		MaxAge:   int(loginTimeout.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(config.RedirectURL, "https:"),
+		// Lax lets the cookie through on the redirect back from the provider.
+		SameSite: http.SameSiteLaxMode,
+	})
+	return oauth2Config(p).AuthCodeURL(s.State, gooidc.Nonce(s.Nonce), oauth2.S256ChallengeOption(s.Verifier)), nil
+}
+
+// Identity is the user as described by the provider.
+type Identity struct {
+	Subject string
+	Email   string
+	Name    string
+	Groups  []string
+}
+
+// Finish completes the login started by Begin from the provider's redirect
+// to the callback: it checks the state, exchanges the code for tokens and
+// verifies the ID token. Errors wrapping ErrLogin are the user's.
+func Finish(w http.ResponseWriter, r *http.Request) (Identity, error) {
+	http.SetCookie(w, &http.Cookie{Name: cookieName, Path: cookiePath, MaxAge: -1, HttpOnly: true})
+
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		return Identity{}, fmt.Errorf("%w: %s %s", ErrLogin, e, q.Get("error_description"))
+	}
+	cookie, err := r.Cookie(cookieName)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: no login in progress", ErrLogin)
+	}
+	var s state
+	_, err = jwt.ParseWithClaims(cookie.Value, &s, func(*jwt.Token) (any, error) {
+		return stateKey, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrLogin, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(s.State)) != 1 {
+		return Identity{}, fmt.Errorf("%w: state does not match", ErrLogin)
+	}
+
+	p, err := getProvider()
+	if err != nil {
+		return Identity{}, err
+	}
+	token, err := oauth2Config(p).Exchange(r.Context(), q.Get("code"), oauth2.VerifierOption(s.Verifier))
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			return Identity{}, fmt.Errorf("%w: %v", ErrLogin, err)
+		}
+		return Identity{}, fmt.Errorf("oidc: could not exchange code: %w", err)
+	}
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, errors.New("oidc: no ID token in token response")
+	}
+	idToken, err := p.Verifier(&gooidc.Config{ClientID: config.ClientID}).Verify(r.Context(), raw)
+	if err != nil {
+		return Identity{}, fmt.Errorf("oidc: invalid ID token: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(s.Nonce)) != 1 {
+		return Identity{}, fmt.Errorf("%w: nonce does not match", ErrLogin)
+	}
+	return identity(idToken)
+}
+
+func identity(idToken *gooidc.IDToken) (Identity, error) {
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, err
+	}
+	id := Identity{Subject: idToken.Subject}
+	id.Email, _ = claims["email"].(string)
+	if id.Email == "" {
+		return Identity{}, fmt.Errorf("%w: the provider did not share an email address", ErrLogin)
+	}
+	// Some providers send the flag as a string.
+	if verified, ok := claims["email_verified"]; ok && verified != true && verified != "true" {
+		return Identity{}, fmt.Errorf("%w: email address %s is not verified", ErrLogin, id.Email)
+	}
+	id.Name, _ = claims["name"].(string)
+	if id.Name == "" {
+		id.Name, _ = claims["preferred_username"].(string)
+	}
+	switch groups := claims[config.GroupsClaim].(type) {
+	case string:
+		id.Groups = []string{groups}
+	case []any:
+		for _, g := range groups {
+			if g, ok := g.(string); ok {
+				id.Groups = append(id.Groups, g)
+			}
+		}
+	}
+	return id, nil
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,231 @@
+package oidc
+
+import (
+	"HL_project_management/internal/oidc/oidctest"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	testClientID = "pm"
+	testSecret   = "secret"
+)
+
+func setup(t *testing.T) *oidctest.Provider {
+	t.Helper()
+	p := oidctest.NewProvider(testClientID)
+	t.Cleanup(p.Close)
+	Configure(Config{
+		Issuer:       p.Issuer(),
+		ClientID:     testClientID,
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://pm.test/auth/oidc/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+		GroupsClaim:  "groups",
+		GroupRoles:   []GroupRole{{Group: "admins", Role: "admin"}, {Group: "staff", Role: "member"}},
+		Secret:       []byte(testSecret),
+	})
+	t.Cleanup(func() { Configure(Config{}) })
+	return p
+}
+
+// begin starts a login and returns the provider URL and the state cookie.
+func begin(t *testing.T) (string, *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	target, err := Begin(w, httptest.NewRequest("GET", "/auth/oidc/login", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != cookieName {
+		t.Fatalf("cookies = %v, want the state cookie", cookies)
+	}
+	return target, cookies[0]
+}
+
+// authorize logs in at the provider and returns the callback it redirects to.
+func authorize(t *testing.T, target string) *url.URL {
+	t.Helper()
+	c := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := c.Get(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := resp.Location()
+	if err != nil {
+		t.Fatalf("provider answered %s: %v", resp.Status, err)
+	}
+	return callback
+}
+
+func finish(callback *url.URL, cookie *http.Cookie) (Identity, error) {
+	r := httptest.NewRequest("GET", callback.String(), nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	return Finish(httptest.NewRecorder(), r)
+}
+
+func login(t *testing.T) (Identity, error) {
+	t.Helper()
+	target, cookie := begin(t)
+	return finish(authorize(t, target), cookie)
+}
+
+// resign returns cookie with its state changed by change and signed with key.
+func resign(t *testing.T, cookie *http.Cookie, key []byte, change func(*state)) *http.Cookie {
+	t.Helper()
+	var s state
+	if _, err := jwt.ParseWithClaims(cookie.Value, &s, func(*jwt.Token) (any, error) { return stateKey, nil }); err != nil {
+		t.Fatal(err)
+	}
+	change(&s)
+	value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, s).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Cookie{Name: cookie.Name, Value: value}
+}
+
+func TestLogin(t *testing.T) {
+	setup(t)
+	target, cookie := begin(t)
+
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		t.Errorf("authorization URL %s lacks an S256 code challenge", target)
+	}
+	if q.Has("code_verifier") || q.Get("state") == "" || q.Get("nonce") == "" {
+		t.Errorf("authorization URL %s: want state and nonce but not the verifier", target)
+	}
+
+	id, err := finish(authorize(t, target), cookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Identity{Subject: "1", Email: "user@example.com", Name: "Test User"}
+	if !reflect.DeepEqual(id, want) {
+		t.Errorf("identity = %+v, want %+v", id, want)
+	}
+}
+
+func TestLoginRejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		forge func(t *testing.T, callback *url.URL, cookie *http.Cookie) (*url.URL, *http.Cookie)
+	}{
+		{"no cookie", func(t *testing.T, callback *url.URL, cookie *http.Cookie) (*url.URL, *http.Cookie) {
+			return callback, nil
+		}},
+		{"state mismatch", func(t *testing.T, callback *url.URL, cookie *http.Cookie) (*url.URL, *http.Cookie) {
+			q := callback.Query()
+			q.Set("state", "other")
+			callback.RawQuery = q.Encode()
+			return callback, cookie
+		}},
+		{"nonce mismatch", func(t *testing.T, callback *url.URL, cookie *http.Cookie) (*url.URL, *http.Cookie) {
+			return callback, resign(t, cookie, stateKey, func(s *state) { s.Nonce = "other" })
+		}},
+		{"wrong PKCE verifier", func(t *testing.T, callback *url.URL, cookie *http.Cookie) (*url.URL, *http.Cookie) {
+			return callback, resign(t, cookie, stateKey, func(s *state) { s.Verifier = oauth2.GenerateVerifier() })
+		}},
+		{"signed with the token secret", func(t *testing.T, callback *url.URL, cookie *http.Cookie) (*url.URL, *http.Cookie) {
+			return callback, resign(t, cookie, []byte(testSecret), func(*state) {})
+		}},
+		{"denied at the provider", func(t *testing.T, callback *url.URL, cookie *http.Cookie) (*url.URL, *http.Cookie) {
+			callback.RawQuery = url.Values{"error": {"access_denied"}, "state": {callback.Query().Get("state")}}.Encode()
+			return callback, cookie
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup(t)
+			target, cookie := begin(t)
+			callback, cookie := tt.forge(t, authorize(t, target), cookie)
+			if _, err := finish(callback, cookie); !errors.Is(err, ErrLogin) {
+				t.Errorf("Finish = %v, want ErrLogin", err)
+			}
+		})
+	}
+}
+
+func TestLoginCodeUsedOnce(t *testing.T) {
+	setup(t)
+	target, cookie := begin(t)
+	callback := authorize(t, target)
+	if _, err := finish(callback, cookie); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := finish(callback, cookie); !errors.Is(err, ErrLogin) {
+		t.Errorf("replayed callback: %v, want ErrLogin", err)
+	}
+}
+
+func TestUnverifiedEmail(t *testing.T) {
+	p := setup(t)
+	p.SetUser(oidctest.User{Subject: "2", Email: "new@example.com", EmailVerified: false})
+	if _, err := login(t); !errors.Is(err, ErrLogin) {
+		t.Errorf("login with an unverified email: %v, want ErrLogin", err)
+	}
+}
+
+func TestGroupRoles(t *testing.T) {
+	tests := []struct {
+		groups []string
+		role   string
+	}{
+		{nil, ""},
+		{[]string{"guests"}, ""},
+		{[]string{"staff"}, "member"},
+		{[]string{"staff", "admins"}, "admin"},
+		{[]string{"admins", "staff"}, "admin"},
+	}
+	p := setup(t)
+	for _, tt := range tests {
+		p.SetUser(oidctest.User{Subject: "3", Email: "groups@example.com", EmailVerified: true, Groups: tt.groups})
+		id, err := login(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(id.Groups, tt.groups) {
+			t.Errorf("groups = %q, want %q", id.Groups, tt.groups)
+		}
+		if role := Role(id.Groups); role != tt.role {
+			t.Errorf("Role(%q) = %q, want %q", id.Groups, role, tt.role)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	p := setup(t)
+	if _, err := login(t); err != nil {
+		t.Fatal(err)
+	}
+
+	// The key changes while the user is at the provider.
+	target, cookie := begin(t)
+	p.RotateKey()
+	if _, err := finish(authorize(t, target), cookie); err != nil {
+		t.Fatalf("login across a key rotation: %v", err)
+	}
+
+	p.RotateKey()
+	if _, err := login(t); err != nil {
+		t.Fatalf("login after a key rotation: %v", err)
+	}
+}
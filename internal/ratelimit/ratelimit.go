@@ -0,0 +1,184 @@
+// Package ratelimit throttles clients with token buckets. Every client has a
+// bucket per class of route, holding up to Burst tokens and refilled at Rate
+// tokens per second; a request takes a token or, with none left, is refused
+// with 429 Too Many Requests.
+package ratelimit
+
+import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/health"
+	"HL_project_management/internal/logging"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Limit is the rate requests are allowed at: Rate per second on average,
+// with up to Burst at once.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Every returns a limit of n requests per period, with bursts of up to
+// burst, or n if burst is 0.
+func Every(n int, period time.Duration, burst int) Limit {
+	if burst == 0 {
+		burst = n
+	}
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: burst}
+}
+
+// fillTime is how long an empty bucket takes to fill up.
+func (l Limit) fillTime() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+type Config struct {
+	Store Store
+	// Default applies to reads, Search to the search endpoints and Write to
+	// requests with any other method than GET or HEAD.
+	Default, Search, Write Limit
+	// Auth applies per address to requests with credentials, before they
+	// are checked.
+	Auth Limit
+}
+
+var config Config
+
+func Configure(cfg Config) {
+	config = cfg
+}
+
+// exempt lists the routes that are never limited, so that probes and
+// scrapes keep working for a busy client.
+var exempt = map[string]bool{
+	"/health":   true,
+	"/livez":    true,
+	"/readyz":   true,
+	"/metrics":  true,
+	"/swagger/": true,
+}
+
+// Middleware limits requests per client: the API key, the user proven by
+// an access token or, for other requests, the IP address. Install it with
+// mux.Router.Use after auth.Middleware and BeforeAuth. Every limited
+// response carries the RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset and RateLimit-Policy headers, and a refused one
+// Retry-After. Should the store fail, requests are let through.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class, limit, ok := classify(r)
+		if ok && !take(w, r, class+":"+client(r), limit) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// BeforeAuth limits requests that carry credentials by IP address, at the
+// Auth limit. Install it with mux.Router.Use before auth.Middleware: keys
+// and tokens that fail to authenticate never reach Middleware, yet each
+// costs a hash and a database lookup.
+func BeforeAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, ok := classify(r)
+		credentials := r.Header.Get("Authorization") != "" || r.Header.Get("X-User-ID") != ""
+		if ok && credentials && !take(w, r, "auth:ip:"+auth.ClientAddr(r), config.Auth) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// take takes a token from the bucket key and sets the rate limit headers.
+// If none is left, it refuses the request and returns false.
+func take(w http.ResponseWriter, r *http.Request, key string, limit Limit) bool {
+	if config.Store == nil {
+		return true
+	}
+	allowed, tokens, err := config.Store.Take(r.Context(), key, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("ratelimit: could not take token", "key", key, "error", err)
+		return true
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	h.Set("RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(tokens)))))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds((float64(limit.Burst)-tokens)/limit.Rate)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, seconds(limit.fillTime().Seconds())))
+	if !allowed {
+		h.Set("Retry-After", strconv.Itoa(max(1, seconds((1-tokens)/limit.Rate))))
+		logging.FromContext(r.Context()).Debug("rate limited", "key", key)
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// classify returns the class of the route r matched and its limit, or false
+// if the route is exempt.
+func classify(r *http.Request) (string, Limit, bool) {
+	if route := mux.CurrentRoute(r); route != nil {
+		tmpl, _ := route.GetPathTemplate()
+		if exempt[tmpl] {
+			return "", Limit{}, false
+		}
+		if tmpl == "/search" || strings.HasPrefix(tmpl, "/search/") {
+			return "search", config.Search, true
+		}
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return "write", config.Write, true
+	}
+	return "default", config.Default, true
+}
+
+// client identifies the caller for its buckets. Each API key has buckets
+// of its own, apart from those of its owner. A user taken from a header
+// could be anyone, so such requests are told apart by address only, like
+// anonymous ones.
+func client(r *http.Request) string {
+	if id, ok := auth.APIKeyID(r.Context()); ok {
+		return "apikey:" + strconv.Itoa(id)
+	}
+	if id, ok := auth.VerifiedUserID(r.Context()); ok {
+		return "user:" + strconv.Itoa(id)
+	}
+	return "ip:" + auth.ClientAddr(r)
+}
+
+// seconds rounds s up to whole seconds.
+func seconds(s float64) int {
+	return int(math.Ceil(math.Max(0, s)))
+}
+
+// Sweep forgets buckets that have filled up every interval until ctx is
+// done.
+func Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	worker := health.NewWorker("rate-limit-sweep", interval)
+	defer worker.Stop()
+	idle := max(config.Default.fillTime(), config.Search.fillTime(), config.Write.fillTime(),
+		config.Auth.fillTime())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := config.Store.Prune(ctx, idle); err != nil {
+				slog.Error("ratelimit: could not delete idle buckets", "error", err)
+			}
+			worker.Beat()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"HL_project_management/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientKey(t *testing.T) {
+	auth.Configure(auth.Config{JWTSecret: []byte("0123456789abcdef0123456789abcdef")})
+	t.Cleanup(func() { auth.Configure(auth.Config{}) })
+	Configure(Config{
+		Store:   NewMemoryStore(),
+		Default: Every(1, time.Hour, 1),
+		Search:  Every(1, time.Hour, 1),
+		Write:   Every(1, time.Hour, 1),
+	})
+	t.Cleanup(func() { Configure(Config{}) })
+
+	h := auth.Middleware(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	send := func(addr string, header http.Header) int {
+		r := httptest.NewRequest("GET", "/tasks", nil)
+		r.RemoteAddr = addr + ":1234"
+		r.Header = header
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	bearer := func(userID int) http.Header {
+		token, err := auth.NewToken(userID, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return http.Header{"Authorization": {"Bearer " + token}}
+	}
+
+	// Tokens prove the user, so each has buckets of its own.
+	if code := send("10.0.0.1", bearer(1)); code != http.StatusOK {
+		t.Fatalf("user 1: %d", code)
+	}
+	if code := send("10.0.0.1", bearer(2)); code != http.StatusOK {
+		t.Errorf("user 2 from the same address: %d, want 200", code)
+	}
+	if code := send("10.0.0.1", bearer(1)); code != http.StatusTooManyRequests {
+		t.Errorf("user 1 again: %d, want 429", code)
+	}
+
+	// Even behind a gateway trusted to set X-User-ID, the header does not
+	// earn a bucket of its own.
+	auth.Configure(auth.Config{TrustUserHeader: true})
+	if code := send("10.0.0.2", http.Header{"X-User-Id": {"1"}}); code != http.StatusOK {
+		t.Fatalf("first header request: %d", code)
+	}
+	if code := send("10.0.0.2", http.Header{"X-User-Id": {"2"}}); code != http.StatusTooManyRequests {
+		t.Errorf("changed header from the same address: %d, want 429", code)
+	}
+	if code := send("10.0.0.3", nil); code != http.StatusOK {
+		t.Errorf("anonymous request from another address: %d, want 200", code)
+	}
+}
+
+func TestBeforeAuth(t *testing.T) {
+	Configure(Config{Store: NewMemoryStore(), Auth: Every(1, time.Hour, 1)})
+	t.Cleanup(func() { Configure(Config{}) })
+
+	checked := 0
+	h := BeforeAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { checked++ }))
+	send := func(addr string, header http.Header) int {
+		r := httptest.NewRequest("GET", "/tasks", nil)
+		r.RemoteAddr = addr + ":1234"
+		r.Header = header
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	bogus := http.Header{"Authorization": {"Bearer " + auth.APIKeyPrefix + "bogus"}}
+
+	if code := send("10.0.0.1", bogus); code != http.StatusOK {
+		t.Fatalf("first key: %d", code)
+	}
+	if code := send("10.0.0.1", bogus); code != http.StatusTooManyRequests {
+		t.Errorf("second key from the same address: %d, want 429", code)
+	}
+	if code := send("10.0.0.1", http.Header{"X-User-Id": {"1"}}); code != http.StatusTooManyRequests {
+		t.Errorf("X-User-ID from the same address: %d, want 429", code)
+	}
+	if checked != 1 {
+		t.Errorf("%d requests reached authentication, want 1", checked)
+	}
+
+	if code := send("10.0.0.1", nil); code != http.StatusOK {
+		t.Errorf("request without credentials: %d, want 200", code)
+	}
+	if code := send("10.0.0.2", bogus); code != http.StatusOK {
+		t.Errorf("key from another address: %d, want 200", code)
+	}
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"HL_project_management/internal/repository"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store keeps the token buckets.
+type Store interface {
+	// Take refills the bucket key according to limit and takes a token from
+	// it if one is left. It returns whether it did and the tokens remaining.
+	Take(ctx context.Context, key string, limit Limit) (bool, float64, error)
+	// Prune forgets buckets unused for longer than idle.
+	Prune(ctx context.Context, idle time.Duration) error
+}
+
+// MemoryStore keeps buckets in the process. Each replica limits on its own,
+// so a client spreading requests over n replicas gets n times the limit.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (bool, float64, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst)}
+		s.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	}
+	b.updated = now
+	if b.tokens < 1 {
+		return false, b.tokens, nil
+	}
+	b.tokens--
+	return true, b.tokens, nil
+}
+
+func (s *MemoryStore) Prune(ctx context.Context, idle time.Duration) error {
+	before := time.Now().Add(-idle)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, b := range s.buckets {
+		if b.updated.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
+
+// PostgresStore keeps buckets in the database, shared by all replicas. It
+// costs a query per request.
+type PostgresStore struct{}
+
+func (PostgresStore) Take(ctx context.Context, key string, limit Limit) (bool, float64, error) {
+	return repository.TakeRateLimitToken(ctx, key, limit.Rate, limit.Burst)
+}
+
+func (PostgresStore) Prune(ctx context.Context, idle time.Duration) error {
+	_, err := repository.DeleteIdleRateLimits(ctx, idle)
+	return err
+}
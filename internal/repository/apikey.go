@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// API key functions
+const apiKeyColumns = "id, user_id, name, scopes, prefix, expires_at, last_used_at, created_at"
+
+func scanAPIKey(row scanner) (model.APIKey, error) {
+	var k model.APIKey
+	err := row.Scan(&k.ID, &k.UserID, &k.Name, (*pq.StringArray)(&k.Scopes), &k.Prefix, &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt)
+	return k, err
+}
+
+// CreateAPIKey stores a key of which only the hash is known.
+func CreateAPIKey(ctx context.Context, k model.APIKey, hash string) (model.APIKey, error) {
+	ctx, end := observe(ctx, "CreateAPIKey")
+	defer end()
+	err := db.QueryRowContext(ctx,
+		"INSERT INTO api_keys (user_id, name, scopes, prefix, hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		k.UserID, k.Name, pq.Array(k.Scopes), k.Prefix, hash, k.ExpiresAt, k.CreatedAt,
+	).Scan(&k.ID)
+	if err != nil {
+		return model.APIKey{}, err
+	}
+	return k, nil
+}
+
+func GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	ctx, end := observe(ctx, "GetAPIKeyByHash")
+	defer end()
+	return scanAPIKey(db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE hash = $1", hash))
+}
+
+func GetAPIKeysByUserID(ctx context.Context, userID int) ([]model.APIKey, error) {
+	ctx, end := observe(ctx, "GetAPIKeysByUserID")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// CountAPIKeys returns the number of keys that have not expired.
+func CountAPIKeys(ctx context.Context) (int, error) {
+	ctx, end := observe(ctx, "CountAPIKeys")
+	defer end()
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM api_keys WHERE expires_at IS NULL OR expires_at > $1", time.Now()).Scan(&n)
+	return n, err
+}
+
+// TouchAPIKey records that a key was used at t.
+func TouchAPIKey(ctx context.Context, id int, t time.Time) error {
+	ctx, end := observe(ctx, "TouchAPIKey")
+	defer end()
+	_, err := db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1", id, t)
+	return err
+}
+
+// DeleteAPIKey revokes a key of the user, reporting whether there was one.
+func DeleteAPIKey(ctx context.Context, id, userID int) (bool, error) {
+	ctx, end := observe(ctx, "DeleteAPIKey")
+	defer end()
+	res, err := db.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
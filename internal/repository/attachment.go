@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+)
+
+// Attachment functions
+const attachmentColumns = "id, task_id, COALESCE(uploader_id, 0), file_name, content_type, size, checksum, storage_key, created_at"
+
+func scanAttachment(row scanner) (model.Attachment, error) {
+	var a model.Attachment
+	err := row.Scan(&a.ID, &a.TaskID, &a.UploaderID, &a.FileName, &a.ContentType, &a.Size, &a.Checksum, &a.StorageKey, &a.CreatedAt)
+	return a, err
+}
+
+func CreateAttachment(ctx context.Context, a model.Attachment) (model.Attachment, error) {
+	ctx, end := observe(ctx, "CreateAttachment")
+	defer end()
+	var uploaderID any
+	if a.UploaderID != 0 {
+		uploaderID = a.UploaderID
+	}
+	err := db.QueryRowContext(ctx,
+		"INSERT INTO attachments (task_id, uploader_id, file_name, content_type, size, checksum, storage_key, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		a.TaskID, uploaderID, a.FileName, a.ContentType, a.Size, a.Checksum, a.StorageKey, a.CreatedAt,
+	).Scan(&a.ID)
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	return a, nil
+}
+
+func GetAttachmentsByTaskID(ctx context.Context, taskID int) ([]model.Attachment, error) {
+	ctx, end := observe(ctx, "GetAttachmentsByTaskID")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT "+attachmentColumns+" FROM attachments WHERE task_id = $1 ORDER BY created_at, id", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []model.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+func GetAttachmentByID(ctx context.Context, taskID, id int) (model.Attachment, error) {
+	ctx, end := observe(ctx, "GetAttachmentByID")
+	defer end()
+	return scanAttachment(db.QueryRowContext(ctx, "SELECT "+attachmentColumns+" FROM attachments WHERE id = $1 AND task_id = $2", id, taskID))
+}
+
+func DeleteAttachment(ctx context.Context, id int) error {
+	ctx, end := observe(ctx, "DeleteAttachment")
+	defer end()
+	_, err := db.ExecContext(ctx, "DELETE FROM attachments WHERE id = $1", id)
+	return err
+}
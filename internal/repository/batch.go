@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Postgres accepts at most 65535 parameters per statement, so multi-row
+// inserts are split into chunks well below that.
+const maxInsertParams = 60000
+
+// Batch runs several writes in one transaction. Savepoints let a failed
+// write be undone without losing the others.
+type Batch struct {
+	tx         *sql.Tx
+	savepoints int
+}
+
+func BeginBatch(ctx context.Context) (*Batch, error) {
+	ctx, end := observe(ctx, "BeginBatch")
+	defer end()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Batch{tx: tx}, nil
+}
+
+func (b *Batch) Commit(ctx context.Context) error {
+	_, end := observe(ctx, "Batch.Commit")
+	defer end()
+	return b.tx.Commit()
+}
+
+func (b *Batch) Rollback() error {
+	return b.tx.Rollback()
+}
+
+// Try runs fn under a savepoint and undoes its writes if it fails, keeping
+// the transaction usable.
+func (b *Batch) Try(ctx context.Context, fn func() error) error {
+	b.savepoints++
+	name := fmt.Sprintf("batch_%d", b.savepoints)
+	if _, err := b.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		if _, rbErr := b.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+	_, err := b.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// insertRows inserts n rows with a multi-row INSERT and returns their IDs in
+// the same order.
+func (b *Batch) insertRows(ctx context.Context, table, columns string, n int, row func(i int) []any) ([]int, error) {
+	width := len(strings.Split(columns, ","))
+	chunk := maxInsertParams / width
+	ids := make([]int, 0, n)
+	for start := 0; start < n; start += chunk {
+		end := min(start+chunk, n)
+		var values []string
+		var args []any
+		for i := start; i < end; i++ {
+			placeholders := make([]string, width)
+			for j := range placeholders {
+				placeholders[j] = fmt.Sprintf("$%d", len(args)+j+1)
+			}
+			values = append(values, "("+strings.Join(placeholders, ", ")+")")
+			args = append(args, row(i)...)
+		}
+		rows, err := b.tx.QueryContext(ctx, "INSERT INTO "+table+" ("+columns+") VALUES "+strings.Join(values, ", ")+" RETURNING id", args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// deleteRows deletes the rows with the given IDs and returns the IDs that
+// existed.
+func (b *Batch) deleteRows(ctx context.Context, table string, ids []int) ([]int, error) {
+	rows, err := b.tx.QueryContext(ctx, "DELETE FROM "+table+" WHERE id = ANY($1) RETURNING id", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted := make([]int, 0, len(ids))
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, id)
+	}
+	return deleted, rows.Err()
+}
+
+// updateRow runs an UPDATE and reports sql.ErrNoRows if nothing matched.
+func (b *Batch) updateRow(ctx context.Context, query string, args ...any) error {
+	res, err := b.tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+func (b *Batch) CreateUsers(ctx context.Context, users []model.User) ([]model.User, error) {
+	ctx, end := observe(ctx, "Batch.CreateUsers")
+	defer end()
+	ids, err := b.insertRows(ctx, "users", "name, email, registration_at, role", len(users), func(i int) []any {
+		u := users[i]
+		return []any{u.Name, u.Email, u.RegistrationAt, u.Role}
+	})
+	if err != nil {
+		return nil, err
+	}
+	created := make([]model.User, len(users))
+	for i, u := range users {
+		u.ID = ids[i]
+		created[i] = u
+	}
+	return created, nil
+}
+
+func (b *Batch) UpdateUser(ctx context.Context, id int, user model.User) (model.User, error) {
+	ctx, end := observe(ctx, "Batch.UpdateUser")
+	defer end()
+	err := b.updateRow(ctx, "UPDATE users SET name = $1, email = $2, role = $3 WHERE id = $4", user.Name, user.Email, user.Role, id)
+	if err != nil {
+		return model.User{}, err
+	}
+	err = b.tx.QueryRowContext(ctx, "SELECT id, name, email, registration_at, role FROM users WHERE id = $1", id).
+		Scan(&user.ID, &user.Name, &user.Email, &user.RegistrationAt, &user.Role)
+	return user, err
+}
+
+func (b *Batch) DeleteUsers(ctx context.Context, ids []int) ([]int, error) {
+	ctx, end := observe(ctx, "Batch.DeleteUsers")
+	defer end()
+	return b.deleteRows(ctx, "users", ids)
+}
+
+func (b *Batch) CreateTasks(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	ctx, end := observe(ctx, "Batch.CreateTasks")
+	defer end()
+	ids, err := b.insertRows(ctx, "tasks", "title, description, priority, status, assignee_id, project_id, created_at, completed_at, due_at", len(tasks), func(i int) []any {
+		t := tasks[i]
+		return []any{t.Title, t.Description, t.Priority, t.Status, t.AssigneeID, t.ProjectID, t.CreatedAt, nullTime(t.CompletedAt), nullTime(t.DueAt)}
+	})
+	if err != nil {
+		return nil, err
+	}
+	created := make([]model.Task, len(tasks))
+	for i, t := range tasks {
+		t.ID = ids[i]
+		created[i] = t
+	}
+	return created, nil
+}
+
+func (b *Batch) GetTaskByID(ctx context.Context, id int) (model.Task, error) {
+	ctx, end := observe(ctx, "Batch.GetTaskByID")
+	defer end()
+	return scanTask(b.tx.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = $1", id))
+}
+
+func (b *Batch) UpdateTask(ctx context.Context, id int, task model.Task) (model.Task, error) {
+	ctx, end := observe(ctx, "Batch.UpdateTask")
+	defer end()
+	err := b.updateRow(ctx,
+		`UPDATE tasks SET title = $1, description = $2, priority = $3, status = $4, assignee_id = $5, project_id = $6, completed_at = $7,
+		reminded_at = CASE WHEN due_at IS DISTINCT FROM $8 THEN NULL ELSE reminded_at END,
+		overdue_notified_at = CASE WHEN due_at IS DISTINCT FROM $8 THEN NULL ELSE overdue_notified_at END,
+		due_at = $8
+		WHERE id = $9`,
+		task.Title, task.Description, task.Priority, task.Status, task.AssigneeID, task.ProjectID, nullTime(task.CompletedAt), nullTime(task.DueAt), id,
+	)
+	if err != nil {
+		return model.Task{}, err
+	}
+	return b.GetTaskByID(ctx, id)
+}
+
+// DeleteTasks returns the deleted tasks and their attachments, whose blobs
+// are to be removed once the batch is committed.
+func (b *Batch) DeleteTasks(ctx context.Context, ids []int) ([]model.Task, []model.Attachment, error) {
+	ctx, end := observe(ctx, "Batch.DeleteTasks")
+	defer end()
+	rows, err := b.tx.QueryContext(ctx, "SELECT "+attachmentColumns+" FROM attachments WHERE task_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []model.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		files = append(files, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = b.tx.QueryContext(ctx, "DELETE FROM tasks WHERE id = ANY($1) RETURNING "+taskColumns, pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var deleted []model.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		deleted = append(deleted, task)
+	}
+	return deleted, files, rows.Err()
+}
+
+func (b *Batch) CreateProjects(ctx context.Context, projects []model.Project) ([]model.Project, error) {
+	ctx, end := observe(ctx, "Batch.CreateProjects")
+	defer end()
+	ids, err := b.insertRows(ctx, "projects", "title, description, start_date, end_date, manager_id", len(projects), func(i int) []any {
+		p := projects[i]
+		return []any{p.Title, p.Description, p.StartDate, nullTime(p.EndDate), p.ManagerID}
+	})
+	if err != nil {
+		return nil, err
+	}
+	created := make([]model.Project, len(projects))
+	for i, p := range projects {
+		p.ID = ids[i]
+		created[i] = p
+	}
+	return created, nil
+}
+
+func (b *Batch) UpdateProject(ctx context.Context, id int, project model.Project) (model.Project, error) {
+	ctx, end := observe(ctx, "Batch.UpdateProject")
+	defer end()
+	err := b.updateRow(ctx,
+		"UPDATE projects SET title = $1, description = $2, start_date = $3, end_date = $4, manager_id = $5 WHERE id = $6",
+		project.Title, project.Description, project.StartDate, project.EndDate, project.ManagerID, id,
+	)
+	if err != nil {
+		return model.Project{}, err
+	}
+	err = b.tx.QueryRowContext(ctx, "SELECT id, title, description, start_date, end_date, manager_id FROM projects WHERE id = $1", id).
+		Scan(&project.ID, &project.Title, &project.Description, &project.StartDate, &project.EndDate, &project.ManagerID)
+	return project, err
+}
+
+func (b *Batch) DeleteProjects(ctx context.Context, ids []int) ([]int, error) {
+	ctx, end := observe(ctx, "Batch.DeleteProjects")
+	defer end()
+	return b.deleteRows(ctx, "projects", ids)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+)
+
+// Comment functions
+func CreateComment(ctx context.Context, comment model.Comment) (model.Comment, error) {
+	ctx, end := observe(ctx, "CreateComment")
+	defer end()
+	err := db.QueryRowContext(ctx,
+		"INSERT INTO comments (task_id, author_id, body, created_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		comment.TaskID, comment.AuthorID, comment.Body, comment.CreatedAt,
+	).Scan(&comment.ID)
+	if err != nil {
+		return model.Comment{}, err
+	}
+	return comment, nil
+}
+
+func GetCommentsByTaskID(ctx context.Context, taskID int) ([]model.Comment, error) {
+	ctx, end := observe(ctx, "GetCommentsByTaskID")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT id, task_id, author_id, body, created_at FROM comments WHERE task_id = $1 ORDER BY created_at, id", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []model.Comment{}
+	for rows.Next() {
+		var comment model.Comment
+		if err := rows.Scan(&comment.ID, &comment.TaskID, &comment.AuthorID, &comment.Body, &comment.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
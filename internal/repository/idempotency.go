@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// StoredResponse is the response recorded for an idempotency key. Status is
+// zero while the first request with the key is still being handled.
+type StoredResponse struct {
+	RequestHash string
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// ClaimIdempotencyKey reserves a key of the user for a request. It succeeds
+// if the key is new, has expired, or was claimed before staleBefore by a
+// request that never finished. Otherwise the stored response is returned.
+// The insert settles concurrent claims, so only one of them succeeds.
+func ClaimIdempotencyKey(ctx context.Context, userID int, key, hash string, ttl time.Duration, staleBefore time.Time) (bool, StoredResponse, error) {
+	ctx, end := observe(ctx, "ClaimIdempotencyKey")
+	defer end()
+	now := time.Now()
+	var claimed bool
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO idempotency_keys (user_id, key, request_hash, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status = NULL, content_type = NULL, body = NULL,
+			created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < $4 OR (idempotency_keys.status IS NULL AND idempotency_keys.created_at < $6)
+		RETURNING TRUE`,
+		userID, key, hash, now, now.Add(ttl), staleBefore,
+	).Scan(&claimed)
+	if err == nil {
+		return true, StoredResponse{}, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, StoredResponse{}, err
+	}
+
+	var stored StoredResponse
+	var status sql.NullInt64
+	var contentType sql.NullString
+	err = db.QueryRowContext(ctx, "SELECT request_hash, status, content_type, body FROM idempotency_keys WHERE user_id = $1 AND key = $2", userID, key).
+		Scan(&stored.RequestHash, &status, &contentType, &stored.Body)
+	stored.Status = int(status.Int64)
+	stored.ContentType = contentType.String
+	return false, stored, err
+}
+
+func SaveIdempotentResponse(ctx context.Context, userID int, key string, resp StoredResponse) error {
+	ctx, end := observe(ctx, "SaveIdempotentResponse")
+	defer end()
+	_, err := db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status = $3, content_type = $4, body = $5 WHERE user_id = $1 AND key = $2",
+		userID, key, resp.Status, resp.ContentType, resp.Body,
+	)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a key so that the request can be retried.
+func ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	ctx, end := observe(ctx, "ReleaseIdempotencyKey")
+	defer end()
+	_, err := db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2", userID, key)
+	return err
+}
+
+func DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, end := observe(ctx, "DeleteExpiredIdempotencyKeys")
+	defer end()
+	res, err := db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < $1", time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
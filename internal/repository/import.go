@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// CreateTasks inserts all tasks in one transaction, so either every task is
+// created or none is.
+func CreateTasks(ctx context.Context, tasks []model.Task) ([]model.Task, error) {
+	ctx, end := observe(ctx, "CreateTasks")
+	defer end()
+	b, err := BeginBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Rollback()
+
+	created, err := b.CreateTasks(ctx, tasks)
+	if err != nil {
+		return nil, err
+	}
+	return created, b.Commit(ctx)
+}
+
+// MatchUsers maps each value to the ID of a user. Values containing @ are
+// matched by email; others, like Trello usernames, the same way @mentions
+// are. Matching ignores case and values without a match are left out.
+func MatchUsers(ctx context.Context, values []string) (map[string]int, error) {
+	ctx, end := observe(ctx, "MatchUsers")
+	defer end()
+	rows, err := db.QueryContext(ctx, `SELECT DISTINCT ON (v) v, u.id FROM unnest($1::text[]) AS v
+		JOIN users u ON LOWER(u.email) = LOWER(v)
+			OR (position('@' in v) = 0 AND (LOWER(split_part(u.email, '@', 1)) = LOWER(v) OR LOWER(REPLACE(u.name, ' ', '')) = LOWER(v)))
+		ORDER BY v, LOWER(u.email) = LOWER(v) DESC, u.id`, pq.Array(values))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := map[string]int{}
+	for rows.Next() {
+		var value string
+		var id int
+		if err := rows.Scan(&value, &id); err != nil {
+			return nil, err
+		}
+		users[value] = id
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Paged appends a LIMIT and OFFSET to an ORDER BY list passed to the Each
+// functions.
+func Paged(orderBy string, limit, offset int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", orderBy, limit, offset)
+}
+
+func GetUsersByIDs(ctx context.Context, ids []int) ([]model.User, error) {
+	ctx, end := observe(ctx, "GetUsersByIDs")
+	defer end()
+	users := []model.User{}
+	err := EachUser(ctx, "id = ANY($1)", "id", []any{pq.Array(ids)}, func(user model.User) error {
+		users = append(users, user)
+		return nil
+	})
+	return users, err
+}
+
+func GetProjectsByIDs(ctx context.Context, ids []int) ([]model.Project, error) {
+	ctx, end := observe(ctx, "GetProjectsByIDs")
+	defer end()
+	projects := []model.Project{}
+	err := EachProject(ctx, "id = ANY($1)", "id", []any{pq.Array(ids)}, func(project model.Project) error {
+		projects = append(projects, project)
+		return nil
+	})
+	return projects, err
+}
+
+func GetProjectsByManagerIDs(ctx context.Context, ids []int) ([]model.Project, error) {
+	ctx, end := observe(ctx, "GetProjectsByManagerIDs")
+	defer end()
+	projects := []model.Project{}
+	err := EachProject(ctx, "manager_id = ANY($1)", "manager_id, id", []any{pq.Array(ids)}, func(project model.Project) error {
+		projects = append(projects, project)
+		return nil
+	})
+	return projects, err
+}
+
+// GetTasksGroupedBy returns a page of the matching tasks of each of several
+// assignees or projects in one query. column is assignee_id or project_id;
+// the placeholders in where start at $2.
+func GetTasksGroupedBy(ctx context.Context, column string, ids []int, where, orderBy string, args []any, limit, offset int) (map[int][]model.Task, error) {
+	ctx, end := observe(ctx, "GetTasksGroupedBy")
+	defer end()
+	if column != "assignee_id" && column != "project_id" {
+		return nil, fmt.Errorf("cannot group tasks by %q", column)
+	}
+	query := fmt.Sprintf(`SELECT %s FROM (
+			SELECT tasks.*, row_number() OVER (PARTITION BY %s ORDER BY %s) AS position
+			FROM tasks WHERE %s = ANY($1) AND (%s)
+		) AS t WHERE position > %d AND position <= %d ORDER BY %s, position`,
+		taskColumns, column, orderBy, column, where, offset, offset+limit, column)
+	rows, err := db.QueryContext(ctx, query, append([]any{pq.Array(ids)}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := map[int][]model.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		key := task.ProjectID
+		if column == "assignee_id" {
+			key = task.AssigneeID
+		}
+		groups[key] = append(groups[key], task)
+	}
+	return groups, rows.Err()
+}
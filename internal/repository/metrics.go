@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/metrics"
+	"HL_project_management/internal/model"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+)
+
+var queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: metrics.Namespace,
+	Name:      "db_query_duration_seconds",
+	Help:      "Time spent in repository operations, by operation.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+}, []string{"operation"})
+
+var tracer = otel.Tracer("HL_project_management/internal/repository")
+
+// slowQuery is how long an operation may take before it is logged.
+var slowQuery time.Duration
+
+// LogSlowQueries logs operations that take longer than d as warnings, with
+// the logger of the request they belong to. 0 turns this off.
+func LogSlowQueries(d time.Duration) {
+	slowQuery = d
+}
+
+// observe times a repository operation, traces it as a span, the parent of
+// the spans of its SQL statements, and logs it if it is slow:
+//
+//	ctx, end := observe(ctx, "GetTaskByID")
+//	defer end()
+func observe(ctx context.Context, operation string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "repository."+operation)
+	return ctx, func() {
+		span.End()
+		elapsed := time.Since(start)
+		queryDuration.WithLabelValues(operation).Observe(elapsed.Seconds())
+		if slowQuery > 0 && elapsed > slowQuery {
+			logging.FromContext(ctx).Warn("slow repository operation",
+				"operation", operation, "duration_ms", float64(elapsed.Microseconds())/1000)
+		}
+	}
+}
+
+// Collectors returns the repository's metrics: operation durations, the
+// connection pool statistics and the task gauges. Call it after OpenDB.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		queryDuration,
+		collectors.NewDBStatsCollector(db, "postgres"),
+		taskCollector{},
+	}
+}
+
+var (
+	openTasksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "", "open_tasks"),
+		"Tasks that are not done, by project.",
+		[]string{"project_id"}, nil)
+	overdueTasksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "", "overdue_tasks"),
+		"Tasks that are not done and past their due date.",
+		nil, nil)
+)
+
+// scrapeTimeout bounds the queries of one scrape of the task gauges.
+const scrapeTimeout = 5 * time.Second
+
+// taskCollector reads the task gauges from the database on every scrape.
+type taskCollector struct{}
+
+func (taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openTasksDesc
+	ch <- overdueTasksDesc
+}
+
+func (taskCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+	ctx, end := observe(ctx, "CollectTaskMetrics")
+	defer end()
+
+	if err := collectOpenTasks(ctx, ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(openTasksDesc, err)
+	}
+	var overdue float64
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM tasks WHERE due_at <= NOW() AND status <> $1", model.TaskStatusDone).Scan(&overdue)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(overdueTasksDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(overdueTasksDesc, prometheus.GaugeValue, overdue)
+}
+
+func collectOpenTasks(ctx context.Context, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx,
+		"SELECT project_id, COUNT(*) FROM tasks WHERE status <> $1 GROUP BY project_id", model.TaskStatusDone)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var projectID int
+		var count float64
+		if err := rows.Scan(&projectID, &count); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(openTasksDesc, prometheus.GaugeValue, count, strconv.Itoa(projectID))
+	}
+	return rows.Err()
+}
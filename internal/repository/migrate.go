@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"HL_project_management/migrations"
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"sort"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Migration is one embedded migration and whether it has been applied.
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// MigrationStatus describes the schema version of the database.
+type MigrationStatus struct {
+	// Version is the last applied migration, 0 if none.
+	Version uint
+	// Dirty is set when a migration failed halfway; the schema has to be
+	// fixed by hand and the version forced.
+	Dirty bool
+	// Latest is the newest embedded migration.
+	Latest     uint
+	Migrations []Migration
+}
+
+// UpToDate reports whether all embedded migrations have been applied.
+func (s MigrationStatus) UpToDate() bool {
+	return !s.Dirty && s.Version == s.Latest
+}
+
+// withMigrate runs fn with a migrator for the embedded migrations. The
+// migrator holds one connection from the pool and returns it afterwards;
+// closing a migrator made with postgres.WithInstance would close the whole
+// pool.
+func withMigrate(fn func(m *migrate.Migrate) error) error {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return err
+	}
+	defer m.Close()
+	return fn(m)
+}
+
+// MigrateUp applies all pending migrations.
+func MigrateUp() error {
+	return withMigrate(func(m *migrate.Migrate) error {
+		return ignoreNoChange(m.Up())
+	})
+}
+
+// MigrateDown rolls back the given number of migrations, or all of them if
+// steps is 0.
+func MigrateDown(steps int) error {
+	return withMigrate(func(m *migrate.Migrate) error {
+		if steps == 0 {
+			return ignoreNoChange(m.Down())
+		}
+		return ignoreNoChange(m.Steps(-steps))
+	})
+}
+
+// MigrateTo migrates up or down to the given version.
+func MigrateTo(version uint) error {
+	return withMigrate(func(m *migrate.Migrate) error {
+		return ignoreNoChange(m.Migrate(version))
+	})
+}
+
+// ForceMigrationVersion records version as applied and clears the dirty flag
+// without running anything, after a failed migration was fixed by hand.
+// A version of -1 means no migration is applied.
+func ForceMigrationVersion(version int) error {
+	return withMigrate(func(m *migrate.Migrate) error {
+		return m.Force(version)
+	})
+}
+
+func GetMigrationStatus() (MigrationStatus, error) {
+	var status MigrationStatus
+	err := withMigrate(func(m *migrate.Migrate) error {
+		var err error
+		status.Version, status.Dirty, err = m.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return status, err
+	}
+
+	all, err := embeddedMigrations()
+	if err != nil {
+		return status, err
+	}
+	for _, mig := range all {
+		mig.Applied = mig.Version < status.Version || mig.Version == status.Version && !status.Dirty
+		status.Migrations = append(status.Migrations, mig)
+		status.Latest = mig.Version
+	}
+	return status, nil
+}
+
+// SchemaVersion reads the applied migration version straight from the
+// schema_migrations table. Unlike GetMigrationStatus it takes no lock, so it
+// is cheap enough for health checks.
+func SchemaVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// LatestMigration returns the version of the newest embedded migration, the
+// one the binary expects.
+func LatestMigration() (uint, error) {
+	all, err := embeddedMigrations()
+	if err != nil || len(all) == 0 {
+		return 0, err
+	}
+	return all[len(all)-1].Version, nil
+}
+
+// embeddedMigrations lists the embedded migrations by version.
+func embeddedMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+	var list []Migration
+	for _, e := range entries {
+		mig, err := source.DefaultParse(e.Name())
+		if err != nil || mig.Direction != source.Up {
+			continue
+		}
+		list = append(list, Migration{Version: mig.Version, Name: mig.Identifier})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"HL_project_management/internal/config"
+	"context"
+	"os"
+	"testing"
+)
+
+// TestMigrateKeepsPool checks that the migrator leaves the shared pool open,
+// as serve -migrate and the migrate command go on using it.
+func TestMigrateKeepsPool(t *testing.T) {
+	dsn := os.Getenv("PM_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("PM_TEST_DATABASE_URL is not set")
+	}
+	if _, err := OpenDB(config.DB{DSN: dsn}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(CloseDB)
+
+	if err := MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+	if err := PingDB(context.Background()); err != nil {
+		t.Fatalf("ping after MigrateUp: %v", err)
+	}
+	status, err := GetMigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.UpToDate() {
+		t.Errorf("status = %+v, want up to date", status)
+	}
+	if err := PingDB(context.Background()); err != nil {
+		t.Fatalf("ping after GetMigrationStatus: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+	"database/sql"
+)
+
+// Notification functions
+func CreateNotification(ctx context.Context, n model.Notification) (model.Notification, error) {
+	ctx, end := observe(ctx, "CreateNotification")
+	defer end()
+	return insertNotification(ctx, db, n)
+}
+
+func insertNotification(ctx context.Context, q queryRower, n model.Notification) (model.Notification, error) {
+	var taskID any
+	if n.TaskID != 0 {
+		taskID = n.TaskID
+	}
+	err := q.QueryRowContext(ctx,
+		"INSERT INTO notifications (user_id, type, task_id, message, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		n.UserID, n.Type, taskID, n.Message, n.CreatedAt,
+	).Scan(&n.ID)
+	if err != nil {
+		return model.Notification{}, err
+	}
+	return n, nil
+}
+
+// queryRower is a *sql.DB or a *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func GetNotificationsByUserID(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]model.Notification, error) {
+	ctx, end := observe(ctx, "GetNotificationsByUserID")
+	defer end()
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, user_id, type, COALESCE(task_id, 0), message, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []model.Notification{}
+	for rows.Next() {
+		var n model.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.TaskID, &n.Message, &n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkNotificationRead returns sql.ErrNoRows if the user has no such notification.
+func MarkNotificationRead(ctx context.Context, userID, id int) error {
+	ctx, end := observe(ctx, "MarkNotificationRead")
+	defer end()
+	res, err := db.ExecContext(ctx, "UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func MarkAllNotificationsRead(ctx context.Context, userID int) error {
+	ctx, end := observe(ctx, "MarkAllNotificationsRead")
+	defer end()
+	_, err := db.ExecContext(ctx, "UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL", userID)
+	return err
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// rateLimitRefill is the number of tokens in a bucket once it has been
+// refilled at $2 tokens per second, up to $3, since it was last used.
+const rateLimitRefill = `LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $2::float8)`
+
+// TakeRateLimitToken refills the token bucket key and takes a token from it
+// if one is left, returning whether it did and how many tokens remain. A new
+// bucket starts full. The upsert runs under the row lock, so concurrent
+// requests from any number of replicas each see the bucket left by the
+// previous one; the database clock is used so that replicas agree on time.
+func TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (bool, float64, error) {
+	ctx, end := observe(ctx, "TakeRateLimitToken")
+	defer end()
+	var allowed bool
+	var tokens float64
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO rate_limits AS b (key, tokens, allowed, updated_at) VALUES ($1, $3::float8 - 1, TRUE, now())
+		ON CONFLICT (key) DO UPDATE SET
+			allowed = `+rateLimitRefill+` >= 1,
+			tokens = CASE WHEN `+rateLimitRefill+` >= 1 THEN `+rateLimitRefill+` - 1 ELSE `+rateLimitRefill+` END,
+			updated_at = now()
+		RETURNING allowed, tokens`,
+		key, rate, burst,
+	).Scan(&allowed, &tokens)
+	return allowed, tokens, err
+}
+
+// DeleteIdleRateLimits deletes buckets unused for longer than idle. A bucket
+// that has had time to fill up is the same as none.
+func DeleteIdleRateLimits(ctx context.Context, idle time.Duration) (int64, error) {
+	ctx, end := observe(ctx, "DeleteIdleRateLimits")
+	defer end()
+	res, err := db.ExecContext(ctx, "DELETE FROM rate_limits WHERE updated_at < now() - make_interval(secs => $1)", idle.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
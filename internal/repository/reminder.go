@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ClaimTasksDueBefore marks open tasks due between now and until as reminded,
+// stores the notification notify makes for each and returns them. Each task is
+// claimed once per due date, so reminders are not repeated even if several
+// schedulers race. The claims and the notifications are committed together,
+// so a notification that cannot be stored leaves its task to the next run.
+func ClaimTasksDueBefore(ctx context.Context, until time.Time, notify func(model.Task) model.Notification) ([]model.Task, error) {
+	ctx, end := observe(ctx, "ClaimTasksDueBefore")
+	defer end()
+	return claimTasks(ctx, notify, `
+		UPDATE tasks SET reminded_at = NOW()
+		WHERE due_at > NOW() AND due_at <= $1
+		AND reminded_at IS NULL AND status <> $2
+		RETURNING `+taskColumns, until, model.TaskStatusDone)
+}
+
+// ClaimOverdueTasks marks open tasks whose due date has passed as notified,
+// stores the notification notify makes for each and returns them, like
+// ClaimTasksDueBefore.
+func ClaimOverdueTasks(ctx context.Context, notify func(model.Task) model.Notification) ([]model.Task, error) {
+	ctx, end := observe(ctx, "ClaimOverdueTasks")
+	defer end()
+	return claimTasks(ctx, notify, `
+		UPDATE tasks SET overdue_notified_at = NOW()
+		WHERE due_at <= NOW()
+		AND overdue_notified_at IS NULL AND status <> $1
+		RETURNING `+taskColumns, model.TaskStatusDone)
+}
+
+func claimTasks(ctx context.Context, notify func(model.Task) model.Notification, query string, args ...any) ([]model.Task, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tasks, err := scanClaimed(tx.QueryContext(ctx, query, args...))
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if _, err := insertNotification(ctx, tx, notify(task)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func scanClaimed(rows *sql.Rows, err error) ([]model.Task, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []model.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// TryAdvisoryLock tries to take the session-level Postgres advisory lock named
+// name on a dedicated connection. The key of the lock is hashtext(name). It returns nil if another session holds the lock.
+// The lock is held until ReleaseAdvisoryLock is called or the connection dies.
+func TryAdvisoryLock(ctx context.Context, name string) (*sql.Conn, error) {
+	ctx, end := observe(ctx, "TryAdvisoryLock")
+	defer end()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", name).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+	return conn, nil
+}
+
+func ReleaseAdvisoryLock(ctx context.Context, conn *sql.Conn, name string) error {
+	ctx, end := observe(ctx, "ReleaseAdvisoryLock")
+	defer end()
+	defer conn.Close()
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1))", name)
+	return err
+}
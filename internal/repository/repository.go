@@ -1,59 +1,40 @@
 package repository
 
 import (
+	"HL_project_management/internal/config"
 	"HL_project_management/internal/model"
+	"context"
 	"database/sql"
-	"fmt"
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
-	"log"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"time"
 )
 
-type Config struct {
-	Port int
-	Env  string
-	Db   struct {
-		Dsn string
-	}
-}
-
 var db *sql.DB
 
-func OpenDB(cfg Config) (*sql.DB, error) {
+func OpenDB(cfg config.DB) (*sql.DB, error) {
 	// Use sql.Open() to create an empty connection pool, using the DSN from the config // struct.
+	// Every statement is traced as a child span of the repository operation.
 	var err error
-	db, err = sql.Open("postgres", cfg.Db.Dsn)
-	fmt.Println(db)
+	db, err = otelsql.Open("postgres", cfg.DSN,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}))
 	if err != nil {
 		return nil, err
 	}
-	migrationUp(db)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 	return db, nil
 }
-func migrationUp(db *sql.DB) {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		log.Fatal(err)
-	}
 
-	// Путь к файлам миграции
-	//m, err := migrate.NewWithDatabaseInstance(
-	//	"file:///usr/src/app/internal/migrations",
-	//	"postgres", driver)
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres", driver)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Применение миграций
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal(err)
-	}
+// PingDB checks that the database can be reached.
+func PingDB(ctx context.Context) error {
+	return db.PingContext(ctx)
 }
+
 func CloseDB() {
 	if db != nil {
 		db.Close()
@@ -61,8 +42,10 @@ func CloseDB() {
 }
 
 // User functions
-func GetAllUsers() ([]model.User, error) {
-	rows, err := db.Query("SELECT id, name, email, registration_at, role FROM users")
+func GetAllUsers(ctx context.Context) ([]model.User, error) {
+	ctx, end := observe(ctx, "GetAllUsers")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT id, name, email, registration_at, role FROM users")
 	if err != nil {
 		return nil, err
 	}
@@ -80,8 +63,10 @@ func GetAllUsers() ([]model.User, error) {
 	return users, nil
 }
 
-func CreateUser(user model.User) (model.User, error) {
-	err := db.QueryRow(
+func CreateUser(ctx context.Context, user model.User) (model.User, error) {
+	ctx, end := observe(ctx, "CreateUser")
+	defer end()
+	err := db.QueryRowContext(ctx,
 		"INSERT INTO users (name, email, registration_at, role) VALUES ($1, $2, $3, $4) RETURNING id",
 		user.Name, user.Email, user.RegistrationAt, user.Role,
 	).Scan(&user.ID)
@@ -91,9 +76,11 @@ func CreateUser(user model.User) (model.User, error) {
 	return user, nil
 }
 
-func GetUserByID(id int) (model.User, error) {
+func GetUserByID(ctx context.Context, id int) (model.User, error) {
+	ctx, end := observe(ctx, "GetUserByID")
+	defer end()
 	var user model.User
-	err := db.QueryRow("SELECT id, name, email, registration_at, role FROM users WHERE id = $1", id).
+	err := db.QueryRowContext(ctx, "SELECT id, name, email, registration_at, role FROM users WHERE id = $1", id).
 		Scan(&user.ID, &user.Name, &user.Email, &user.RegistrationAt, &user.Role)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -104,24 +91,41 @@ func GetUserByID(id int) (model.User, error) {
 	return user, nil
 }
 
-func UpdateUser(id int, user model.User) (model.User, error) {
-	_, err := db.Exec(
+// GetUserByEmail returns the oldest user with the email address, compared
+// case-insensitively.
+func GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	ctx, end := observe(ctx, "GetUserByEmail")
+	defer end()
+	var user model.User
+	err := db.QueryRowContext(ctx, "SELECT id, name, email, registration_at, role FROM users WHERE LOWER(email) = LOWER($1) ORDER BY id LIMIT 1", email).
+		Scan(&user.ID, &user.Name, &user.Email, &user.RegistrationAt, &user.Role)
+	return user, err
+}
+
+func UpdateUser(ctx context.Context, id int, user model.User) (model.User, error) {
+	ctx, end := observe(ctx, "UpdateUser")
+	defer end()
+	_, err := db.ExecContext(ctx,
 		"UPDATE users SET name = $1, email = $2, role = $3 WHERE id = $4",
 		user.Name, user.Email, user.Role, id,
 	)
 	if err != nil {
 		return model.User{}, err
 	}
-	return GetUserByID(id)
+	return GetUserByID(ctx, id)
 }
 
-func DeleteUser(id int) error {
-	_, err := db.Exec("DELETE FROM users WHERE id = $1", id)
+func DeleteUser(ctx context.Context, id int) error {
+	ctx, end := observe(ctx, "DeleteUser")
+	defer end()
+	_, err := db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
 	return err
 }
 
-func GetTasksByUserID(userID int) ([]model.Task, error) {
-	rows, err := db.Query("SELECT id, title, description, priority, status, assignee_id, project_id, created_at, completed_at FROM tasks WHERE assignee_id = $1", userID)
+func GetTasksByUserID(ctx context.Context, userID int) ([]model.Task, error) {
+	ctx, end := observe(ctx, "GetTasksByUserID")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE assignee_id = $1", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -129,8 +133,8 @@ func GetTasksByUserID(userID int) ([]model.Task, error) {
 
 	var tasks []model.Task
 	for rows.Next() {
-		var task model.Task
-		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.AssigneeID, &task.ProjectID, &task.CreatedAt, &task.CompletedAt); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
@@ -139,38 +143,82 @@ func GetTasksByUserID(userID int) ([]model.Task, error) {
 	return tasks, nil
 }
 
-func SearchUsers(name string, email string) ([]model.User, error) {
-	var rows *sql.Rows
-	var err error
-	if name != "" && email != "" {
-		rows, err = db.Query("SELECT id, name, email, registration_at, role FROM users WHERE name = $1 AND email = $2", name, email)
-	} else if name != "" {
-		rows, err = db.Query("SELECT id, name, email, registration_at, role FROM users WHERE name = $1", name)
-	} else if email != "" {
-		rows, err = db.Query("SELECT id, name, email, registration_at, role FROM users WHERE email = $1", email)
-	} else {
+func SearchUsers(ctx context.Context, name string, email string) ([]model.User, error) {
+	ctx, end := observe(ctx, "SearchUsers")
+	defer end()
+	if name == "" && email == "" {
 		return nil, nil
 	}
+	where, args := UserSearchCondition(name, email)
+	users := []model.User{}
+	err := EachUser(ctx, where, "id", args, func(user model.User) error {
+		users = append(users, user)
+		return nil
+	})
+	return users, err
+}
+
+// UserSearchCondition matches users with exactly the given name and email,
+// ignoring empty ones. It matches nobody if both are empty.
+func UserSearchCondition(name, email string) (string, []any) {
+	if name == "" && email == "" {
+		return "FALSE", nil
+	}
+	return "($1 = '' OR name = $1) AND ($2 = '' OR email = $2)", []any{name, email}
+}
+
+// EachUser calls fn for every user matching where, in the given order,
+// without loading them all into memory.
+func EachUser(ctx context.Context, where, orderBy string, args []any, fn func(model.User) error) error {
+	ctx, end := observe(ctx, "EachUser")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT id, name, email, registration_at, role FROM users WHERE "+where+" ORDER BY "+orderBy, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var users []model.User
 	for rows.Next() {
 		var user model.User
 		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.RegistrationAt, &user.Role); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
 		}
-		users = append(users, user)
 	}
 
-	return users, nil
+	return rows.Err()
 }
 
 // Task functions
-func GetAllTasks() ([]model.Task, error) {
-	rows, err := db.Query("SELECT id, title, description, priority, status, assignee_id, project_id, created_at, completed_at FROM tasks")
+const taskColumns = "id, title, description, priority, status, assignee_id, project_id, created_at, completed_at, due_at"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row scanner) (model.Task, error) {
+	var task model.Task
+	var completedAt, dueAt sql.NullTime
+	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.AssigneeID, &task.ProjectID, &task.CreatedAt, &completedAt, &dueAt)
+	if err != nil {
+		return model.Task{}, err
+	}
+	task.CompletedAt = completedAt.Time
+	task.DueAt = dueAt.Time
+	return task, nil
+}
+
+// nullTime stores zero times as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func GetAllTasks(ctx context.Context) ([]model.Task, error) {
+	ctx, end := observe(ctx, "GetAllTasks")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks")
 	if err != nil {
 		return nil, err
 	}
@@ -178,8 +226,8 @@ func GetAllTasks() ([]model.Task, error) {
 
 	var tasks []model.Task
 	for rows.Next() {
-		var task model.Task
-		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.AssigneeID, &task.ProjectID, &task.CreatedAt, &task.CompletedAt); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
@@ -188,10 +236,12 @@ func GetAllTasks() ([]model.Task, error) {
 	return tasks, nil
 }
 
-func CreateTask(task model.Task) (model.Task, error) {
-	err := db.QueryRow(
-		"INSERT INTO tasks (title, description, priority, status, assignee_id, project_id, created_at, completed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
-		task.Title, task.Description, task.Priority, task.Status, task.AssigneeID, task.ProjectID, task.CreatedAt, task.CompletedAt,
+func CreateTask(ctx context.Context, task model.Task) (model.Task, error) {
+	ctx, end := observe(ctx, "CreateTask")
+	defer end()
+	err := db.QueryRowContext(ctx,
+		"INSERT INTO tasks (title, description, priority, status, assignee_id, project_id, created_at, completed_at, due_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id",
+		task.Title, task.Description, task.Priority, task.Status, task.AssigneeID, task.ProjectID, task.CreatedAt, nullTime(task.CompletedAt), nullTime(task.DueAt),
 	).Scan(&task.ID)
 	if err != nil {
 		return model.Task{}, err
@@ -199,10 +249,10 @@ func CreateTask(task model.Task) (model.Task, error) {
 	return task, nil
 }
 
-func GetTaskByID(id int) (model.Task, error) {
-	var task model.Task
-	err := db.QueryRow("SELECT id, title, description, priority, status, assignee_id, project_id, created_at, completed_at FROM tasks WHERE id = $1", id).
-		Scan(&task.ID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.AssigneeID, &task.ProjectID, &task.CreatedAt, &task.CompletedAt)
+func GetTaskByID(ctx context.Context, id int) (model.Task, error) {
+	ctx, end := observe(ctx, "GetTaskByID")
+	defer end()
+	task, err := scanTask(db.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = $1", id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return model.Task{}, err
@@ -212,55 +262,89 @@ func GetTaskByID(id int) (model.Task, error) {
 	return task, nil
 }
 
-func UpdateTask(id int, task model.Task) (model.Task, error) {
-	_, err := db.Exec(
-		"UPDATE tasks SET title = $1, description = $2, priority = $3, status = $4, assignee_id = $5, project_id = $6, completed_at = $7 WHERE id = $8",
-		task.Title, task.Description, task.Priority, task.Status, task.AssigneeID, task.ProjectID, task.CompletedAt, id,
+func UpdateTask(ctx context.Context, id int, task model.Task) (model.Task, error) {
+	ctx, end := observe(ctx, "UpdateTask")
+	defer end()
+	_, err := db.ExecContext(ctx,
+		`UPDATE tasks SET title = $1, description = $2, priority = $3, status = $4, assignee_id = $5, project_id = $6, completed_at = $7,
+		reminded_at = CASE WHEN due_at IS DISTINCT FROM $8 THEN NULL ELSE reminded_at END,
+		overdue_notified_at = CASE WHEN due_at IS DISTINCT FROM $8 THEN NULL ELSE overdue_notified_at END,
+		due_at = $8
+		WHERE id = $9`,
+		task.Title, task.Description, task.Priority, task.Status, task.AssigneeID, task.ProjectID, nullTime(task.CompletedAt), nullTime(task.DueAt), id,
 	)
 	if err != nil {
 		return model.Task{}, err
 	}
-	return GetTaskByID(id)
+	return GetTaskByID(ctx, id)
 }
 
-func DeleteTask(id int) error {
-	_, err := db.Exec("DELETE FROM tasks WHERE id = $1", id)
+func DeleteTask(ctx context.Context, id int) error {
+	ctx, end := observe(ctx, "DeleteTask")
+	defer end()
+	_, err := db.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)
 	return err
 }
 
-func SearchTasks(title, priority, status string, assigneeID, projectID int) ([]model.Task, error) {
-	query := fmt.Sprintf(
-		`
-		SELECT   *
-		FROM tasks
-		WHERE (STRPOS(LOWER(title), LOWER($1)) > 0 OR $1= '')
+func SearchTasks(ctx context.Context, title, priority, status string, assigneeID, projectID int) ([]model.Task, error) {
+	ctx, end := observe(ctx, "SearchTasks")
+	defer end()
+	where, args := TaskSearchCondition(title, priority, status, assigneeID, projectID)
+	return FilterTasks(ctx, where, "id", args...)
+}
+
+// TaskSearchCondition matches tasks by case-insensitive substrings of their
+// title, priority and status and by assignee and project, ignoring empty ones.
+func TaskSearchCondition(title, priority, status string, assigneeID, projectID int) (string, []any) {
+	return `(STRPOS(LOWER(title), LOWER($1)) > 0 OR $1= '')
 		AND (STRPOS(LOWER(priority), LOWER($2)) > 0 or $2 = '')
 		AND (STRPOS(LOWER(status), LOWER($3)) > 0 or $3 = '')
 		AND ($4 = 0 OR assignee_id = $4)
-		AND ($5 = 0 OR project_id = $5)
-		`)
-	rows, err := db.Query(query, title, priority, status, assigneeID, projectID)
+		AND ($5 = 0 OR project_id = $5)`, []any{title, priority, status, assigneeID, projectID}
+}
+
+// FilterTasks returns the tasks matching a condition over the columns of the
+// tasks table in the given order, both as compiled by the filter package.
+func FilterTasks(ctx context.Context, where, orderBy string, args ...any) ([]model.Task, error) {
+	ctx, end := observe(ctx, "FilterTasks")
+	defer end()
+	tasks := []model.Task{}
+	err := EachTask(ctx, where, orderBy, args, func(task model.Task) error {
+		tasks = append(tasks, task)
+		return nil
+	})
+	return tasks, err
+}
+
+// EachTask calls fn for every task matching where, in the given order,
+// without loading them all into memory.
+func EachTask(ctx context.Context, where, orderBy string, args []any, fn func(model.Task) error) error {
+	ctx, end := observe(ctx, "EachTask")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE "+where+" ORDER BY "+orderBy, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var tasks []model.Task
 
 	for rows.Next() {
-		var task model.Task
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.AssigneeID, &task.ProjectID, &task.CreatedAt, &task.CompletedAt)
+		task, err := scanTask(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
 		}
-		tasks = append(tasks, task)
-
 	}
-	return tasks, nil
+
+	return rows.Err()
 }
 
 // Project functions
-func GetAllProjects() ([]model.Project, error) {
-	rows, err := db.Query("SELECT id, title, description, start_date, end_date, manager_id FROM projects")
+func GetAllProjects(ctx context.Context) ([]model.Project, error) {
+	ctx, end := observe(ctx, "GetAllProjects")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT id, title, description, start_date, end_date, manager_id FROM projects")
 	if err != nil {
 		return nil, err
 	}
@@ -278,16 +362,18 @@ func GetAllProjects() ([]model.Project, error) {
 	return projects, nil
 }
 
-func CreateProject(project model.Project) (model.Project, error) {
+func CreateProject(ctx context.Context, project model.Project) (model.Project, error) {
+	ctx, end := observe(ctx, "CreateProject")
+	defer end()
 	var err error
 	if project.EndDate.IsZero() {
-		err = db.QueryRow(
+		err = db.QueryRowContext(ctx,
 			"INSERT INTO projects (title, description, start_date, end_date, manager_id) VALUES ($1, $2, $3, $4, $5) RETURNING id",
 			project.Title, project.Description, project.StartDate, sql.NullTime{}, project.ManagerID,
 		).Scan(&project.ID)
 
 	} else {
-		err = db.QueryRow(
+		err = db.QueryRowContext(ctx,
 			"INSERT INTO projects (title, description, start_date, end_date, manager_id) VALUES ($1, $2, $3, $4, $5) RETURNING id",
 			project.Title, project.Description, project.StartDate, project.EndDate, project.ManagerID,
 		).Scan(&project.ID)
@@ -298,9 +384,11 @@ func CreateProject(project model.Project) (model.Project, error) {
 	return project, nil
 }
 
-func GetProjectByID(id int) (model.Project, error) {
+func GetProjectByID(ctx context.Context, id int) (model.Project, error) {
+	ctx, end := observe(ctx, "GetProjectByID")
+	defer end()
 	var project model.Project
-	err := db.QueryRow("SELECT id, title, description, start_date, end_date, manager_id FROM projects WHERE id = $1", id).
+	err := db.QueryRowContext(ctx, "SELECT id, title, description, start_date, end_date, manager_id FROM projects WHERE id = $1", id).
 		Scan(&project.ID, &project.Title, &project.Description, &project.StartDate, &project.EndDate, &project.ManagerID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -311,24 +399,30 @@ func GetProjectByID(id int) (model.Project, error) {
 	return project, nil
 }
 
-func UpdateProject(id int, project model.Project) (model.Project, error) {
-	_, err := db.Exec(
+func UpdateProject(ctx context.Context, id int, project model.Project) (model.Project, error) {
+	ctx, end := observe(ctx, "UpdateProject")
+	defer end()
+	_, err := db.ExecContext(ctx,
 		"UPDATE projects SET title = $1, description = $2, start_date = $3, end_date = $4, manager_id = $5 WHERE id = $6",
 		project.Title, project.Description, project.StartDate, project.EndDate, project.ManagerID, id,
 	)
 	if err != nil {
 		return model.Project{}, err
 	}
-	return GetProjectByID(id)
+	return GetProjectByID(ctx, id)
 }
 
-func DeleteProject(id int) error {
-	_, err := db.Exec("DELETE FROM projects WHERE id = $1", id)
+func DeleteProject(ctx context.Context, id int) error {
+	ctx, end := observe(ctx, "DeleteProject")
+	defer end()
+	_, err := db.ExecContext(ctx, "DELETE FROM projects WHERE id = $1", id)
 	return err
 }
 
-func GetTasksByProjectID(projectID int) ([]model.Task, error) {
-	rows, err := db.Query("SELECT id, title, description, priority, status, assignee_id, project_id, created_at, completed_at FROM tasks WHERE project_id = $1", projectID)
+func GetTasksByProjectID(ctx context.Context, projectID int) ([]model.Task, error) {
+	ctx, end := observe(ctx, "GetTasksByProjectID")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE project_id = $1", projectID)
 	if err != nil {
 		return nil, err
 	}
@@ -336,8 +430,8 @@ func GetTasksByProjectID(projectID int) ([]model.Task, error) {
 
 	var tasks []model.Task
 	for rows.Next() {
-		var task model.Task
-		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.AssigneeID, &task.ProjectID, &task.CreatedAt, &task.CompletedAt); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
@@ -346,30 +440,45 @@ func GetTasksByProjectID(projectID int) ([]model.Task, error) {
 	return tasks, nil
 }
 
-func SearchProjects(title string, managerID int) ([]model.Project, error) {
-	query := fmt.Sprintf(
-		`
-		SELECT   *
-		FROM projects
-		WHERE (STRPOS(LOWER(title), LOWER($1)) > 0 OR $1= '')
-		AND ($2 = 0 OR manager_id = $2)
-		`)
-	rows, err := db.Query(query, title, managerID)
+func SearchProjects(ctx context.Context, title string, managerID int) ([]model.Project, error) {
+	ctx, end := observe(ctx, "SearchProjects")
+	defer end()
+	where, args := ProjectSearchCondition(title, managerID)
+	projects := []model.Project{}
+	err := EachProject(ctx, where, "id", args, func(project model.Project) error {
+		projects = append(projects, project)
+		return nil
+	})
+	return projects, err
+}
+
+// ProjectSearchCondition matches projects by a case-insensitive substring of
+// their title and by manager, ignoring empty ones.
+func ProjectSearchCondition(title string, managerID int) (string, []any) {
+	return `(STRPOS(LOWER(title), LOWER($1)) > 0 OR $1= '')
+		AND ($2 = 0 OR manager_id = $2)`, []any{title, managerID}
+}
+
+// EachProject calls fn for every project matching where, in the given order,
+// without loading them all into memory.
+func EachProject(ctx context.Context, where, orderBy string, args []any, fn func(model.Project) error) error {
+	ctx, end := observe(ctx, "EachProject")
+	defer end()
+	rows, err := db.QueryContext(ctx, "SELECT id, title, description, start_date, end_date, manager_id FROM projects WHERE "+where+" ORDER BY "+orderBy, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	var projects []model.Project
 
 	for rows.Next() {
 		var project model.Project
-		err := rows.Scan(&project.ID, &project.Title, &project.Description, &project.StartDate, &project.EndDate, &project.ManagerID)
-		if err != nil {
-			return nil, err
+		if err := rows.Scan(&project.ID, &project.Title, &project.Description, &project.StartDate, &project.EndDate, &project.ManagerID); err != nil {
+			return err
+		}
+		if err := fn(project); err != nil {
+			return err
 		}
-		projects = append(projects, project)
-
 	}
-	return projects, nil
 
+	return rows.Err()
 }
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+	"fmt"
+	"strings"
+)
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+// escapeHTML returns SQL that HTML-escapes the text expr, so that the <mark>
+// tags added by ts_headline are the only markup in a snippet. The parser
+// reads entities such as &amp; as tokens of their own, so words next to
+// them are still highlighted.
+func escapeHTML(expr string) string {
+	return `replace(replace(replace(replace(replace(` + expr + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+}
+
+// searchQueries select matches of tsquery $1 for each searchable type as
+// (type, id, title, doc, rank, task_id), where doc is the text to highlight.
+// Each names its columns, as any of them may come first in the union.
+var searchQueries = map[string]string{
+	"task": `
+			SELECT 'task' AS type, t.id, t.title, t.title || ' ' || t.description AS doc,
+				ts_rank(t.search_vector, q) AS rank, 0 AS task_id
+			FROM tasks t, to_tsquery('simple', $1) q
+			WHERE t.search_vector @@ q`,
+	"project": `
+			SELECT 'project' AS type, p.id, p.title, p.title || ' ' || p.description AS doc,
+				ts_rank(p.search_vector, q) AS rank, 0 AS task_id
+			FROM projects p, to_tsquery('simple', $1) q
+			WHERE p.search_vector @@ q`,
+	"comment": `
+			SELECT 'comment' AS type, c.id, t.title, c.body AS doc,
+				ts_rank(c.search_vector, q) AS rank, c.task_id
+			FROM comments c JOIN tasks t ON t.id = c.task_id, to_tsquery('simple', $1) q
+			WHERE c.search_vector @@ q`,
+	"user": `
+			SELECT 'user' AS type, u.id, u.name AS title, u.name || ' ' || u.email AS doc,
+				ts_rank(u.search_vector, q) AS rank, 0 AS task_id
+			FROM users u, to_tsquery('simple', $1) q
+			WHERE u.search_vector @@ q`,
+}
+
+// SearchTypes lists the types that can be searched, in result order for ties.
+var SearchTypes = []string{"task", "project", "comment", "user"}
+
+// Search runs a full-text query, given in tsquery syntax, across the given
+// types and returns the best matches first.
+func Search(ctx context.Context, tsquery string, types []string, limit, offset int) ([]model.SearchResult, error) {
+	ctx, end := observe(ctx, "Search")
+	defer end()
+	var parts []string
+	for _, t := range types {
+		query, ok := searchQueries[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown search type %q", t)
+		}
+		parts = append(parts, query)
+	}
+	if len(parts) == 0 {
+		return []model.SearchResult{}, nil
+	}
+
+	// Snippets are costly, so only the page of best matches gets them.
+	rows, err := db.QueryContext(ctx, `
+		SELECT m.type, m.id, m.title,
+			ts_headline('simple', `+escapeHTML("m.doc")+`, to_tsquery('simple', $1), '`+headlineOptions+`'),
+			m.rank, m.task_id
+		FROM (`+strings.Join(parts, "\n\t\t\tUNION ALL")+`
+			ORDER BY rank DESC, type, id LIMIT $2 OFFSET $3
+		) m
+		ORDER BY m.rank DESC, m.type, m.id`,
+		tsquery, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []model.SearchResult{}
+	for rows.Next() {
+		var result model.SearchResult
+		if err := rows.Scan(&result.Type, &result.ID, &result.Title, &result.Snippet, &result.Rank, &result.TaskID); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+)
+
+// Saved view functions
+const viewColumns = "id, owner_id, COALESCE(project_id, 0), name, filter, sort, shared, created_at, updated_at"
+
+// memberProjects selects the projects user $1 is a member of: those they
+// manage or have tasks in. Watching a project does not make a member, as
+// anyone can watch any project.
+const memberProjects = `
+	SELECT id FROM projects WHERE manager_id = $1
+	UNION SELECT project_id FROM tasks WHERE assignee_id = $1`
+
+func scanView(row scanner) (model.SavedView, error) {
+	var v model.SavedView
+	err := row.Scan(&v.ID, &v.OwnerID, &v.ProjectID, &v.Name, &v.Filter, &v.Sort, &v.Shared, &v.CreatedAt, &v.UpdatedAt)
+	return v, err
+}
+
+func nullID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func CreateView(ctx context.Context, v model.SavedView) (model.SavedView, error) {
+	ctx, end := observe(ctx, "CreateView")
+	defer end()
+	err := db.QueryRowContext(ctx,
+		"INSERT INTO saved_views (owner_id, project_id, name, filter, sort, shared, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		v.OwnerID, nullID(v.ProjectID), v.Name, v.Filter, v.Sort, v.Shared, v.CreatedAt, v.UpdatedAt,
+	).Scan(&v.ID)
+	if err != nil {
+		return model.SavedView{}, err
+	}
+	return v, nil
+}
+
+func GetViewByID(ctx context.Context, id int) (model.SavedView, error) {
+	ctx, end := observe(ctx, "GetViewByID")
+	defer end()
+	return scanView(db.QueryRowContext(ctx, "SELECT "+viewColumns+" FROM saved_views WHERE id = $1", id))
+}
+
+// GetViewsVisibleTo returns the user's own views followed by views shared
+// with them through their projects.
+func GetViewsVisibleTo(ctx context.Context, userID int) ([]model.SavedView, error) {
+	ctx, end := observe(ctx, "GetViewsVisibleTo")
+	defer end()
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+viewColumns+` FROM saved_views
+		WHERE owner_id = $1 OR (shared AND project_id IN (`+memberProjects+`))
+		ORDER BY owner_id <> $1, name, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := []model.SavedView{}
+	for rows.Next() {
+		v, err := scanView(rows)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, v)
+	}
+
+	return views, rows.Err()
+}
+
+func UpdateView(ctx context.Context, id int, v model.SavedView) (model.SavedView, error) {
+	ctx, end := observe(ctx, "UpdateView")
+	defer end()
+	_, err := db.ExecContext(ctx,
+		"UPDATE saved_views SET project_id = $1, name = $2, filter = $3, sort = $4, shared = $5, updated_at = $6 WHERE id = $7",
+		nullID(v.ProjectID), v.Name, v.Filter, v.Sort, v.Shared, v.UpdatedAt, id,
+	)
+	if err != nil {
+		return model.SavedView{}, err
+	}
+	return GetViewByID(ctx, id)
+}
+
+func DeleteView(ctx context.Context, id int) error {
+	ctx, end := observe(ctx, "DeleteView")
+	defer end()
+	_, err := db.ExecContext(ctx, "DELETE FROM saved_views WHERE id = $1", id)
+	return err
+}
+
+func IsProjectMember(ctx context.Context, projectID, userID int) (bool, error) {
+	ctx, end := observe(ctx, "IsProjectMember")
+	defer end()
+	var member bool
+	err := db.QueryRowContext(ctx, "SELECT $2::int IN ("+memberProjects+")", userID, projectID).Scan(&member)
+	return member, err
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"HL_project_management/internal/model"
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// Watcher functions
+func WatchTask(ctx context.Context, taskID, userID int) error {
+	ctx, end := observe(ctx, "WatchTask")
+	defer end()
+	_, err := db.ExecContext(ctx, "INSERT INTO task_watchers (task_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", taskID, userID)
+	return err
+}
+
+func UnwatchTask(ctx context.Context, taskID, userID int) error {
+	ctx, end := observe(ctx, "UnwatchTask")
+	defer end()
+	_, err := db.ExecContext(ctx, "DELETE FROM task_watchers WHERE task_id = $1 AND user_id = $2", taskID, userID)
+	return err
+}
+
+func WatchProject(ctx context.Context, projectID, userID int) error {
+	ctx, end := observe(ctx, "WatchProject")
+	defer end()
+	_, err := db.ExecContext(ctx, "INSERT INTO project_watchers (project_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", projectID, userID)
+	return err
+}
+
+func UnwatchProject(ctx context.Context, projectID, userID int) error {
+	ctx, end := observe(ctx, "UnwatchProject")
+	defer end()
+	_, err := db.ExecContext(ctx, "DELETE FROM project_watchers WHERE project_id = $1 AND user_id = $2", projectID, userID)
+	return err
+}
+
+func GetTaskWatchers(ctx context.Context, taskID int) ([]model.User, error) {
+	ctx, end := observe(ctx, "GetTaskWatchers")
+	defer end()
+	return queryUsers(ctx, `
+		SELECT u.id, u.name, u.email, u.registration_at, u.role
+		FROM users u JOIN task_watchers w ON w.user_id = u.id
+		WHERE w.task_id = $1
+		ORDER BY u.id`, taskID)
+}
+
+func GetProjectWatchers(ctx context.Context, projectID int) ([]model.User, error) {
+	ctx, end := observe(ctx, "GetProjectWatchers")
+	defer end()
+	return queryUsers(ctx, `
+		SELECT u.id, u.name, u.email, u.registration_at, u.role
+		FROM users u JOIN project_watchers w ON w.user_id = u.id
+		WHERE w.project_id = $1
+		ORDER BY u.id`, projectID)
+}
+
+// GetTaskWatcherIDs returns everyone watching the task directly or through
+// its project.
+func GetTaskWatcherIDs(ctx context.Context, taskID int) ([]int, error) {
+	ctx, end := observe(ctx, "GetTaskWatcherIDs")
+	defer end()
+	return queryIDs(ctx, `
+		SELECT user_id FROM task_watchers WHERE task_id = $1
+		UNION
+		SELECT w.user_id FROM project_watchers w JOIN tasks t ON t.project_id = w.project_id
+		WHERE t.id = $1`, taskID)
+}
+
+// GetUserIDsByHandles resolves @mention handles to users. A handle matches
+// the local part of a user's email or their name with spaces removed,
+// ignoring case.
+func GetUserIDsByHandles(ctx context.Context, handles []string) ([]int, error) {
+	ctx, end := observe(ctx, "GetUserIDsByHandles")
+	defer end()
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	return queryIDs(ctx, `
+		SELECT id FROM users
+		WHERE LOWER(SPLIT_PART(email, '@', 1)) = ANY($1)
+		OR LOWER(REPLACE(name, ' ', '')) = ANY($1)`, pq.Array(handles))
+}
+
+func queryUsers(ctx context.Context, query string, args ...any) ([]model.User, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []model.User{}
+	for rows.Next() {
+		var user model.User
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.RegistrationAt, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func queryIDs(ctx context.Context, query string, args ...any) ([]int, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
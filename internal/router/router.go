@@ -1,22 +1,67 @@
 package router
 
 import (
+	"HL_project_management/internal/auth"
+	"HL_project_management/internal/config"
+	"HL_project_management/internal/graph"
 	"HL_project_management/internal/handler"
+	"HL_project_management/internal/idempotency"
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/metrics"
+	"HL_project_management/internal/ratelimit"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
-func SetupRouter() *mux.Router {
+// SetupRouter registers the API routes. Optional parts are left out unless
+// enabled in cfg.Features; the GraphiQL IDE is always served in development.
+func SetupRouter(cfg *config.Config) *mux.Router {
 	r := mux.NewRouter()
+	// Spans are named after the route template, such as /tasks/{id}.
+	r.Use(otelmux.Middleware(cfg.Tracing.ServiceName))
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
+	if cfg.RateLimit.Enabled {
+		r.Use(ratelimit.BeforeAuth)
+	}
+	r.Use(auth.Middleware)
+	r.Use(auth.RequireScopes(routeScopes))
+	if cfg.RateLimit.Enabled {
+		r.Use(ratelimit.Middleware)
+	}
 
 	// Swagger docs
-	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	if cfg.Features.Swagger {
+		r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	}
 	r.HandleFunc("/health", handler.HealthCheck).Methods("GET")
+	r.HandleFunc("/livez", handler.Livez).Methods("GET")
+	r.HandleFunc("/readyz", handler.Readyz).Methods("GET")
+	if cfg.Features.Metrics {
+		r.Handle("/metrics", metrics.Handler()).Methods("GET")
+	}
+	r.HandleFunc("/search", handler.Search).Methods("GET")
+	if cfg.OIDC.Issuer != "" {
+		r.HandleFunc("/auth/oidc/login", handler.OIDCLogin).Methods("GET")
+		r.HandleFunc("/auth/oidc/callback", handler.OIDCCallback).Methods("GET")
+	}
+	if cfg.Admin.Token != "" {
+		admin := r.PathPrefix("/admin").Subrouter()
+		admin.Use(auth.RequireToken(cfg.Admin.Token))
+		admin.HandleFunc("/log-level", handler.GetLogLevel).Methods("GET")
+		admin.HandleFunc("/log-level", handler.SetLogLevel).Methods("PUT")
+	}
+	if cfg.Features.GraphQL {
+		playground := cfg.Env == config.Development || cfg.Features.GraphiQL
+		r.Handle("/graphql", graph.NewHandler(playground)).Methods("GET", "POST")
+	}
 
 	r.HandleFunc("/users", handler.GetAllUsers).Methods("GET")
-	r.HandleFunc("/users", handler.CreateUser).Methods("POST")
+	r.Handle("/users", idempotency.Handler(handler.CreateUser)).Methods("POST")
+	r.Handle("/users:batch", idempotency.Handler(handler.BatchUsers)).Methods("POST")
 	r.HandleFunc("/users/{id}", handler.GetUserByID).Methods("GET")
 	r.HandleFunc("/users/{id}", handler.UpdateUser).Methods("PUT")
 	r.HandleFunc("/users/{id}", handler.DeleteUser).Methods("DELETE")
@@ -24,24 +69,105 @@ func SetupRouter() *mux.Router {
 	r.HandleFunc("/search/users", handler.SearchUsers).Methods("GET")
 
 	r.HandleFunc("/tasks", handler.GetAllTasks).Methods("GET")
-	r.HandleFunc("/tasks", handler.CreateTask).Methods("POST")
+	r.Handle("/tasks", idempotency.Handler(handler.CreateTask)).Methods("POST")
+	r.Handle("/tasks:batch", idempotency.Handler(handler.BatchTasks)).Methods("POST")
 	r.HandleFunc("/tasks/{id}", handler.GetTaskByID).Methods("GET")
 	r.HandleFunc("/tasks/{id}", handler.UpdateTask).Methods("PUT")
 	r.HandleFunc("/tasks/{id}", handler.DeleteTask).Methods("DELETE")
+	r.HandleFunc("/tasks/{id}/watchers", handler.GetTaskWatchers).Methods("GET")
+	r.HandleFunc("/tasks/{id}/watchers", handler.WatchTask).Methods("POST")
+	r.HandleFunc("/tasks/{id}/watchers", handler.UnwatchTask).Methods("DELETE")
+	r.HandleFunc("/tasks/{id}/comments", handler.GetTaskComments).Methods("GET")
+	r.Handle("/tasks/{id}/comments", idempotency.Handler(handler.CreateTaskComment)).Methods("POST")
+	r.HandleFunc("/tasks/{id}/attachments", handler.GetTaskAttachments).Methods("GET")
+	r.Handle("/tasks/{id}/attachments", handler.Streaming(http.HandlerFunc(handler.UploadTaskAttachment))).Methods("POST")
+	r.Handle("/tasks/{id}/attachments/{attachmentId}", handler.Streaming(http.HandlerFunc(handler.DownloadTaskAttachment))).Methods("GET")
+	r.HandleFunc("/tasks/{id}/attachments/{attachmentId}", handler.DeleteTaskAttachment).Methods("DELETE")
 	r.HandleFunc("/search/tasks", handler.SearchTasks).Methods("GET")
 	//
 	r.HandleFunc("/projects", handler.GetAllProjects).Methods("GET")
-	r.HandleFunc("/projects", handler.CreateProject).Methods("POST")
+	r.Handle("/projects", idempotency.Handler(handler.CreateProject)).Methods("POST")
+	r.Handle("/projects:batch", idempotency.Handler(handler.BatchProjects)).Methods("POST")
 	r.HandleFunc("/projects/{id}", handler.GetProjectByID).Methods("GET")
 	r.HandleFunc("/projects/{id}", handler.UpdateProject).Methods("PUT")
 	r.HandleFunc("/projects/{id}", handler.DeleteProject).Methods("DELETE")
 	r.HandleFunc("/projects/{id}/tasks", handler.GetTasksByProjectID).Methods("GET")
+	r.Handle("/projects/{id}/import", handler.Streaming(idempotency.Handler(handler.ImportTasks))).Methods("POST")
+	r.HandleFunc("/projects/{id}/watchers", handler.GetProjectWatchers).Methods("GET")
+	r.HandleFunc("/projects/{id}/watchers", handler.WatchProject).Methods("POST")
+	r.HandleFunc("/projects/{id}/watchers", handler.UnwatchProject).Methods("DELETE")
 	r.HandleFunc("/search/projects", handler.SearchProjects).Methods("GET")
 
+	r.HandleFunc("/me/views", handler.GetMyViews).Methods("GET")
+	r.Handle("/me/views", idempotency.Handler(handler.CreateView)).Methods("POST")
+	r.HandleFunc("/me/views/{id}", handler.GetMyView).Methods("GET")
+	r.HandleFunc("/me/views/{id}", handler.UpdateView).Methods("PUT")
+	r.HandleFunc("/me/views/{id}", handler.DeleteView).Methods("DELETE")
+	r.HandleFunc("/views/{id}/tasks", handler.GetViewTasks).Methods("GET")
+
+	r.HandleFunc("/me/api-keys", handler.GetMyAPIKeys).Methods("GET")
+	r.HandleFunc("/me/api-keys", handler.CreateAPIKey).Methods("POST")
+	r.HandleFunc("/me/api-keys/{id}", handler.DeleteAPIKey).Methods("DELETE")
+
+	r.HandleFunc("/me/notifications", handler.GetMyNotifications).Methods("GET")
+	r.HandleFunc("/me/notifications/read-all", handler.MarkAllNotificationsRead).Methods("POST")
+	r.HandleFunc("/me/notifications/{id}/read", handler.MarkNotificationRead).Methods("POST")
+
 	// Default handler for unsupported methods
-	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	r.MethodNotAllowedHandler = logging.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	})
+	}))
+	r.NotFoundHandler = logging.Middleware(http.NotFoundHandler())
 
 	return r
 }
+
+// routeScopes lists the scopes API keys and scoped tokens need for each
+// route. Routes missing here, such as GraphQL, saved views, notifications
+// and managing API keys, need a personal login.
+var routeScopes = map[string][]string{
+	"GET /health":  {},
+	"GET /livez":   {},
+	"GET /readyz":  {},
+	"GET /metrics": {},
+	"GET /search":  {auth.TasksRead, auth.ProjectsRead, auth.UsersRead},
+
+	"GET /users":            {auth.UsersRead},
+	"POST /users":           {auth.UsersWrite},
+	"POST /users:batch":     {auth.UsersWrite},
+	"GET /users/{id}":       {auth.UsersRead},
+	"PUT /users/{id}":       {auth.UsersWrite},
+	"DELETE /users/{id}":    {auth.UsersWrite},
+	"GET /users/{id}/tasks": {auth.UsersRead, auth.TasksRead},
+	"GET /search/users":     {auth.UsersRead},
+
+	"GET /tasks":                                    {auth.TasksRead},
+	"POST /tasks":                                   {auth.TasksWrite},
+	"POST /tasks:batch":                             {auth.TasksWrite},
+	"GET /tasks/{id}":                               {auth.TasksRead},
+	"PUT /tasks/{id}":                               {auth.TasksWrite},
+	"DELETE /tasks/{id}":                            {auth.TasksWrite},
+	"GET /tasks/{id}/watchers":                      {auth.TasksRead},
+	"POST /tasks/{id}/watchers":                     {auth.TasksWrite},
+	"DELETE /tasks/{id}/watchers":                   {auth.TasksWrite},
+	"GET /tasks/{id}/comments":                      {auth.TasksRead},
+	"POST /tasks/{id}/comments":                     {auth.TasksWrite},
+	"GET /tasks/{id}/attachments":                   {auth.TasksRead},
+	"POST /tasks/{id}/attachments":                  {auth.TasksWrite},
+	"GET /tasks/{id}/attachments/{attachmentId}":    {auth.TasksRead},
+	"DELETE /tasks/{id}/attachments/{attachmentId}": {auth.TasksWrite},
+	"GET /search/tasks":                             {auth.TasksRead},
+
+	"GET /projects":                  {auth.ProjectsRead},
+	"POST /projects":                 {auth.ProjectsWrite},
+	"POST /projects:batch":           {auth.ProjectsWrite},
+	"GET /projects/{id}":             {auth.ProjectsRead},
+	"PUT /projects/{id}":             {auth.ProjectsWrite},
+	"DELETE /projects/{id}":          {auth.ProjectsAdmin},
+	"GET /projects/{id}/tasks":       {auth.ProjectsRead, auth.TasksRead},
+	"POST /projects/{id}/import":     {auth.TasksWrite},
+	"GET /projects/{id}/watchers":    {auth.ProjectsRead},
+	"POST /projects/{id}/watchers":   {auth.ProjectsWrite},
+	"DELETE /projects/{id}/watchers": {auth.ProjectsWrite},
+	"GET /search/projects":           {auth.ProjectsRead},
+}
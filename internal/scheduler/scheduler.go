@@ -0,0 +1,151 @@
+// Package scheduler periodically looks for tasks that are about to be due or
+// are overdue, notifies their assignees and publishes events about them.
+//
+// Every replica runs a scheduler, but only the one holding a Postgres advisory
+// lock does any work, so reminders are sent once per cluster.
+package scheduler
+
+import (
+	"HL_project_management/internal/events"
+	"HL_project_management/internal/health"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("HL_project_management/internal/scheduler")
+
+// lockName names the scheduler's advisory lock.
+const lockName = "pm.scheduler"
+
+const (
+	NotificationDueSoon = "task_due_soon"
+	NotificationOverdue = "task_overdue"
+)
+
+type Config struct {
+	// Interval is how often the scheduler checks for due tasks.
+	Interval time.Duration
+	// Window is how far ahead of a task's due date its reminder is sent.
+	Window time.Duration
+}
+
+type Scheduler struct {
+	cfg  Config
+	lock *sql.Conn
+}
+
+func New(cfg Config) *Scheduler {
+	return &Scheduler{cfg: cfg}
+}
+
+// Run checks for due tasks every Interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	defer s.resign()
+	worker := health.NewWorker("scheduler", s.cfg.Interval)
+	defer worker.Stop()
+
+	for {
+		s.tick(ctx)
+		worker.Beat()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	if !s.lead(ctx) {
+		return
+	}
+	ctx, span := tracer.Start(ctx, "scheduler.tick")
+	defer span.End()
+
+	dueSoon, err := repository.ClaimTasksDueBefore(ctx, time.Now().Add(s.cfg.Window), func(task model.Task) model.Notification {
+		return notification(task, NotificationDueSoon, fmt.Sprintf("Task %q is due %s", task.Title, task.DueAt.Format(time.RFC1123)))
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "scheduler: could not remind of tasks due soon", "error", err)
+	}
+	for _, task := range dueSoon {
+		publish(task, events.TaskDueSoon)
+	}
+
+	overdue, err := repository.ClaimOverdueTasks(ctx, func(task model.Task) model.Notification {
+		return notification(task, NotificationOverdue, fmt.Sprintf("Task %q was due %s", task.Title, task.DueAt.Format(time.RFC1123)))
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "scheduler: could not notify of overdue tasks", "error", err)
+	}
+	for _, task := range overdue {
+		publish(task, events.TaskOverdue)
+	}
+}
+
+// notification tells the assignee of task about it. It is stored together
+// with the claim on the task, so that a failed insert is retried next time.
+func notification(task model.Task, kind, message string) model.Notification {
+	return model.Notification{
+		UserID:    task.AssigneeID,
+		Type:      kind,
+		TaskID:    task.ID,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+}
+
+func publish(task model.Task, eventType events.Type) {
+	events.Publish(events.Event{
+		Type:      eventType,
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		UserID:    task.AssigneeID,
+	})
+}
+
+// lead reports whether this replica is the leader, trying to become one if it
+// is not. Leadership is lost when the connection holding the lock breaks.
+func (s *Scheduler) lead(ctx context.Context) bool {
+	if s.lock != nil {
+		if err := s.lock.PingContext(ctx); err == nil {
+			return true
+		}
+		slog.Warn("scheduler: lost leadership")
+		s.lock.Close()
+		s.lock = nil
+	}
+
+	conn, err := repository.TryAdvisoryLock(ctx, lockName)
+	if err != nil {
+		slog.Error("scheduler: could not acquire leader lock", "error", err)
+		return false
+	}
+	if conn == nil {
+		return false
+	}
+	slog.Info("scheduler: acquired leadership")
+	s.lock = conn
+	return true
+}
+
+func (s *Scheduler) resign() {
+	if s.lock == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := repository.ReleaseAdvisoryLock(ctx, s.lock, lockName); err != nil {
+		slog.Error("scheduler: could not release leader lock", "error", err)
+	}
+	s.lock = nil
+}
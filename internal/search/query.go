@@ -0,0 +1,101 @@
+// Package search translates user-facing search queries into Postgres full-text
+// search queries.
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var ErrEmptyQuery = errors.New("search query has no words")
+
+// ToTSQuery converts a search query into tsquery syntax. Words are matched
+// together; "quoted phrases" match adjacent words; word* matches any word
+// starting with word; -word excludes matches; OR matches either side.
+//
+//	design "api docs" -draft      => design & (api <-> docs) & !draft
+//	deploy* OR release            => deploy:* | release
+//
+// Only letters and digits reach the result, so it is always a valid tsquery.
+func ToTSQuery(query string) (string, error) {
+	var groups [][]string // terms ANDed within a group, groups ORed
+	var terms []string
+	input := []rune(query)
+	for i := 0; i < len(input); {
+		if unicode.IsSpace(input[i]) {
+			i++
+			continue
+		}
+
+		negate := false
+		if input[i] == '-' {
+			negate = true
+			i++
+		}
+
+		var text string
+		phrase := false
+		if i < len(input) && input[i] == '"' {
+			end := i + 1
+			for end < len(input) && input[end] != '"' {
+				end++
+			}
+			if end == len(input) {
+				return "", errors.New("unterminated quote in search query")
+			}
+			text, phrase, i = string(input[i+1:end]), true, end+1
+		} else {
+			start := i
+			for i < len(input) && !unicode.IsSpace(input[i]) && input[i] != '"' {
+				i++
+			}
+			text = string(input[start:i])
+		}
+
+		if text == "OR" && !phrase && !negate {
+			if len(terms) > 0 {
+				groups = append(groups, terms)
+				terms = nil
+			}
+			continue
+		}
+		if term := toTerm(text, phrase); term != "" {
+			if negate {
+				term = "!" + term
+			}
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) > 0 {
+		groups = append(groups, terms)
+	}
+	if len(groups) == 0 {
+		return "", ErrEmptyQuery
+	}
+
+	parts := make([]string, len(groups))
+	for i, group := range groups {
+		parts[i] = strings.Join(group, " & ")
+	}
+	return strings.Join(parts, " | "), nil
+}
+
+// toTerm turns a word or phrase into a tsquery operand. Punctuation splits
+// words, so "e-mail" matches the same as the phrase "e mail".
+func toTerm(text string, phrase bool) string {
+	prefix := !phrase && strings.HasSuffix(text, "*")
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	if prefix {
+		words[len(words)-1] += ":*"
+	}
+	if len(words) == 1 {
+		return words[0]
+	}
+	return "(" + strings.Join(words, " <-> ") + ")"
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToTSQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"design", "design"},
+		{"Design API", "design & api"},
+		{`design "api docs" -draft`, "design & (api <-> docs) & !draft"},
+		{"deploy* OR release", "deploy:* | release"},
+		{"a b OR c OR d", "a & b | c | d"},
+		{"Привет МИР", "привет & мир"},
+
+		// Quoting
+		{`"api docs"`, "(api <-> docs)"},
+		{`-"draft version"`, "!(draft <-> version)"},
+		{`"deploy*"`, "deploy"},
+		{`"OR"`, "or"},
+		{`a"b c"`, "a & (b <-> c)"},
+		{`"a b"*`, "(a <-> b)"},
+
+		// Prefixes
+		{"dep*", "dep:*"},
+		{"-dep*", "!dep:*"},
+		{"e-mail*", "(e <-> mail:*)"},
+		{"*dep", "dep"},
+
+		// OR only between terms
+		{"or", "or"},
+		{"-OR", "!or"},
+		{"OR OR a", "a"},
+		{"a OR", "a"},
+
+		// Punctuation never reaches the tsquery.
+		{"it's", "(it <-> s)"},
+		{"a & !b:*", "a & b:*"},
+		{"a | b", "a & b"},
+		{"(a) <-> b", "a & b"},
+	}
+	for _, tt := range tests {
+		got, err := ToTSQuery(tt.query)
+		if err != nil || got != tt.want {
+			t.Errorf("ToTSQuery(%q) = %q, %v, want %q", tt.query, got, err, tt.want)
+		}
+	}
+}
+
+func TestToTSQueryEmpty(t *testing.T) {
+	for _, query := range []string{"", "   ", "\t\n", "OR", "OR OR", `""`, "-", "--", "*", `- "" * !&|`} {
+		if got, err := ToTSQuery(query); !errors.Is(err, ErrEmptyQuery) {
+			t.Errorf("ToTSQuery(%q) = %q, %v, want ErrEmptyQuery", query, got, err)
+		}
+	}
+}
+
+func TestToTSQueryUnterminatedQuote(t *testing.T) {
+	for _, query := range []string{`"api docs`, `design "`, `"a" "b`} {
+		got, err := ToTSQuery(query)
+		if err == nil || errors.Is(err, ErrEmptyQuery) {
+			t.Errorf("ToTSQuery(%q) = %q, %v, want an unterminated quote error", query, got, err)
+		}
+	}
+}
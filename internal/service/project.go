@@ -0,0 +1,20 @@
+package service
+
+import (
+	"HL_project_management/internal/model"
+	"errors"
+	"time"
+)
+
+// PrepareNewProject checks the dates of a project about to be created and
+// fills in the defaults.
+func PrepareNewProject(project *model.Project) error {
+	project.StartDate = time.Now()
+	if project.EndDate.Before(project.StartDate) && !project.EndDate.IsZero() {
+		return errors.New("End date should be after start date")
+	}
+	if project.EndDate.IsZero() {
+		project.EndDate = time.Now().AddDate(1, 0, 0)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+// Package service holds what the REST and gRPC APIs do around the repository
+// when tasks and projects change: filling in defaults, adding watchers,
+// publishing events and removing stored files.
+package service
+
+import (
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/storage"
+	"context"
+)
+
+type Config struct {
+	// Blobs keeps the content of attachments.
+	Blobs storage.BlobStore
+}
+
+var config Config
+
+func Configure(cfg Config) {
+	config = cfg
+}
+
+// DeleteBlobs removes the stored content of attachments whose metadata is
+// gone, even if the request that deleted them has been cancelled since.
+func DeleteBlobs(ctx context.Context, list []model.Attachment) {
+	ctx = context.WithoutCancel(ctx)
+	for _, a := range list {
+		if err := config.Blobs.Delete(ctx, a.StorageKey); err != nil {
+			logging.FromContext(ctx).Error("could not delete blob", "key", a.StorageKey, "error", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"HL_project_management/internal/events"
+	"HL_project_management/internal/mention"
+	"HL_project_management/internal/model"
+	"HL_project_management/internal/repository"
+	"context"
+	"errors"
+	"time"
+)
+
+// PrepareNewTask checks the dates of a task about to be created. A task
+// without a due date keeps none.
+func PrepareNewTask(task *model.Task) error {
+	task.CreatedAt = time.Now()
+	if task.CompletedAt.Before(task.CreatedAt) && !task.CompletedAt.IsZero() {
+		return errors.New("Completed date should be after created date")
+	}
+	if task.DueAt.Before(task.CreatedAt) && !task.DueAt.IsZero() {
+		return errors.New("Due date should be after created date")
+	}
+	return nil
+}
+
+// AnnounceTaskCreated makes the assignee watch a new task and announces it.
+func AnnounceTaskCreated(ctx context.Context, task model.Task, actorID int) {
+	Watch(ctx, task.ID, task.AssigneeID, actorID)
+	events.Publish(events.Event{
+		Type:      events.TaskCreated,
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		UserID:    task.AssigneeID,
+		ActorID:   actorID,
+		Mentioned: WatchMentions(ctx, task.ID, mention.Parse(task.Description)),
+	})
+}
+
+// AnnounceTaskUpdated makes a new assignee watch the task and announces the change.
+func AnnounceTaskUpdated(ctx context.Context, existing, task model.Task, actorID int) {
+	event := events.Event{
+		Type:      events.TaskUpdated,
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		ActorID:   actorID,
+		Mentioned: WatchMentions(ctx, task.ID, mention.New(existing.Description, task.Description)),
+	}
+	if task.AssigneeID != existing.AssigneeID {
+		Watch(ctx, task.ID, task.AssigneeID)
+		event.UserID = task.AssigneeID
+	}
+	events.Publish(event)
+}
+
+// RemoveTask deletes a task along with the blobs of its attachments and
+// announces it.
+func RemoveTask(ctx context.Context, task model.Task, actorID int) error {
+	files, err := repository.GetAttachmentsByTaskID(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if err := repository.DeleteTask(ctx, task.ID); err != nil {
+		return err
+	}
+	DeleteBlobs(ctx, files)
+	AnnounceTaskDeleted(task, actorID)
+	return nil
+}
+
+// AnnounceTaskDeleted announces that a task is gone.
+func AnnounceTaskDeleted(task model.Task, actorID int) {
+	events.Publish(events.Event{
+		Type:      events.TaskDeleted,
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		UserID:    task.AssigneeID,
+		ActorID:   actorID,
+	})
+}
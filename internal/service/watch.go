@@ -0,0 +1,32 @@
+package service
+
+import (
+	"HL_project_management/internal/logging"
+	"HL_project_management/internal/repository"
+	"context"
+)
+
+// Watch makes the given users watch a task. Failures are logged rather than
+// reported, since the change that triggered them has already been saved.
+func Watch(ctx context.Context, taskID int, userIDs ...int) {
+	for _, userID := range userIDs {
+		if userID == 0 {
+			continue
+		}
+		if err := repository.WatchTask(ctx, taskID, userID); err != nil {
+			logging.FromContext(ctx).Error("could not add watcher", "user_id", userID, "task_id", taskID, "error", err)
+		}
+	}
+}
+
+// WatchMentions makes the users behind the mentioned handles watch a task and
+// returns their IDs.
+func WatchMentions(ctx context.Context, taskID int, handles []string) []int {
+	ids, err := repository.GetUserIDsByHandles(ctx, handles)
+	if err != nil {
+		logging.FromContext(ctx).Error("could not resolve mentions", "task_id", taskID, "error", err)
+		return nil
+	}
+	Watch(ctx, taskID, ids...)
+	return ids
+}
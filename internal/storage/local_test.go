@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newLocalStore(t *testing.T) (*LocalStore, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "blobs")
+	s, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, dir
+}
+
+func readObject(t *testing.T, s BlobStore, key string) string {
+	t.Helper()
+	r, err := s.Open(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newLocalStore(t)
+	const key = "attachments/1/report.txt"
+
+	if err := s.Put(ctx, key, strings.NewReader("first"), 5, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readObject(t, s, key); got != "first" {
+		t.Errorf("object = %q, want first", got)
+	}
+	if err := s.Put(ctx, key, strings.NewReader("second"), 6, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readObject(t, s, key); got != "second" {
+		t.Errorf("replaced object = %q, want second", got)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing object: %v", err)
+	}
+	if _, err := s.Open(ctx, "never/stored"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open of a missing object: %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStorePathTraversal(t *testing.T) {
+	ctx := context.Background()
+	s, dir := newLocalStore(t)
+	outside := filepath.Join(filepath.Dir(dir), "outside")
+
+	for _, key := range []string{"", "/", "..", "../outside", "a/../../outside", "a/../b", `..\outside`} {
+		if err := s.Put(ctx, key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if _, err := s.Open(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) = %v, want an invalid key error", key, err)
+		}
+		if err := s.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+	}
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("a file was written outside the store: %v", err)
+	}
+
+	// Absolute keys stay below the directory.
+	if err := s.Put(ctx, "/etc/passwd", strings.NewReader("x"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "etc", "passwd")); err != nil {
+		t.Errorf("absolute key not stored below the directory: %v", err)
+	}
+}
+
+// failingReader returns its data and then an error.
+type failingReader struct {
+	data string
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestLocalStorePutIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	s, dir := newLocalStore(t)
+	const key = "a/object"
+	if err := s.Put(ctx, key, strings.NewReader("original"), 8, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		r    io.Reader
+		size int64
+	}{
+		{"read error", &failingReader{data: "partial"}, 100},
+		{"short body", strings.NewReader("short"), 100},
+		{"long body", strings.NewReader("longer than announced"), 4},
+	}
+	for _, tt := range tests {
+		if err := s.Put(ctx, key, tt.r, tt.size, ""); err == nil {
+			t.Errorf("%s: Put succeeded", tt.name)
+		}
+		if got := readObject(t, s, key); got != "original" {
+			t.Errorf("%s: object = %q, want the original", tt.name, got)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("files left behind: %q", names)
+	}
+}
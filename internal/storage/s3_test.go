@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "eu-central-1"
+	testBucket    = "pm"
+)
+
+// s3StandIn is a bucket served over HTTP that accepts only requests signed
+// with testSecretKey.
+type s3StandIn struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	ranges   []string
+	rejected []error
+}
+
+func newS3StandIn(t *testing.T) (*S3Store, *s3StandIn) {
+	t.Helper()
+	stub := &s3StandIn{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	s, err := NewS3Store(S3Config{
+		Endpoint:  srv.URL,
+		Region:    testRegion,
+		Bucket:    testBucket,
+		AccessKey: testAccessKey,
+		SecretKey: testSecretKey,
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, stub
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := checkSignature(r); err != nil {
+		s.rejected = append(s.rejected, fmt.Errorf("%s %s: %w", r.Method, r.URL.Path, err))
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+testBucket+"/")
+	if !ok {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(body)) != r.ContentLength {
+			http.Error(w, "IncompleteBody", http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = body
+	case http.MethodHead, http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		if rng := r.Header.Get("Range"); rng != "" {
+			s.ranges = append(s.ranges, rng)
+			from, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			if err != nil || from >= len(data) {
+				http.Error(w, "InvalidRange", http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", from, len(data)-1, len(data)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)-from))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(data[from:])
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		if _, ok := s.objects[key]; !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// checkSignature verifies the AWS Signature Version 4 of r the way S3 does.
+func checkSignature(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	var credential, signedHeaders, signature string
+	for _, part := range strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 "), ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		switch name {
+		case "Credential":
+			credential = value
+		case "SignedHeaders":
+			signedHeaders = value
+		case "Signature":
+			signature = value
+		}
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") || credential == "" || signature == "" {
+		return fmt.Errorf("malformed Authorization header %q", auth)
+	}
+	accessKey, scope, _ := strings.Cut(credential, "/")
+	scopeParts := strings.Split(scope, "/")
+	if accessKey != testAccessKey || len(scopeParts) != 4 || scopeParts[1] != testRegion || scopeParts[2] != "s3" || scopeParts[3] != "aws4_request" {
+		return fmt.Errorf("unexpected credential %q", credential)
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil || !strings.HasPrefix(amzDate, scopeParts[0]) || time.Since(signedAt).Abs() > 15*time.Minute {
+		return fmt.Errorf("bad X-Amz-Date %q for scope %q", amzDate, scope)
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, h := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(h)
+		if h == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(value) + "\n")
+	}
+	if !strings.Contains(signedHeaders, "host") || !strings.Contains(signedHeaders, "x-amz-date") {
+		return fmt.Errorf("host and x-amz-date are not signed: %q", signedHeaders)
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range scopeParts {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	if want := hex.EncodeToString(mac.Sum(nil)); !hmac.Equal([]byte(signature), []byte(want)) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	s, stub := newS3StandIn(t)
+	const key = "attachments/1/report 2024.txt"
+	const content = "0123456789abcdef"
+
+	if err := s.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readObject(t, s, key); got != content {
+		t.Errorf("object = %q, want %q", got, content)
+	}
+
+	r, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if pos, err := r.Seek(-6, io.SeekEnd); err != nil || pos != 10 {
+		t.Fatalf("Seek = %d, %v, want 10", pos, err)
+	}
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "abc" {
+		t.Errorf("read after Seek = %q, %v, want abc", buf, err)
+	}
+	if _, err := r.Seek(2, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil || string(rest) != content[2:] {
+		t.Errorf("read after second Seek = %q, %v, want %q", rest, err, content[2:])
+	}
+	want := []string{"bytes=0-", "bytes=10-", "bytes=2-"}
+	if fmt.Sprint(stub.ranges) != fmt.Sprint(want) {
+		t.Errorf("ranges requested = %q, want %q", stub.ranges, want)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing object: %v", err)
+	}
+	for _, err := range stub.rejected {
+		t.Errorf("signature rejected: %v", err)
+	}
+}
+
+func TestS3StoreRejectedSignature(t *testing.T) {
+	s, stub := newS3StandIn(t)
+	s.cfg.SecretKey = "wrong"
+	err := s.Put(context.Background(), "key", strings.NewReader("x"), 1, "")
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put with a wrong secret: %v, want a 403 error", err)
+	}
+	if len(stub.rejected) != 1 {
+		t.Errorf("stand-in rejected %d requests, want 1", len(stub.rejected))
+	}
+}
@@ -0,0 +1,21 @@
+// Package storage keeps binary objects such as task attachments outside of
+// the database.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// BlobStore stores opaque objects under string keys.
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns a seekable reader over the object stored under key, or
+	// ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
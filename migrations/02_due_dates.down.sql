@@ -0,0 +1,7 @@
+drop table if exists notifications;
+
+drop index if exists tasks_due_at_idx;
+
+alter table tasks drop column if exists overdue_notified_at;
+alter table tasks drop column if exists reminded_at;
+alter table tasks drop column if exists due_at;
@@ -0,0 +1,20 @@
+alter table tasks add column if not exists due_at timestamp;
+alter table tasks add column if not exists reminded_at timestamp;
+alter table tasks add column if not exists overdue_notified_at timestamp;
+
+-- completed_at used to be defaulted one month ahead and doubled as a deadline
+update tasks set due_at = completed_at, completed_at = null where completed_at > now();
+
+create index if not exists tasks_due_at_idx on tasks (due_at) where status <> 'done';
+
+create table IF NOT EXISTS notifications (
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    type varchar(50) not null,
+    task_id int references tasks(id) on delete cascade,
+    message text not null,
+    created_at timestamp not null,
+    read_at timestamp
+);
+
+create index if not exists notifications_user_id_idx on notifications (user_id, created_at desc);
@@ -0,0 +1,3 @@
+drop table if exists comments;
+drop table if exists project_watchers;
+drop table if exists task_watchers;
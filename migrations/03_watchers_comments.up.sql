@@ -0,0 +1,21 @@
+create table IF NOT EXISTS task_watchers (
+    task_id int not null references tasks(id) on delete cascade,
+    user_id int not null references users(id) on delete cascade,
+    primary key (task_id, user_id)
+);
+
+create table IF NOT EXISTS project_watchers (
+    project_id int not null references projects(id) on delete cascade,
+    user_id int not null references users(id) on delete cascade,
+    primary key (project_id, user_id)
+);
+
+create table IF NOT EXISTS comments (
+    id serial primary key,
+    task_id int not null references tasks(id) on delete cascade,
+    author_id int not null references users(id),
+    body text not null,
+    created_at timestamp not null
+);
+
+create index if not exists comments_task_id_idx on comments (task_id, created_at);
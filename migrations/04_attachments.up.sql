@@ -0,0 +1,13 @@
+create table IF NOT EXISTS attachments (
+    id serial primary key,
+    task_id int not null references tasks(id) on delete cascade,
+    uploader_id int references users(id) on delete set null,
+    file_name varchar(255) not null,
+    content_type varchar(255) not null,
+    size bigint not null,
+    checksum char(64) not null,
+    storage_key varchar(255) not null unique,
+    created_at timestamp not null
+);
+
+create index if not exists attachments_task_id_idx on attachments (task_id);
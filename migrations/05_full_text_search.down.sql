@@ -0,0 +1,11 @@
+drop index if exists users_search_idx;
+alter table users drop column if exists search_vector;
+
+drop index if exists comments_search_idx;
+alter table comments drop column if exists search_vector;
+
+drop index if exists projects_search_idx;
+alter table projects drop column if exists search_vector;
+
+drop index if exists tasks_search_idx;
+alter table tasks drop column if exists search_vector;
@@ -0,0 +1,25 @@
+-- The 'simple' configuration does not stem, so it works the same for Russian
+-- and English text; prefix queries (word*) make up for the missing stemming.
+alter table tasks add column if not exists search_vector tsvector generated always as (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) stored;
+create index if not exists tasks_search_idx on tasks using gin (search_vector);
+
+alter table projects add column if not exists search_vector tsvector generated always as (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) stored;
+create index if not exists projects_search_idx on projects using gin (search_vector);
+
+alter table comments add column if not exists search_vector tsvector generated always as (
+    to_tsvector('simple', coalesce(body, ''))
+) stored;
+create index if not exists comments_search_idx on comments using gin (search_vector);
+
+alter table users add column if not exists search_vector tsvector generated always as (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    -- split emails into words so that both "john" and "example" find john@example.com
+    setweight(to_tsvector('simple', regexp_replace(coalesce(email, ''), '[^[:alnum:]]+', ' ', 'g')), 'B')
+) stored;
+create index if not exists users_search_idx on users using gin (search_vector);
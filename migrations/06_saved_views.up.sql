@@ -0,0 +1,14 @@
+create table IF NOT EXISTS saved_views (
+    id serial primary key,
+    owner_id int not null references users(id) on delete cascade,
+    project_id int references projects(id) on delete cascade,
+    name varchar(100) not null,
+    filter text not null,
+    sort varchar(100) not null default '',
+    shared boolean not null default false,
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+create index if not exists saved_views_owner_id_idx on saved_views (owner_id);
+create index if not exists saved_views_project_id_idx on saved_views (project_id) where shared;
@@ -0,0 +1,13 @@
+create table IF NOT EXISTS idempotency_keys (
+    user_id int not null default 0,
+    key varchar(255) not null,
+    request_hash char(64) not null,
+    status int,
+    content_type varchar(255),
+    body bytea,
+    created_at timestamp not null,
+    expires_at timestamp not null,
+    primary key (user_id, key)
+);
+
+create index if not exists idempotency_keys_expires_at_idx on idempotency_keys (expires_at);
@@ -0,0 +1,8 @@
+create table IF NOT EXISTS rate_limits (
+    key varchar(255) primary key,
+    tokens double precision not null,
+    allowed boolean not null,
+    updated_at timestamptz not null
+);
+
+create index if not exists rate_limits_updated_at_idx on rate_limits (updated_at);
@@ -0,0 +1,13 @@
+create table IF NOT EXISTS api_keys (
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    name varchar(100) not null,
+    scopes text[] not null,
+    prefix varchar(16) not null,
+    hash char(64) not null unique,
+    expires_at timestamp,
+    last_used_at timestamp,
+    created_at timestamp not null
+);
+
+create index if not exists api_keys_user_id_idx on api_keys (user_id);
@@ -0,0 +1,12 @@
+-- Longer titles and descriptions are cut to fit.
+drop index if exists tasks_search_idx;
+alter table tasks drop column if exists search_vector;
+
+alter table tasks alter column title type varchar(50) using left(title, 50);
+alter table tasks alter column description type varchar(50) using left(description, 50);
+
+alter table tasks add column search_vector tsvector generated always as (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) stored;
+create index tasks_search_idx on tasks using gin (search_vector);
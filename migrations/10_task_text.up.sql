@@ -0,0 +1,14 @@
+-- Imported and typed-in task titles and descriptions are often longer than
+-- 50 characters. The search vector is generated from both columns, so it is
+-- dropped while their type changes and then generated again.
+drop index if exists tasks_search_idx;
+alter table tasks drop column if exists search_vector;
+
+alter table tasks alter column title type text;
+alter table tasks alter column description type text;
+
+alter table tasks add column search_vector tsvector generated always as (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) stored;
+create index tasks_search_idx on tasks using gin (search_vector);
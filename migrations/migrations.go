@@ -0,0 +1,8 @@
+// Package migrations holds the SQL migrations, embedded so that the service
+// binary can apply them from any working directory.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS